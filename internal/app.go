@@ -4,10 +4,15 @@ import (
 	"context"
 
 	"github.com/apiarycd/apiarycd/internal/config"
+	"github.com/apiarycd/apiarycd/internal/declarative"
 	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/git"
+	"github.com/apiarycd/apiarycd/internal/poller"
+	"github.com/apiarycd/apiarycd/internal/reconciler"
 	"github.com/apiarycd/apiarycd/internal/server"
 	"github.com/apiarycd/apiarycd/internal/stacks"
 	"github.com/apiarycd/apiarycd/internal/swarm"
+	"github.com/apiarycd/apiarycd/internal/webhooks"
 	"github.com/apiarycd/apiarycd/pkg/badgerfx"
 	"github.com/apiarycd/apiarycd/pkg/dockerfx"
 	"github.com/apiarycd/apiarycd/pkg/openapifx"
@@ -35,11 +40,16 @@ func Run(version healthfx.Version) {
 		config.Module(),
 		server.Module(),
 		swarm.Module(),
+		git.Module(),
+		reconciler.Module(),
 		//
 		// BUSINESS MODULES
 		fx.Supply(version),
 		stacks.Module(),
 		deployments.Module(),
+		poller.Module(),
+		webhooks.Module(),
+		declarative.Module(),
 		//
 		// LIFECYCLE MANAGEMENT
 		fx.Invoke(func(lc fx.Lifecycle, logger *zap.Logger) {
@@ -0,0 +1,55 @@
+package compose
+
+import (
+	"github.com/moby/moby/api/types/swarm"
+)
+
+const (
+	// LabelNamespace is set on every resource of a stack. It is compatible
+	// with `docker stack deploy`.
+	LabelNamespace = "com.docker.stack.namespace"
+	// LabelImage holds the image requested in the compose file.
+	LabelImage = "com.docker.stack.image"
+
+	// DefaultNetwork is attached to services that do not list any networks.
+	DefaultNetwork = "default"
+)
+
+// ReadFileFunc returns the contents of a file referenced by the compose file.
+// path is relative to the directory of the compose file.
+type ReadFileFunc func(path string) ([]byte, error)
+
+// Options controls loading of a compose file.
+type Options struct {
+	Namespace string            // Stack name used to prefix and label resources
+	Variables map[string]string // Values for ${VAR} interpolation
+	ReadFile  ReadFileFunc      // Reads env files, configs and secrets
+}
+
+// Network is an overlay network owned by the stack.
+type Network struct {
+	Name       string
+	Driver     string
+	DriverOpts map[string]string
+	Labels     map[string]string
+	Attachable bool
+	Internal   bool
+}
+
+// Stack is the set of Swarm resources described by a compose file.
+//
+// External networks, configs and secrets are referenced by name only and are
+// not part of the stack. Config and secret references carry the name only;
+// the ID has to be resolved before the service is created.
+//
+// Configs and secrets are immutable in Swarm, so their names are suffixed
+// with a digest of the content unless the compose file sets a name
+// explicitly.
+type Stack struct {
+	Namespace string
+
+	Services []swarm.ServiceSpec
+	Networks []Network
+	Configs  []swarm.ConfigSpec
+	Secrets  []swarm.SecretSpec
+}
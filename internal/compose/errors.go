@@ -0,0 +1,10 @@
+package compose
+
+import "errors"
+
+var (
+	ErrInvalid     = errors.New("invalid compose file")
+	ErrUnsupported = errors.New("unsupported compose feature")
+
+	ErrMissingVariable = errors.New("missing required variable")
+)
@@ -0,0 +1,6 @@
+package compose
+
+// Interpolate exposes interpolate to the tests.
+func Interpolate(s string, variables map[string]string) (string, error) {
+	return interpolate(s, variables)
+}
@@ -0,0 +1,175 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// interpolateNode substitutes variables in all scalar values of the tree.
+// Mapping keys are left untouched.
+func interpolateNode(node *yaml.Node, variables map[string]string) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		value, err := interpolate(node.Value, variables)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+
+		if value != node.Value {
+			node.Value = value
+			// Let the decoder resolve the type of the substituted value.
+			node.Tag = ""
+			node.Style &^= yaml.TaggedStyle
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := interpolateNode(node.Content[i], variables); err != nil {
+				return err
+			}
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := interpolateNode(child, variables); err != nil {
+				return err
+			}
+		}
+	case yaml.AliasNode:
+	}
+
+	return nil
+}
+
+// interpolate substitutes $VAR, ${VAR}, ${VAR:-default}, ${VAR-default},
+// ${VAR:?error}, ${VAR?error}, ${VAR:+replacement} and ${VAR+replacement} in
+// s. $$ is an escaped $. Unset variables without a default are replaced with
+// an empty string.
+func interpolate(s string, variables map[string]string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var result strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i == len(s)-1 {
+			result.WriteByte(s[i])
+			continue
+		}
+
+		switch next := s[i+1]; {
+		case next == '$':
+			result.WriteByte('$')
+			i++
+		case next == '{':
+			end := closingBrace(s, i+2) //nolint:mnd // skip "${"
+			if end < 0 {
+				return "", fmt.Errorf("%w: unterminated variable in %q", ErrInvalid, s)
+			}
+
+			value, err := expand(s[i+2:end], variables)
+			if err != nil {
+				return "", err
+			}
+
+			result.WriteString(value)
+			i = end
+		case isNameStart(next):
+			end := i + 1
+			for end < len(s) && isNameChar(s[end]) {
+				end++
+			}
+
+			result.WriteString(variables[s[i+1:end]])
+			i = end - 1
+		default:
+			result.WriteByte('$')
+		}
+	}
+
+	return result.String(), nil
+}
+
+// expand evaluates the contents of a braced variable.
+func expand(expr string, variables map[string]string) (string, error) {
+	end := 0
+	for end < len(expr) && isNameChar(expr[end]) {
+		end++
+	}
+
+	name, rest := expr[:end], expr[end:]
+	if name == "" || !isNameStart(name[0]) {
+		return "", fmt.Errorf("%w: invalid variable name in \"${%s}\"", ErrInvalid, expr)
+	}
+
+	value, isSet := variables[name]
+	if rest == "" {
+		return value, nil
+	}
+
+	// With a colon, an empty value is treated like an unset one.
+	op, arg := rest[:1], rest[1:]
+	if op == ":" && len(rest) > 1 {
+		op, arg = rest[:2], rest[2:]
+		isSet = isSet && value != ""
+	}
+
+	switch op {
+	case "-", ":-":
+		if isSet {
+			return value, nil
+		}
+		return interpolate(arg, variables)
+	case "+", ":+":
+		if !isSet {
+			return "", nil
+		}
+		return interpolate(arg, variables)
+	case "?", ":?":
+		if isSet {
+			return value, nil
+		}
+
+		message, err := interpolate(arg, variables)
+		if err != nil {
+			return "", err
+		}
+		if message == "" {
+			message = "must be set"
+		}
+
+		return "", fmt.Errorf("%w %q: %s", ErrMissingVariable, name, message)
+	default:
+		return "", fmt.Errorf("%w: invalid variable expression \"${%s}\"", ErrInvalid, expr)
+	}
+}
+
+// closingBrace returns the index of the brace closing the variable that
+// starts at start, accounting for nested variables in defaults.
+func closingBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '$':
+			i++
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
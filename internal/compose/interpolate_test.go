@@ -0,0 +1,134 @@
+package compose_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/compose"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Parallel()
+
+	variables := map[string]string{
+		"TAG":    "1.2",
+		"EMPTY":  "",
+		"NAME":   "web",
+		"PREFIX": "app",
+	}
+
+	tests := []struct {
+		name    string
+		s       string
+		want    string
+		wantErr error
+	}{
+		{name: "no variables", s: "nginx:latest", want: "nginx:latest"},
+		{name: "braced", s: "nginx:${TAG}", want: "nginx:1.2"},
+		{name: "unbraced", s: "nginx:$TAG", want: "nginx:1.2"},
+		{name: "unbraced followed by text", s: "$NAME-$TAG.conf", want: "web-1.2.conf"},
+		{name: "unset", s: "[${UNSET}] [$UNSET]", want: "[] []"},
+		{name: "default for unset", s: "${UNSET:-d} ${UNSET-d}", want: "d d"},
+		{name: "default for empty with colon", s: "${EMPTY:-d}", want: "d"},
+		{name: "default for empty without colon", s: "${EMPTY-d}", want: ""},
+		{name: "default not used", s: "${TAG:-d} ${TAG-d}", want: "1.2 1.2"},
+		{name: "empty default", s: "${UNSET:-}", want: ""},
+		{name: "replacement", s: "${TAG:+set} ${EMPTY+set} ${EMPTY:+set} ${UNSET+set}", want: "set set  "},
+		{name: "required set", s: "${TAG:?missing} ${EMPTY?missing}", want: "1.2 "},
+		{name: "nested default", s: "${UNSET:-${NAME}}", want: "web"},
+		{name: "nested default of a default", s: "${UNSET:-${OTHER:-${PREFIX}_x}}", want: "app_x"},
+		{name: "nested default not used", s: "${TAG:-${UNSET:?missing}}", want: "1.2"},
+		{name: "escaped", s: "$$TAG", want: "$TAG"},
+		{name: "escaped braced", s: "$${TAG}", want: "${TAG}"},
+		{name: "escaped in default", s: "${UNSET:-$$1}", want: "$1"},
+		{name: "escaped brace in default", s: "${UNSET:-$${X}}", want: "${X}"},
+		{name: "lone dollar", s: "cost: 5$ or $", want: "cost: 5$ or $"},
+		{name: "dollar before digit", s: "$1", want: "$1"},
+		{name: "required unset", s: "${UNSET:?is needed}", wantErr: compose.ErrMissingVariable},
+		{name: "required empty with colon", s: "${EMPTY:?is needed}", wantErr: compose.ErrMissingVariable},
+		{name: "required unset without colon", s: "${UNSET?is needed}", wantErr: compose.ErrMissingVariable},
+		{name: "required in nested default", s: "${UNSET:-${OTHER:?is needed}}", wantErr: compose.ErrMissingVariable},
+		{name: "unterminated", s: "nginx:${TAG", wantErr: compose.ErrInvalid},
+		{name: "unterminated nested", s: "${UNSET:-${TAG}", wantErr: compose.ErrInvalid},
+		{name: "empty name", s: "${}", wantErr: compose.ErrInvalid},
+		{name: "invalid name", s: "${1TAG}", wantErr: compose.ErrInvalid},
+		{name: "invalid operator", s: "${TAG/1/2}", wantErr: compose.ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := compose.Interpolate(tt.s, variables)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Interpolate(%q) error = %v, want %v", tt.s, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("Interpolate(%q) = %q, want %q", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpolateMissingMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "${DB_PASSWORD:?set it in the stack variables}", want: `"DB_PASSWORD": set it in the stack variables`},
+		{s: "${DB_PASSWORD:?}", want: `"DB_PASSWORD": must be set`},
+		{s: "${DB_PASSWORD:?$HOST is down}", want: `"DB_PASSWORD": db is down`},
+	}
+
+	for _, tt := range tests {
+		_, err := compose.Interpolate(tt.s, map[string]string{"HOST": "db"})
+		if !errors.Is(err, compose.ErrMissingVariable) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Interpolate(%q) error = %v, want %v with %s", tt.s, err, compose.ErrMissingVariable, tt.want)
+		}
+	}
+}
+
+func TestLoadInterpolation(t *testing.T) {
+	t.Parallel()
+
+	data := `services:
+  web:
+    image: nginx:${TAG:-latest}
+    environment:
+      PORT: ${PORT}
+      LITERAL: $${NOT_INTERPOLATED}
+    deploy:
+      replicas: ${REPLICAS}
+`
+
+	stack, err := compose.Load([]byte(data), compose.Options{
+		Namespace: "app",
+		Variables: map[string]string{"PORT": "8080", "REPLICAS": "3"},
+		ReadFile:  nil,
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	spec := stack.Services[0]
+	if got := spec.TaskTemplate.ContainerSpec.Image; got != "nginx:latest" {
+		t.Errorf("image = %q, want %q", got, "nginx:latest")
+	}
+	// Substituted values are typed by the decoder.
+	if got := *spec.Mode.Replicated.Replicas; got != 3 {
+		t.Errorf("replicas = %d, want 3", got)
+	}
+	env := strings.Join(spec.TaskTemplate.ContainerSpec.Env, " ")
+	if !strings.Contains(env, "PORT=8080") || !strings.Contains(env, "LITERAL=${NOT_INTERPOLATED}") {
+		t.Errorf("env = %q, want PORT=8080 and LITERAL=${NOT_INTERPOLATED}", env)
+	}
+
+	required := "services:\n  web:\n    image: nginx:${TAG:?pin the image}\n"
+	_, err = compose.Load([]byte(required), compose.Options{Namespace: "app", Variables: nil, ReadFile: nil})
+	if !errors.Is(err, compose.ErrMissingVariable) || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Load() error = %v, want %v on line 3", err, compose.ErrMissingVariable)
+	}
+}
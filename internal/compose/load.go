@@ -0,0 +1,314 @@
+package compose
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/moby/moby/api/types/mount"
+	"github.com/moby/moby/api/types/swarm"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	defaultNetworkDriver = "overlay"
+	digestLength         = 10
+)
+
+type translator struct {
+	project *project
+	opts    Options
+
+	networks map[string]string // compose key -> Swarm name
+	volumes  map[string]*mount.VolumeOptions
+	names    map[string]string // compose volume key -> Swarm name
+	configs  map[string]string // compose key -> Swarm name
+	secrets  map[string]string // compose key -> Swarm name
+
+	stack *Stack
+}
+
+// Load parses a compose file, interpolates opts.Variables into it and
+// translates it into Swarm resources namespaced by opts.Namespace.
+func Load(data []byte, opts Options) (*Stack, error) {
+	if opts.Namespace == "" {
+		return nil, fmt.Errorf("%w: namespace is required", ErrInvalid)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+
+	if err := interpolateNode(&root, opts.Variables); err != nil {
+		return nil, err
+	}
+
+	p := new(project)
+	if err := root.Decode(p); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+
+	if len(p.Services) == 0 {
+		return nil, fmt.Errorf("%w: no services defined", ErrInvalid)
+	}
+	for name, s := range p.Services {
+		if s == nil {
+			return nil, fmt.Errorf("%w: service %q has no definition", ErrInvalid, name)
+		}
+	}
+
+	t := &translator{
+		project: p,
+		opts:    opts,
+
+		networks: make(map[string]string),
+		volumes:  make(map[string]*mount.VolumeOptions),
+		names:    make(map[string]string),
+		configs:  make(map[string]string),
+		secrets:  make(map[string]string),
+
+		stack: &Stack{
+			Namespace: opts.Namespace,
+			Services:  []swarm.ServiceSpec{},
+			Networks:  []Network{},
+			Configs:   []swarm.ConfigSpec{},
+			Secrets:   []swarm.SecretSpec{},
+		},
+	}
+
+	return t.translate()
+}
+
+func (t *translator) translate() (*Stack, error) {
+	t.translateNetworks()
+	t.translateVolumes()
+
+	if err := t.translateConfigs(); err != nil {
+		return nil, err
+	}
+	if err := t.translateSecrets(); err != nil {
+		return nil, err
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(t.project.Services)) {
+		spec, err := t.translateService(name, t.project.Services[name])
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %w", name, err)
+		}
+
+		t.stack.Services = append(t.stack.Services, spec)
+	}
+
+	return t.stack, nil
+}
+
+func (t *translator) translateNetworks() {
+	networks := t.project.Networks
+	if networks == nil {
+		networks = make(map[string]*network)
+	}
+
+	// The default network is created implicitly when a service does not list
+	// any networks.
+	if _, ok := networks[DefaultNetwork]; !ok && t.usesDefaultNetwork() {
+		networks[DefaultNetwork] = nil
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(networks)) {
+		n := networks[key]
+		if n == nil {
+			n = new(network)
+		}
+
+		if n.External {
+			t.networks[key] = externalName(key, n.Name)
+			continue
+		}
+
+		name := t.scoped(key, n.Name)
+		t.networks[key] = name
+
+		driver := n.Driver
+		if driver == "" {
+			driver = defaultNetworkDriver
+		}
+
+		t.stack.Networks = append(t.stack.Networks, Network{
+			Name:       name,
+			Driver:     driver,
+			DriverOpts: n.DriverOpts,
+			Labels:     t.labels(n.Labels),
+			Attachable: n.Attachable,
+			Internal:   n.Internal,
+		})
+	}
+}
+
+func (t *translator) usesDefaultNetwork() bool {
+	for _, s := range t.project.Services {
+		if len(s.Networks) == 0 {
+			return true
+		}
+		if _, ok := s.Networks[DefaultNetwork]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *translator) translateVolumes() {
+	for key, v := range t.project.Volumes {
+		if v == nil {
+			v = new(volume)
+		}
+
+		if v.External {
+			t.names[key] = externalName(key, v.Name)
+			t.volumes[key] = nil
+			continue
+		}
+
+		t.names[key] = t.scoped(key, v.Name)
+
+		var opts mount.VolumeOptions
+		opts.Labels = t.labels(v.Labels)
+		if v.Driver != "" || len(v.DriverOpts) > 0 {
+			var driver mount.Driver
+			driver.Name = v.Driver
+			driver.Options = v.DriverOpts
+			opts.DriverConfig = &driver
+		}
+
+		t.volumes[key] = &opts
+	}
+}
+
+func (t *translator) translateConfigs() error {
+	for _, key := range slices.Sorted(maps.Keys(t.project.Configs)) {
+		c := t.project.Configs[key]
+		if c == nil {
+			return fmt.Errorf("%w: config %q has no definition", ErrInvalid, key)
+		}
+
+		if c.External {
+			t.configs[key] = externalName(key, c.Name)
+			continue
+		}
+
+		data, err := t.fileData(c)
+		if err != nil {
+			return fmt.Errorf("config %q: %w", key, err)
+		}
+
+		var spec swarm.ConfigSpec
+		spec.Name = t.versioned(key, c.Name, data)
+		spec.Labels = t.labels(c.Labels)
+		spec.Data = data
+
+		t.configs[key] = spec.Name
+		t.stack.Configs = append(t.stack.Configs, spec)
+	}
+
+	return nil
+}
+
+func (t *translator) translateSecrets() error {
+	for _, key := range slices.Sorted(maps.Keys(t.project.Secrets)) {
+		s := t.project.Secrets[key]
+		if s == nil {
+			return fmt.Errorf("%w: secret %q has no definition", ErrInvalid, key)
+		}
+
+		if s.External {
+			t.secrets[key] = externalName(key, s.Name)
+			continue
+		}
+
+		if s.Content != "" {
+			return fmt.Errorf("%w: secret %q: inline content", ErrUnsupported, key)
+		}
+
+		data, err := t.fileData(s)
+		if err != nil {
+			return fmt.Errorf("secret %q: %w", key, err)
+		}
+
+		var spec swarm.SecretSpec
+		spec.Name = t.versioned(key, s.Name, data)
+		spec.Labels = t.labels(s.Labels)
+		spec.Data = data
+
+		t.secrets[key] = spec.Name
+		t.stack.Secrets = append(t.stack.Secrets, spec)
+	}
+
+	return nil
+}
+
+func (t *translator) fileData(obj *fileObject) ([]byte, error) {
+	switch {
+	case obj.File != "":
+		return t.readFile(obj.File)
+	case obj.Content != "":
+		return []byte(obj.Content), nil
+	case obj.Environment != "":
+		return nil, fmt.Errorf("%w: environment source", ErrUnsupported)
+	default:
+		return nil, fmt.Errorf("%w: one of file or content is required", ErrInvalid)
+	}
+}
+
+func (t *translator) readFile(path string) ([]byte, error) {
+	if t.opts.ReadFile == nil {
+		return nil, fmt.Errorf("%w: file %q: no file source", ErrUnsupported, path)
+	}
+
+	data, err := t.opts.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", path, err)
+	}
+
+	return data, nil
+}
+
+// scoped returns name if it is set explicitly, or the compose key prefixed
+// with the namespace.
+func (t *translator) scoped(key, name string) string {
+	if name != "" {
+		return name
+	}
+
+	return t.opts.Namespace + "_" + key
+}
+
+// versioned is like scoped, but suffixes generated names with a digest of data.
+func (t *translator) versioned(key, name string, data []byte) string {
+	if name != "" {
+		return name
+	}
+
+	sum := sha256.Sum256(data)
+	return t.scoped(key, "") + "_" + hex.EncodeToString(sum[:])[:digestLength]
+}
+
+// labels returns a copy of labels with the namespace label set.
+func (t *translator) labels(labels map[string]string) map[string]string {
+	result := make(map[string]string, len(labels)+1)
+	maps.Copy(result, labels)
+	result[LabelNamespace] = t.opts.Namespace
+
+	return result
+}
+
+// externalName returns the name of an external resource.
+func externalName(key, name string) string {
+	if name != "" {
+		return name
+	}
+
+	return key
+}
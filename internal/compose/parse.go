@@ -0,0 +1,206 @@
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	nanoCPUs    = 1e9
+	bytesFactor = 1024
+)
+
+// splitShell splits s into arguments the way a POSIX shell would, honoring
+// single quotes, double quotes and backslash escapes.
+func splitShell(s string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape in command")
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
+
+// parseBytes parses a size such as "512m", "1.5GB" or "1024" into bytes.
+// Units are binary.
+func parseBytes(s string) (int64, error) {
+	value := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), "b")
+
+	multiplier := 1.0
+	if n := len(value); n > 0 {
+		if i := strings.IndexByte("kmgt", value[n-1]); i >= 0 {
+			multiplier = math.Pow(bytesFactor, float64(i+1))
+			value = value[:n-1]
+		}
+	}
+
+	size, err := strconv.ParseFloat(value, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	return int64(size * multiplier), nil
+}
+
+// parseCPUs parses a fractional number of CPUs into nano CPUs.
+func parseCPUs(s string) (int64, error) {
+	cpus, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || cpus < 0 {
+		return 0, fmt.Errorf("invalid cpus %q", s)
+	}
+
+	return int64(cpus * nanoCPUs), nil
+}
+
+// parsePortRange parses "80" or "8000-8010".
+func parsePortRange(s string) (uint32, uint32, error) {
+	startStr, endStr, isRange := strings.Cut(s, "-")
+
+	start, err := strconv.ParseUint(startStr, 10, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port %q", s)
+	}
+	if !isRange {
+		return uint32(start), uint32(start), nil
+	}
+
+	end, err := strconv.ParseUint(endStr, 10, 16)
+	if err != nil || end < start {
+		return 0, 0, fmt.Errorf("invalid port range %q", s)
+	}
+
+	return uint32(start), uint32(end), nil
+}
+
+// parseEnvFile parses an env file the way Compose does. Blank lines and
+// comments are skipped and keys may be prefixed with export. Single quoted
+// values are literal, double quoted values support backslash escapes, and both
+// may span several lines. Unquoted values end at an inline comment. Keys
+// without a value would be taken from the environment, which a stack does not
+// have, so they are skipped.
+func parseEnvFile(data string) (map[string]string, error) {
+	env := make(map[string]string)
+
+	rest := data
+	for rest != "" {
+		var line string
+		line, rest, _ = strings.Cut(rest, "\n")
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if after, ok := strings.CutPrefix(line, "export"); ok && after != "" && (after[0] == ' ' || after[0] == '\t') {
+			line = strings.TrimLeft(after, " \t")
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("invalid variable name %q", key)
+		}
+
+		value = strings.TrimLeft(value, " \t")
+		if value != "" && (value[0] == '\'' || value[0] == '"') {
+			var err error
+			if value, rest, err = unquoteEnvValue(value, rest); err != nil {
+				return nil, fmt.Errorf("variable %q: %w", key, err)
+			}
+		} else if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		} else if i = strings.Index(value, "\t#"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+
+		env[key] = value
+	}
+
+	return env, nil
+}
+
+// unquoteEnvValue returns the contents of a quoted env file value, reading
+// the following lines from rest until the closing quote, and the lines left.
+func unquoteEnvValue(value, rest string) (string, string, error) {
+	quote := value[0]
+
+	var b strings.Builder
+	for i := 1; ; i++ {
+		if i == len(value) {
+			if rest == "" {
+				return "", "", errors.New("unterminated quoted value")
+			}
+
+			var line string
+			line, rest, _ = strings.Cut(rest, "\n")
+			value += "\n" + line
+		}
+
+		switch c := value[i]; {
+		case c == quote:
+			if tail := strings.TrimSpace(value[i+1:]); tail != "" && !strings.HasPrefix(tail, "#") {
+				return "", "", fmt.Errorf("unexpected %q after quoted value", tail)
+			}
+			return b.String(), rest, nil
+		case c == '\\' && quote == '"' && i+1 < len(value):
+			i++
+			switch value[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(value[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
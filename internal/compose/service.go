@@ -0,0 +1,789 @@
+package compose
+
+import (
+	"fmt"
+	"maps"
+	"net/netip"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/mount"
+	"github.com/moby/moby/api/types/swarm"
+)
+
+const (
+	defaultFileMode   os.FileMode = 0o444
+	defaultSecretsDir             = "/run/secrets"
+
+	modeReplicated    = "replicated"
+	modeGlobal        = "global"
+	modeReplicatedJob = "replicated-job"
+	modeGlobalJob     = "global-job"
+)
+
+func (t *translator) translateService(name string, s *service) (swarm.ServiceSpec, error) {
+	var spec swarm.ServiceSpec
+
+	if s.Image == "" {
+		return spec, fmt.Errorf("%w: image is required", ErrInvalid)
+	}
+
+	containerSpec, err := t.containerSpec(s)
+	if err != nil {
+		return spec, err
+	}
+
+	mode, err := serviceMode(s.Deploy)
+	if err != nil {
+		return spec, err
+	}
+
+	endpoint, err := endpointSpec(s)
+	if err != nil {
+		return spec, err
+	}
+
+	networks, err := t.networkAttachments(name, s)
+	if err != nil {
+		return spec, err
+	}
+
+	taskResources, err := resourceRequirements(s.Deploy.Resources)
+	if err != nil {
+		return spec, err
+	}
+
+	restart, err := restartPolicySpec(s.Deploy.RestartPolicy)
+	if err != nil {
+		return spec, err
+	}
+
+	update, err := updateConfigSpec("update_config", s.Deploy.UpdateConfig, true)
+	if err != nil {
+		return spec, err
+	}
+
+	rollback, err := updateConfigSpec("rollback_config", s.Deploy.RollbackConfig, false)
+	if err != nil {
+		return spec, err
+	}
+
+	spec.Name = t.scoped(name, "")
+	spec.Labels = t.labels(s.Deploy.Labels)
+	spec.Labels[LabelImage] = s.Image
+
+	spec.TaskTemplate.ContainerSpec = containerSpec
+	spec.TaskTemplate.Resources = taskResources
+	spec.TaskTemplate.RestartPolicy = restart
+	spec.TaskTemplate.Placement = placementSpec(s.Deploy.Placement)
+	spec.TaskTemplate.Networks = networks
+	spec.TaskTemplate.LogDriver = logDriver(s.Logging)
+
+	spec.Mode = mode
+	spec.UpdateConfig = update
+	spec.RollbackConfig = rollback
+	spec.EndpointSpec = endpoint
+
+	return spec, nil
+}
+
+func (t *translator) containerSpec(s *service) (*swarm.ContainerSpec, error) {
+	env, err := t.environment(s)
+	if err != nil {
+		return nil, err
+	}
+
+	mounts, err := t.mounts(s)
+	if err != nil {
+		return nil, err
+	}
+
+	configs, err := t.configReferences(s.Configs)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := t.secretReferences(s.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	dns, err := dnsConfig(s.DNS)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec swarm.ContainerSpec
+	spec.Image = s.Image
+	spec.Labels = t.labels(s.Labels)
+	spec.Command = s.Entrypoint
+	spec.Args = s.Command
+	spec.Hostname = s.Hostname
+	spec.Env = env
+	spec.Dir = s.WorkingDir
+	spec.User = s.User
+	spec.Init = s.Init
+	spec.StopSignal = s.StopSignal
+	spec.StopGracePeriod = s.StopGracePeriod.ptr()
+	spec.TTY = s.TTY
+	spec.OpenStdin = s.StdinOpen
+	spec.ReadOnly = s.ReadOnly
+	spec.Mounts = mounts
+	spec.Healthcheck = healthConfig(s.Healthcheck)
+	spec.Hosts = extraHosts(s.ExtraHosts)
+	spec.Configs = configs
+	spec.Secrets = secrets
+	spec.CapabilityAdd = s.CapAdd
+	spec.CapabilityDrop = s.CapDrop
+	spec.DNSConfig = dns
+
+	if len(s.Sysctls) > 0 {
+		spec.Sysctls = s.Sysctls
+	}
+
+	return &spec, nil
+}
+
+// environment merges env files with the environment section, which takes
+// precedence.
+func (t *translator) environment(s *service) ([]string, error) {
+	env := make(map[string]string)
+
+	for _, file := range s.EnvFile {
+		data, err := t.readFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		vars, err := parseEnvFile(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: env file %q: %w", ErrInvalid, file, err)
+		}
+		maps.Copy(env, vars)
+	}
+
+	maps.Copy(env, s.Environment)
+
+	if len(env) == 0 {
+		return nil, nil
+	}
+
+	result := make([]string, 0, len(env))
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		result = append(result, key+"="+env[key])
+	}
+
+	return result, nil
+}
+
+func (t *translator) mounts(s *service) ([]mount.Mount, error) {
+	result := make([]mount.Mount, 0, len(s.Volumes)+len(s.Tmpfs))
+
+	for _, v := range s.Volumes {
+		if v.Short != "" {
+			var err error
+			if v, err = parseVolume(v.Short); err != nil {
+				return nil, err
+			}
+		}
+
+		m, err := t.mount(v)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, m)
+	}
+
+	for _, target := range s.Tmpfs {
+		var m mount.Mount
+		m.Type = mount.TypeTmpfs
+		m.Target = target
+		result = append(result, m)
+	}
+
+	if len(result) == 0 {
+		return nil, nil
+	}
+
+	return result, nil
+}
+
+func (t *translator) mount(v serviceVolume) (mount.Mount, error) {
+	var m mount.Mount
+	m.Type = mount.Type(v.Type)
+	m.Target = v.Target
+	m.ReadOnly = v.ReadOnly
+
+	if m.Target == "" {
+		return m, fmt.Errorf("%w: volume target is required", ErrInvalid)
+	}
+
+	switch m.Type {
+	case mount.TypeVolume:
+		if v.Source == "" {
+			// Anonymous volume
+			break
+		}
+
+		opts, ok := t.volumes[v.Source]
+		if !ok {
+			return m, fmt.Errorf("%w: volume %q is not defined", ErrInvalid, v.Source)
+		}
+
+		m.Source = t.names[v.Source]
+		if opts != nil || v.Volume.NoCopy || v.Volume.Subpath != "" {
+			var volumeOpts mount.VolumeOptions
+			if opts != nil {
+				volumeOpts = *opts
+			}
+			volumeOpts.NoCopy = v.Volume.NoCopy
+			volumeOpts.Subpath = v.Volume.Subpath
+			m.VolumeOptions = &volumeOpts
+		}
+	case mount.TypeBind:
+		if !path.IsAbs(v.Source) {
+			return m, fmt.Errorf("%w: relative bind mount %q", ErrUnsupported, v.Source)
+		}
+
+		m.Source = v.Source
+		if v.Bind.Propagation != "" {
+			var bindOpts mount.BindOptions
+			bindOpts.Propagation = mount.Propagation(v.Bind.Propagation)
+			m.BindOptions = &bindOpts
+		}
+	case mount.TypeTmpfs:
+		if v.Tmpfs.Size != "" {
+			size, err := parseBytes(v.Tmpfs.Size)
+			if err != nil {
+				return m, fmt.Errorf("%w: tmpfs: %w", ErrInvalid, err)
+			}
+
+			var tmpfsOpts mount.TmpfsOptions
+			tmpfsOpts.SizeBytes = size
+			m.TmpfsOptions = &tmpfsOpts
+		}
+	default:
+		return m, fmt.Errorf("%w: volume type %q", ErrUnsupported, v.Type)
+	}
+
+	return m, nil
+}
+
+// parseVolume parses the short volume syntax "[source:]target[:mode]".
+func parseVolume(short string) (serviceVolume, error) {
+	var v serviceVolume
+
+	parts := strings.Split(short, ":")
+	switch len(parts) {
+	case 1:
+		v.Target = parts[0]
+	case 2: //nolint:mnd // source:target
+		v.Source, v.Target = parts[0], parts[1]
+	case 3: //nolint:mnd // source:target:mode
+		v.Source, v.Target = parts[0], parts[1]
+		for mode := range strings.SplitSeq(parts[2], ",") {
+			switch mode {
+			case "ro":
+				v.ReadOnly = true
+			case "nocopy":
+				v.Volume.NoCopy = true
+			case "shared", "rshared", "slave", "rslave", "private", "rprivate":
+				v.Bind.Propagation = mode
+			}
+		}
+	default:
+		return v, fmt.Errorf("%w: volume %q", ErrInvalid, short)
+	}
+
+	switch {
+	case v.Source == "":
+		v.Type = string(mount.TypeVolume)
+	case strings.HasPrefix(v.Source, "/"), strings.HasPrefix(v.Source, "."), strings.HasPrefix(v.Source, "~"):
+		v.Type = string(mount.TypeBind)
+	default:
+		v.Type = string(mount.TypeVolume)
+	}
+
+	return v, nil
+}
+
+func (t *translator) configReferences(refs []fileReference) ([]*swarm.ConfigReference, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	result := make([]*swarm.ConfigReference, 0, len(refs))
+	for _, ref := range refs {
+		name, ok := t.configs[ref.Source]
+		if !ok {
+			return nil, fmt.Errorf("%w: config %q is not defined", ErrInvalid, ref.Source)
+		}
+
+		target := ref.Target
+		if target == "" {
+			target = "/" + ref.Source
+		}
+
+		var file swarm.ConfigReferenceFileTarget
+		file.Name = target
+		file.UID = orDefault(ref.UID, "0")
+		file.GID = orDefault(ref.GID, "0")
+		file.Mode = fileMode(ref.Mode)
+
+		var reference swarm.ConfigReference
+		reference.File = &file
+		reference.ConfigName = name
+
+		result = append(result, &reference)
+	}
+
+	return result, nil
+}
+
+func (t *translator) secretReferences(refs []fileReference) ([]*swarm.SecretReference, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	result := make([]*swarm.SecretReference, 0, len(refs))
+	for _, ref := range refs {
+		name, ok := t.secrets[ref.Source]
+		if !ok {
+			return nil, fmt.Errorf("%w: secret %q is not defined", ErrInvalid, ref.Source)
+		}
+
+		target := ref.Target
+		if target == "" {
+			target = ref.Source
+		}
+		if !path.IsAbs(target) {
+			target = path.Join(defaultSecretsDir, target)
+		}
+
+		var file swarm.SecretReferenceFileTarget
+		file.Name = target
+		file.UID = orDefault(ref.UID, "0")
+		file.GID = orDefault(ref.GID, "0")
+		file.Mode = fileMode(ref.Mode)
+
+		var reference swarm.SecretReference
+		reference.File = &file
+		reference.SecretName = name
+
+		result = append(result, &reference)
+	}
+
+	return result, nil
+}
+
+func (t *translator) networkAttachments(name string, s *service) ([]swarm.NetworkAttachmentConfig, error) {
+	networks := s.Networks
+	if len(networks) == 0 {
+		networks = serviceNetworks{DefaultNetwork: nil}
+	}
+
+	result := make([]swarm.NetworkAttachmentConfig, 0, len(networks))
+	for _, key := range slices.Sorted(maps.Keys(networks)) {
+		target, ok := t.networks[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: network %q is not defined", ErrInvalid, key)
+		}
+
+		// Other services reach this one by its compose name.
+		aliases := []string{name}
+		if opts := networks[key]; opts != nil {
+			aliases = append(aliases, opts.Aliases...)
+		}
+
+		var attachment swarm.NetworkAttachmentConfig
+		attachment.Target = target
+		attachment.Aliases = aliases
+
+		result = append(result, attachment)
+	}
+
+	return result, nil
+}
+
+func serviceMode(d deploy) (swarm.ServiceMode, error) {
+	var mode swarm.ServiceMode
+
+	switch d.Mode {
+	case "", modeReplicated:
+		replicas := uint64(1)
+		if d.Replicas != nil {
+			replicas = *d.Replicas
+		}
+
+		var replicated swarm.ReplicatedService
+		replicated.Replicas = &replicas
+		mode.Replicated = &replicated
+	case modeGlobal:
+		mode.Global = &swarm.GlobalService{}
+	case modeReplicatedJob:
+		var job swarm.ReplicatedJob
+		job.TotalCompletions = d.Replicas
+		mode.ReplicatedJob = &job
+	case modeGlobalJob:
+		mode.GlobalJob = &swarm.GlobalJob{}
+	default:
+		return mode, fmt.Errorf("%w: deploy mode %q", ErrInvalid, d.Mode)
+	}
+
+	return mode, nil
+}
+
+func endpointSpec(s *service) (*swarm.EndpointSpec, error) {
+	var spec swarm.EndpointSpec
+	spec.Mode = swarm.ResolutionMode(s.Deploy.EndpointMode)
+
+	switch spec.Mode {
+	case "", swarm.ResolutionModeVIP, swarm.ResolutionModeDNSRR:
+	default:
+		return nil, fmt.Errorf("%w: endpoint mode %q", ErrInvalid, s.Deploy.EndpointMode)
+	}
+
+	for _, p := range s.Ports {
+		ports, err := portConfigs(p)
+		if err != nil {
+			return nil, err
+		}
+
+		spec.Ports = append(spec.Ports, ports...)
+	}
+
+	if spec.Mode == "" && len(spec.Ports) == 0 {
+		return nil, nil //nolint:nilnil // no endpoint configuration
+	}
+
+	return &spec, nil
+}
+
+func portConfigs(p port) ([]swarm.PortConfig, error) {
+	if p.Short == "" {
+		published, publishedEnd, err := parsePortRange(orDefault(p.Published, "0"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+		}
+		// Unlike containers, Swarm cannot pick a free port of a range.
+		if publishedEnd != published {
+			return nil, fmt.Errorf("%w: published port range %q for a single target", ErrUnsupported, p.Published)
+		}
+
+		pc, err := portConfig(p.Target, published, p.Protocol, p.Mode)
+		if err != nil {
+			return nil, err
+		}
+
+		return []swarm.PortConfig{pc}, nil
+	}
+
+	// [published:]target[/protocol]
+	spec, protocol, _ := strings.Cut(p.Short, "/")
+	parts := strings.Split(spec, ":")
+	if len(parts) > 2 { //nolint:mnd // published:target
+		return nil, fmt.Errorf("%w: host ip in port %q", ErrUnsupported, p.Short)
+	}
+
+	targetStart, targetEnd, err := parsePortRange(parts[len(parts)-1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+
+	publishedStart, publishedEnd := uint32(0), uint32(0)
+	if len(parts) == 2 { //nolint:mnd // published:target
+		if publishedStart, publishedEnd, err = parsePortRange(parts[0]); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+		}
+		if publishedEnd-publishedStart != targetEnd-targetStart {
+			return nil, fmt.Errorf("%w: port ranges do not match in %q", ErrInvalid, p.Short)
+		}
+	}
+
+	result := make([]swarm.PortConfig, 0, targetEnd-targetStart+1)
+	for offset := range targetEnd - targetStart + 1 {
+		published := uint32(0)
+		if publishedStart > 0 {
+			published = publishedStart + offset
+		}
+
+		pc, pcErr := portConfig(targetStart+offset, published, protocol, "")
+		if pcErr != nil {
+			return nil, pcErr
+		}
+
+		result = append(result, pc)
+	}
+
+	return result, nil
+}
+
+func portConfig(target, published uint32, protocol, mode string) (swarm.PortConfig, error) {
+	var pc swarm.PortConfig
+	pc.TargetPort = target
+	pc.PublishedPort = published
+
+	if target == 0 {
+		return pc, fmt.Errorf("%w: port target is required", ErrInvalid)
+	}
+
+	switch protocol {
+	case "", "tcp":
+		pc.Protocol = "tcp"
+	case "udp":
+		pc.Protocol = "udp"
+	case "sctp":
+		pc.Protocol = "sctp"
+	default:
+		return pc, fmt.Errorf("%w: port protocol %q", ErrInvalid, protocol)
+	}
+
+	switch mode {
+	case "", "ingress":
+		pc.PublishMode = swarm.PortConfigPublishModeIngress
+	case "host":
+		pc.PublishMode = swarm.PortConfigPublishModeHost
+	default:
+		return pc, fmt.Errorf("%w: port mode %q", ErrInvalid, mode)
+	}
+
+	return pc, nil
+}
+
+func resourceRequirements(r resources) (*swarm.ResourceRequirements, error) {
+	if r.Limits == nil && r.Reservations == nil {
+		return nil, nil //nolint:nilnil // no resource requirements
+	}
+
+	var result swarm.ResourceRequirements
+
+	if r.Limits != nil {
+		cpus, memory, err := r.Limits.parse()
+		if err != nil {
+			return nil, err
+		}
+
+		var limits swarm.Limit
+		limits.NanoCPUs = cpus
+		limits.MemoryBytes = memory
+		limits.Pids = r.Limits.Pids
+		result.Limits = &limits
+	}
+
+	if r.Reservations != nil {
+		cpus, memory, err := r.Reservations.parse()
+		if err != nil {
+			return nil, err
+		}
+
+		var reservations swarm.Resources
+		reservations.NanoCPUs = cpus
+		reservations.MemoryBytes = memory
+		result.Reservations = &reservations
+	}
+
+	return &result, nil
+}
+
+func (r *resourceSpec) parse() (int64, int64, error) {
+	var cpus, memory int64
+	var err error
+
+	if r.CPUs != "" {
+		if cpus, err = parseCPUs(r.CPUs); err != nil {
+			return 0, 0, fmt.Errorf("%w: %w", ErrInvalid, err)
+		}
+	}
+
+	if r.Memory != "" {
+		if memory, err = parseBytes(r.Memory); err != nil {
+			return 0, 0, fmt.Errorf("%w: %w", ErrInvalid, err)
+		}
+	}
+
+	return cpus, memory, nil
+}
+
+func restartPolicySpec(p *restartPolicy) (*swarm.RestartPolicy, error) {
+	if p == nil {
+		return nil, nil //nolint:nilnil // no restart policy
+	}
+
+	var policy swarm.RestartPolicy
+	policy.Condition = swarm.RestartPolicyCondition(p.Condition)
+	policy.Delay = p.Delay.ptr()
+	policy.MaxAttempts = p.MaxAttempts
+	policy.Window = p.Window.ptr()
+
+	switch policy.Condition {
+	case "", swarm.RestartPolicyConditionNone, swarm.RestartPolicyConditionOnFailure, swarm.RestartPolicyConditionAny:
+	default:
+		return nil, fmt.Errorf("%w: restart condition %q", ErrInvalid, p.Condition)
+	}
+
+	return &policy, nil
+}
+
+// updateConfigSpec translates an update_config, or a rollback_config which
+// cannot fail over to a rollback.
+func updateConfigSpec(key string, c *updateConfig, canRollback bool) (*swarm.UpdateConfig, error) {
+	if c == nil {
+		return nil, nil //nolint:nilnil // Swarm defaults
+	}
+
+	var config swarm.UpdateConfig
+	// Compose defaults to updating one task at a time, Swarm to all of them.
+	config.Parallelism = 1
+	if c.Parallelism != nil {
+		config.Parallelism = *c.Parallelism
+	}
+	if delay := c.Delay.ptr(); delay != nil {
+		config.Delay = *delay
+	}
+	if monitor := c.Monitor.ptr(); monitor != nil {
+		config.Monitor = *monitor
+	}
+	config.FailureAction = swarm.FailureAction(c.FailureAction)
+	config.MaxFailureRatio = c.MaxFailureRatio
+	config.Order = swarm.UpdateOrder(c.Order)
+
+	switch config.FailureAction {
+	case "", swarm.UpdateFailureActionPause, swarm.UpdateFailureActionContinue:
+	case swarm.UpdateFailureActionRollback:
+		if !canRollback {
+			return nil, fmt.Errorf("%w: %s failure action %q", ErrInvalid, key, c.FailureAction)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s failure action %q", ErrInvalid, key, c.FailureAction)
+	}
+
+	switch config.Order {
+	case "", swarm.UpdateOrderStopFirst, swarm.UpdateOrderStartFirst:
+	default:
+		return nil, fmt.Errorf("%w: %s order %q", ErrInvalid, key, c.Order)
+	}
+
+	if config.MaxFailureRatio < 0 || config.MaxFailureRatio > 1 {
+		return nil, fmt.Errorf("%w: %s max failure ratio %v", ErrInvalid, key, c.MaxFailureRatio)
+	}
+
+	return &config, nil
+}
+
+func placementSpec(p placement) *swarm.Placement {
+	if len(p.Constraints) == 0 && len(p.Preferences) == 0 && p.MaxReplicas == 0 {
+		return nil
+	}
+
+	var result swarm.Placement
+	result.Constraints = p.Constraints
+	result.MaxReplicas = p.MaxReplicas
+
+	for _, pref := range p.Preferences {
+		var spread swarm.SpreadOver
+		spread.SpreadDescriptor = pref.Spread
+
+		var preference swarm.PlacementPreference
+		preference.Spread = &spread
+
+		result.Preferences = append(result.Preferences, preference)
+	}
+
+	return &result
+}
+
+func healthConfig(h *healthcheck) *container.HealthConfig {
+	if h == nil {
+		return nil
+	}
+
+	var config container.HealthConfig
+	if h.Disable {
+		config.Test = []string{"NONE"}
+		return &config
+	}
+
+	config.Test = h.Test
+
+	if h.Interval != nil {
+		config.Interval = *h.Interval.ptr()
+	}
+	if h.Timeout != nil {
+		config.Timeout = *h.Timeout.ptr()
+	}
+	if h.StartPeriod != nil {
+		config.StartPeriod = *h.StartPeriod.ptr()
+	}
+	if h.StartInterval != nil {
+		config.StartInterval = *h.StartInterval.ptr()
+	}
+	config.Retries = h.Retries
+
+	return &config
+}
+
+func logDriver(l *logging) *swarm.Driver {
+	if l == nil || l.Driver == "" {
+		return nil
+	}
+
+	var driver swarm.Driver
+	driver.Name = l.Driver
+	driver.Options = l.Options
+
+	return &driver
+}
+
+// extraHosts converts "host:ip" entries into the hosts file format used by
+// Swarm ("ip host").
+func extraHosts(hosts hostList) []string {
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	result := make([]string, 0, len(hosts))
+	for _, host := range slices.Sorted(maps.Keys(hosts)) {
+		result = append(result, hosts[host]+" "+host)
+	}
+
+	return result
+}
+
+func dnsConfig(nameservers stringOrList) (*swarm.DNSConfig, error) {
+	if len(nameservers) == 0 {
+		return nil, nil //nolint:nilnil // no DNS configuration
+	}
+
+	var dns swarm.DNSConfig
+	dns.Nameservers = make([]netip.Addr, 0, len(nameservers))
+	for _, ns := range nameservers {
+		addr, err := netip.ParseAddr(ns)
+		if err != nil {
+			return nil, fmt.Errorf("%w: dns %q: %w", ErrInvalid, ns, err)
+		}
+		dns.Nameservers = append(dns.Nameservers, addr)
+	}
+
+	return &dns, nil
+}
+
+func fileMode(mode *uint32) os.FileMode {
+	if mode == nil {
+		return defaultFileMode
+	}
+
+	return os.FileMode(*mode)
+}
+
+// orDefault returns value, or fallback if value is empty.
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
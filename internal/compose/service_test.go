@@ -0,0 +1,632 @@
+package compose_test
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/netip"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/compose"
+	"github.com/moby/moby/api/types/mount"
+	"github.com/moby/moby/api/types/network"
+	"github.com/moby/moby/api/types/swarm"
+)
+
+func TestLoadDNS(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		dns     string
+		want    []netip.Addr
+		wantErr error
+	}{
+		{
+			name: "none",
+			dns:  "",
+			want: nil,
+		},
+		{
+			name: "single",
+			dns:  "dns: 8.8.8.8",
+			want: []netip.Addr{netip.MustParseAddr("8.8.8.8")},
+		},
+		{
+			name: "list",
+			dns:  "dns: [1.1.1.1, \"2606:4700:4700::1111\"]",
+			want: []netip.Addr{netip.MustParseAddr("1.1.1.1"), netip.MustParseAddr("2606:4700:4700::1111")},
+		},
+		{
+			name:    "hostname",
+			dns:     "dns: dns.example.com",
+			wantErr: compose.ErrInvalid,
+		},
+		{
+			name:    "invalid in list",
+			dns:     "dns: [8.8.8.8, 8.8.8]",
+			wantErr: compose.ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := "services:\n  web:\n    image: nginx\n    " + tt.dns + "\n"
+			stack, err := compose.Load([]byte(data), compose.Options{Namespace: "app", Variables: nil, ReadFile: nil})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			spec := stack.Services[0].TaskTemplate.ContainerSpec
+			if tt.want == nil {
+				if spec.DNSConfig != nil {
+					t.Fatalf("DNSConfig = %+v, want nil", spec.DNSConfig)
+				}
+				return
+			}
+			if spec.DNSConfig == nil || !slices.Equal(spec.DNSConfig.Nameservers, tt.want) {
+				t.Fatalf("DNSConfig = %+v, want nameservers %v", spec.DNSConfig, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadUpdateConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		deploy       string
+		wantUpdate   *swarm.UpdateConfig
+		wantRollback *swarm.UpdateConfig
+		wantErr      error
+	}{
+		{
+			name:         "none",
+			deploy:       "replicas: 1",
+			wantUpdate:   nil,
+			wantRollback: nil,
+		},
+		{
+			name:   "compose defaults to one task at a time",
+			deploy: "update_config: {}",
+			wantUpdate: &swarm.UpdateConfig{
+				Parallelism: 1,
+			},
+			wantRollback: nil,
+		},
+		{
+			name: "update config",
+			deploy: "update_config:\n        parallelism: 2\n        delay: 10s\n        failure_action: rollback\n" +
+				"        monitor: 30s\n        max_failure_ratio: 0.5\n        order: start-first",
+			wantUpdate: &swarm.UpdateConfig{
+				Parallelism:     2,
+				Delay:           10 * time.Second,
+				FailureAction:   swarm.UpdateFailureActionRollback,
+				Monitor:         30 * time.Second,
+				MaxFailureRatio: 0.5,
+				Order:           swarm.UpdateOrderStartFirst,
+			},
+			wantRollback: nil,
+		},
+		{
+			name:       "rollback config",
+			deploy:     "rollback_config:\n        parallelism: 0\n        failure_action: pause\n        order: stop-first",
+			wantUpdate: nil,
+			wantRollback: &swarm.UpdateConfig{
+				Parallelism:   0,
+				FailureAction: swarm.UpdateFailureActionPause,
+				Order:         swarm.UpdateOrderStopFirst,
+			},
+		},
+		{
+			name:    "rollback config rolling back",
+			deploy:  "rollback_config:\n        failure_action: rollback",
+			wantErr: compose.ErrInvalid,
+		},
+		{
+			name:    "unknown failure action",
+			deploy:  "update_config:\n        failure_action: retry",
+			wantErr: compose.ErrInvalid,
+		},
+		{
+			name:    "unknown order",
+			deploy:  "update_config:\n        order: random",
+			wantErr: compose.ErrInvalid,
+		},
+		{
+			name:    "failure ratio over one",
+			deploy:  "update_config:\n        max_failure_ratio: 2",
+			wantErr: compose.ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := "services:\n  web:\n    image: nginx\n    deploy:\n      " + tt.deploy + "\n"
+			stack, err := compose.Load([]byte(data), compose.Options{Namespace: "app", Variables: nil, ReadFile: nil})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			spec := stack.Services[0]
+			if !reflect.DeepEqual(spec.UpdateConfig, tt.wantUpdate) {
+				t.Errorf("UpdateConfig = %+v, want %+v", spec.UpdateConfig, tt.wantUpdate)
+			}
+			if !reflect.DeepEqual(spec.RollbackConfig, tt.wantRollback) {
+				t.Errorf("RollbackConfig = %+v, want %+v", spec.RollbackConfig, tt.wantRollback)
+			}
+		})
+	}
+}
+
+// loadService loads a stack of a single service "web" defined by service,
+// followed by the top level sections in extra. files are the files the
+// compose file may read.
+func loadService(service, extra string, files map[string]string) (swarm.ServiceSpec, error) {
+	data := "services:\n  web:\n    image: nginx\n" + service + "\n" + extra
+	stack, err := compose.Load([]byte(data), compose.Options{
+		Namespace: "app",
+		Variables: nil,
+		ReadFile: func(name string) ([]byte, error) {
+			content, ok := files[name]
+			if !ok {
+				return nil, fmt.Errorf("file %q not found", name)
+			}
+			return []byte(content), nil
+		},
+	})
+	if err != nil {
+		return swarm.ServiceSpec{}, err
+	}
+
+	return stack.Services[0], nil
+}
+
+func TestLoadPorts(t *testing.T) {
+	t.Parallel()
+
+	ingress := func(target, published uint32, protocol network.IPProtocol) swarm.PortConfig {
+		return swarm.PortConfig{
+			Protocol:      protocol,
+			TargetPort:    target,
+			PublishedPort: published,
+			PublishMode:   swarm.PortConfigPublishModeIngress,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		ports   string
+		want    []swarm.PortConfig
+		wantErr error
+	}{
+		{
+			name:  "target only",
+			ports: `["80"]`,
+			want:  []swarm.PortConfig{ingress(80, 0, network.TCP)},
+		},
+		{
+			name:  "published and protocol",
+			ports: `["8080:80", "53:53/udp"]`,
+			want: []swarm.PortConfig{
+				ingress(80, 8080, network.TCP),
+				ingress(53, 53, network.UDP),
+			},
+		},
+		{
+			name:  "ranges",
+			ports: `["8000-8001:80-81"]`,
+			want: []swarm.PortConfig{
+				ingress(80, 8000, network.TCP),
+				ingress(81, 8001, network.TCP),
+			},
+		},
+		{
+			name:    "mismatched ranges",
+			ports:   `["8000-8002:80-81"]`,
+			wantErr: compose.ErrInvalid,
+		},
+		{
+			name:    "host ip",
+			ports:   `["127.0.0.1:8080:80"]`,
+			wantErr: compose.ErrUnsupported,
+		},
+		{
+			name:    "unknown protocol",
+			ports:   `["80/icmp"]`,
+			wantErr: compose.ErrInvalid,
+		},
+		{
+			name:  "long syntax",
+			ports: `[{target: 80, published: "8080", protocol: udp, mode: host}]`,
+			want: []swarm.PortConfig{{
+				Protocol:      network.UDP,
+				TargetPort:    80,
+				PublishedPort: 8080,
+				PublishMode:   swarm.PortConfigPublishModeHost,
+			}},
+		},
+		{
+			name:  "long syntax without published port",
+			ports: `[{target: 80}]`,
+			want:  []swarm.PortConfig{ingress(80, 0, network.TCP)},
+		},
+		{
+			name:    "long syntax published range",
+			ports:   `[{target: 80, published: "8000-8002"}]`,
+			wantErr: compose.ErrUnsupported,
+		},
+		{
+			name:    "long syntax without target",
+			ports:   `[{published: "8080"}]`,
+			wantErr: compose.ErrInvalid,
+		},
+		{
+			name:    "long syntax unknown mode",
+			ports:   `[{target: 80, mode: bridge}]`,
+			wantErr: compose.ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			spec, err := loadService("    ports: "+tt.ports, "", nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if spec.EndpointSpec == nil || !reflect.DeepEqual(spec.EndpointSpec.Ports, tt.want) {
+				t.Fatalf("EndpointSpec = %+v, want ports %+v", spec.EndpointSpec, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadVolumes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		volumes string
+		extra   string
+		want    []mount.Mount
+		wantErr error
+	}{
+		{
+			name:    "bind",
+			volumes: "    volumes: [\"/srv/data:/data:ro\"]",
+			want:    []mount.Mount{{Type: mount.TypeBind, Source: "/srv/data", Target: "/data", ReadOnly: true}},
+		},
+		{
+			name:    "relative bind",
+			volumes: "    volumes: [\"./data:/data\"]",
+			wantErr: compose.ErrUnsupported,
+		},
+		{
+			name:    "named volume",
+			volumes: "    volumes: [\"data:/data\"]",
+			extra:   "volumes:\n  data: {}\n",
+			want: []mount.Mount{{
+				Type:          mount.TypeVolume,
+				Source:        "app_data",
+				Target:        "/data",
+				VolumeOptions: &mount.VolumeOptions{Labels: map[string]string{compose.LabelNamespace: "app"}},
+			}},
+		},
+		{
+			name:    "external volume",
+			volumes: "    volumes: [\"data:/data:nocopy\"]",
+			extra:   "volumes:\n  data:\n    external: true\n    name: shared\n",
+			want: []mount.Mount{{
+				Type:          mount.TypeVolume,
+				Source:        "shared",
+				Target:        "/data",
+				VolumeOptions: &mount.VolumeOptions{NoCopy: true},
+			}},
+		},
+		{
+			name:    "undefined volume",
+			volumes: "    volumes: [\"data:/data\"]",
+			wantErr: compose.ErrInvalid,
+		},
+		{
+			name:    "anonymous volume",
+			volumes: "    volumes: [\"/cache\"]",
+			want:    []mount.Mount{{Type: mount.TypeVolume, Target: "/cache"}},
+		},
+		{
+			name:    "tmpfs",
+			volumes: "    volumes: [{type: tmpfs, target: /run, tmpfs: {size: 64m}}]\n    tmpfs: [/tmp]",
+			want: []mount.Mount{
+				{Type: mount.TypeTmpfs, Target: "/run", TmpfsOptions: &mount.TmpfsOptions{SizeBytes: 64 << 20}},
+				{Type: mount.TypeTmpfs, Target: "/tmp"},
+			},
+		},
+		{
+			name:    "npipe",
+			volumes: "    volumes: [{type: npipe, source: pipe, target: /pipe}]",
+			wantErr: compose.ErrUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			spec, err := loadService(tt.volumes, tt.extra, nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if got := spec.TaskTemplate.ContainerSpec.Mounts; !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Mounts = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadEnvironment(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		environment string
+		files       map[string]string
+		want        []string
+		wantErr     error
+	}{
+		{
+			name:        "none",
+			environment: "",
+			want:        nil,
+		},
+		{
+			name:        "map",
+			environment: "    environment: {B: 2, A: \"1\"}",
+			want:        []string{"A=1", "B=2"},
+		},
+		{
+			name:        "environment overrides env files, later files override earlier ones",
+			environment: "    env_file: [a.env, b.env]\n    environment: [C=env]",
+			files:       map[string]string{"a.env": "A=a\nB=a\nC=a\n", "b.env": "B=b\nC=b\n"},
+			want:        []string{"A=a", "B=b", "C=env"},
+		},
+		{
+			name:        "env file syntax",
+			environment: "    env_file: app.env",
+			files: map[string]string{"app.env": "# comment\n\n" +
+				"export EXPORTED=1\n" +
+				"SPACED = value  \n" +
+				"COMMENTED=value # comment\n" +
+				"HASH=a#b\n" +
+				"SINGLE='$HOME \\n # not a comment'\n" +
+				"DOUBLE=\"say \\\"hi\\\"\\n\" # comment\n" +
+				"MULTILINE=\"first\nsecond\"\n" +
+				"EMPTY=\n" +
+				"UNSET\n"},
+			want: []string{
+				"COMMENTED=value",
+				"DOUBLE=say \"hi\"\n",
+				"EMPTY=",
+				"EXPORTED=1",
+				"HASH=a#b",
+				"MULTILINE=first\nsecond",
+				"SINGLE=$HOME \\n # not a comment",
+				"SPACED=value",
+			},
+		},
+		{
+			name:        "text after a quoted value",
+			environment: "    env_file: app.env",
+			files:       map[string]string{"app.env": "A='quoted'text\n"},
+			wantErr:     compose.ErrInvalid,
+		},
+		{
+			name:        "unterminated quote",
+			environment: "    env_file: app.env",
+			files:       map[string]string{"app.env": "A=\"open\nB=1\n"},
+			wantErr:     compose.ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			spec, err := loadService(tt.environment, "", tt.files)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if got := spec.TaskTemplate.ContainerSpec.Env; !slices.Equal(got, tt.want) {
+				t.Fatalf("Env = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLabels(t *testing.T) {
+	t.Parallel()
+
+	spec, err := loadService("    labels: {tier: web}\n    deploy:\n      labels: [traefik.enable=true]", "", nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Service labels carry the namespace and the requested image, container
+	// labels the namespace only.
+	wantService := map[string]string{
+		"traefik.enable":       "true",
+		compose.LabelNamespace: "app",
+		compose.LabelImage:     "nginx",
+	}
+	if !maps.Equal(spec.Labels, wantService) {
+		t.Errorf("service Labels = %v, want %v", spec.Labels, wantService)
+	}
+	wantContainer := map[string]string{"tier": "web", compose.LabelNamespace: "app"}
+	if got := spec.TaskTemplate.ContainerSpec.Labels; !maps.Equal(got, wantContainer) {
+		t.Errorf("container Labels = %v, want %v", got, wantContainer)
+	}
+}
+
+func TestLoadMode(t *testing.T) {
+	t.Parallel()
+
+	replicas := func(n uint64) *uint64 { return &n }
+
+	tests := []struct {
+		name    string
+		deploy  string
+		want    swarm.ServiceMode
+		wantErr error
+	}{
+		{
+			name:   "default",
+			deploy: "",
+			want:   swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: replicas(1)}},
+		},
+		{
+			name:   "replicated",
+			deploy: "    deploy: {mode: replicated, replicas: 3}",
+			want:   swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: replicas(3)}},
+		},
+		{
+			name:   "scaled to zero",
+			deploy: "    deploy: {replicas: 0}",
+			want:   swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: replicas(0)}},
+		},
+		{
+			name:   "global",
+			deploy: "    deploy: {mode: global}",
+			want:   swarm.ServiceMode{Global: &swarm.GlobalService{}},
+		},
+		{
+			name:   "replicated job",
+			deploy: "    deploy: {mode: replicated-job, replicas: 2}",
+			want:   swarm.ServiceMode{ReplicatedJob: &swarm.ReplicatedJob{TotalCompletions: replicas(2)}},
+		},
+		{
+			name:    "unknown",
+			deploy:  "    deploy: {mode: daemonset}",
+			wantErr: compose.ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			spec, err := loadService(tt.deploy, "", nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(spec.Mode, tt.want) {
+				t.Fatalf("Mode = %+v, want %+v", spec.Mode, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadResources(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		resources string
+		want      *swarm.ResourceRequirements
+		wantErr   error
+	}{
+		{
+			name:      "none",
+			resources: "replicas: 1",
+			want:      nil,
+		},
+		{
+			name: "limits and reservations",
+			resources: "resources:\n        limits: {cpus: \"0.5\", memory: 512m, pids: 100}\n" +
+				"        reservations: {cpus: \"0.25\", memory: 1g}",
+			want: &swarm.ResourceRequirements{
+				Limits:       &swarm.Limit{NanoCPUs: 5e8, MemoryBytes: 512 << 20, Pids: 100},
+				Reservations: &swarm.Resources{NanoCPUs: 25e7, MemoryBytes: 1 << 30},
+			},
+		},
+		{
+			name:      "invalid cpus",
+			resources: "resources:\n        limits: {cpus: half}",
+			wantErr:   compose.ErrInvalid,
+		},
+		{
+			name:      "invalid memory",
+			resources: "resources:\n        reservations: {memory: lots}",
+			wantErr:   compose.ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			spec, err := loadService("    deploy:\n      "+tt.resources, "", nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if got := spec.TaskTemplate.Resources; !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Resources = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+type project struct {
+	Services map[string]*service    `yaml:"services"`
+	Networks map[string]*network    `yaml:"networks"`
+	Volumes  map[string]*volume     `yaml:"volumes"`
+	Configs  map[string]*fileObject `yaml:"configs"`
+	Secrets  map[string]*fileObject `yaml:"secrets"`
+}
+
+type service struct {
+	Image           string          `yaml:"image"`
+	Command         command         `yaml:"command"`
+	Entrypoint      command         `yaml:"entrypoint"`
+	Environment     mapOrList       `yaml:"environment"`
+	EnvFile         stringOrList    `yaml:"env_file"`
+	Labels          mapOrList       `yaml:"labels"`
+	Ports           []port          `yaml:"ports"`
+	Volumes         []serviceVolume `yaml:"volumes"`
+	Tmpfs           stringOrList    `yaml:"tmpfs"`
+	Networks        serviceNetworks `yaml:"networks"`
+	Configs         []fileReference `yaml:"configs"`
+	Secrets         []fileReference `yaml:"secrets"`
+	Deploy          deploy          `yaml:"deploy"`
+	Healthcheck     *healthcheck    `yaml:"healthcheck"`
+	Logging         *logging        `yaml:"logging"`
+	Hostname        string          `yaml:"hostname"`
+	WorkingDir      string          `yaml:"working_dir"`
+	User            string          `yaml:"user"`
+	StopSignal      string          `yaml:"stop_signal"`
+	StopGracePeriod *duration       `yaml:"stop_grace_period"`
+	TTY             bool            `yaml:"tty"`
+	StdinOpen       bool            `yaml:"stdin_open"`
+	ReadOnly        bool            `yaml:"read_only"`
+	Init            *bool           `yaml:"init"`
+	ExtraHosts      hostList        `yaml:"extra_hosts"`
+	DNS             stringOrList    `yaml:"dns"`
+	CapAdd          []string        `yaml:"cap_add"`
+	CapDrop         []string        `yaml:"cap_drop"`
+	Sysctls         mapOrList       `yaml:"sysctls"`
+}
+
+type deploy struct {
+	Mode           string         `yaml:"mode"`
+	Replicas       *uint64        `yaml:"replicas"`
+	Labels         mapOrList      `yaml:"labels"`
+	EndpointMode   string         `yaml:"endpoint_mode"`
+	Resources      resources      `yaml:"resources"`
+	RestartPolicy  *restartPolicy `yaml:"restart_policy"`
+	Placement      placement      `yaml:"placement"`
+	UpdateConfig   *updateConfig  `yaml:"update_config"`
+	RollbackConfig *updateConfig  `yaml:"rollback_config"`
+}
+
+type resources struct {
+	Limits       *resourceSpec `yaml:"limits"`
+	Reservations *resourceSpec `yaml:"reservations"`
+}
+
+type resourceSpec struct {
+	CPUs   string `yaml:"cpus"`
+	Memory string `yaml:"memory"`
+	Pids   int64  `yaml:"pids"`
+}
+
+type restartPolicy struct {
+	Condition   string    `yaml:"condition"`
+	Delay       *duration `yaml:"delay"`
+	MaxAttempts *uint64   `yaml:"max_attempts"`
+	Window      *duration `yaml:"window"`
+}
+
+// updateConfig is either an update_config or a rollback_config.
+type updateConfig struct {
+	Parallelism     *uint64   `yaml:"parallelism"`
+	Delay           *duration `yaml:"delay"`
+	FailureAction   string    `yaml:"failure_action"`
+	Monitor         *duration `yaml:"monitor"`
+	MaxFailureRatio float32   `yaml:"max_failure_ratio"`
+	Order           string    `yaml:"order"`
+}
+
+type placement struct {
+	Constraints []string `yaml:"constraints"`
+	Preferences []struct {
+		Spread string `yaml:"spread"`
+	} `yaml:"preferences"`
+	MaxReplicas uint64 `yaml:"max_replicas_per_node"`
+}
+
+type healthcheck struct {
+	Test          healthTest `yaml:"test"`
+	Interval      *duration  `yaml:"interval"`
+	Timeout       *duration  `yaml:"timeout"`
+	StartPeriod   *duration  `yaml:"start_period"`
+	StartInterval *duration  `yaml:"start_interval"`
+	Retries       int        `yaml:"retries"`
+	Disable       bool       `yaml:"disable"`
+}
+
+type logging struct {
+	Driver  string            `yaml:"driver"`
+	Options map[string]string `yaml:"options"`
+}
+
+type network struct {
+	Name       string            `yaml:"name"`
+	Driver     string            `yaml:"driver"`
+	DriverOpts map[string]string `yaml:"driver_opts"`
+	Labels     mapOrList         `yaml:"labels"`
+	Attachable bool              `yaml:"attachable"`
+	Internal   bool              `yaml:"internal"`
+	External   bool              `yaml:"external"`
+}
+
+type volume struct {
+	Name       string            `yaml:"name"`
+	Driver     string            `yaml:"driver"`
+	DriverOpts map[string]string `yaml:"driver_opts"`
+	Labels     mapOrList         `yaml:"labels"`
+	External   bool              `yaml:"external"`
+}
+
+// fileObject is a top-level config or secret.
+type fileObject struct {
+	Name        string    `yaml:"name"`
+	File        string    `yaml:"file"`
+	Content     string    `yaml:"content"`
+	Environment string    `yaml:"environment"`
+	Labels      mapOrList `yaml:"labels"`
+	External    bool      `yaml:"external"`
+}
+
+// fileReference is a config or secret attached to a service, in either the
+// short ("name") or the long ({source, target, ...}) syntax.
+type fileReference struct {
+	Source string  `yaml:"source"`
+	Target string  `yaml:"target"`
+	UID    string  `yaml:"uid"`
+	GID    string  `yaml:"gid"`
+	Mode   *uint32 `yaml:"mode"`
+}
+
+func (r *fileReference) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		r.Source = node.Value
+		return nil
+	}
+
+	type plain fileReference
+	return node.Decode((*plain)(r)) //nolint:wrapcheck // decoding error is reported by the caller
+}
+
+// port is an entry of the service ports list.
+type port struct {
+	Short string
+
+	Target    uint32 `yaml:"target"`
+	Published string `yaml:"published"`
+	Protocol  string `yaml:"protocol"`
+	Mode      string `yaml:"mode"`
+}
+
+func (p *port) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		p.Short = node.Value
+		return nil
+	}
+
+	type plain port
+	return node.Decode((*plain)(p)) //nolint:wrapcheck // decoding error is reported by the caller
+}
+
+// serviceVolume is an entry of the service volumes list.
+type serviceVolume struct {
+	Short string
+
+	Type     string `yaml:"type"`
+	Source   string `yaml:"source"`
+	Target   string `yaml:"target"`
+	ReadOnly bool   `yaml:"read_only"`
+	Bind     struct {
+		Propagation string `yaml:"propagation"`
+	} `yaml:"bind"`
+	Volume struct {
+		NoCopy  bool   `yaml:"nocopy"`
+		Subpath string `yaml:"subpath"`
+	} `yaml:"volume"`
+	Tmpfs struct {
+		Size string `yaml:"size"`
+	} `yaml:"tmpfs"`
+}
+
+func (v *serviceVolume) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		v.Short = node.Value
+		return nil
+	}
+
+	type plain serviceVolume
+	return node.Decode((*plain)(v)) //nolint:wrapcheck // decoding error is reported by the caller
+}
+
+// serviceNetworks is either a list of network names or a map of network
+// names to attachment options.
+type serviceNetworks map[string]*struct {
+	Aliases []string `yaml:"aliases"`
+}
+
+func (n *serviceNetworks) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		var names []string
+		if err := node.Decode(&names); err != nil {
+			return err //nolint:wrapcheck // decoding error is reported by the caller
+		}
+
+		*n = make(serviceNetworks, len(names))
+		for _, name := range names {
+			(*n)[name] = nil
+		}
+		return nil
+	}
+
+	type plain serviceNetworks
+	return node.Decode((*plain)(n)) //nolint:wrapcheck // decoding error is reported by the caller
+}
+
+// mapOrList is either a map or a list of KEY=VALUE strings. Keys without a
+// value are dropped.
+type mapOrList map[string]string
+
+func (m *mapOrList) UnmarshalYAML(node *yaml.Node) error {
+	*m = make(mapOrList)
+
+	switch node.Kind { //nolint:exhaustive // other kinds are rejected below
+	case yaml.SequenceNode:
+		var items []string
+		if err := node.Decode(&items); err != nil {
+			return err //nolint:wrapcheck // decoding error is reported by the caller
+		}
+
+		for _, item := range items {
+			if key, value, ok := strings.Cut(item, "="); ok {
+				(*m)[key] = value
+			}
+		}
+	case yaml.MappingNode:
+		var items map[string]*string
+		if err := node.Decode(&items); err != nil {
+			return err //nolint:wrapcheck // decoding error is reported by the caller
+		}
+
+		for key, value := range items {
+			if value != nil {
+				(*m)[key] = *value
+			}
+		}
+	default:
+		return fmt.Errorf("line %d: expected a map or a list", node.Line)
+	}
+
+	return nil
+}
+
+// hostList is either a map of hostnames to IPs or a list of "host:ip" or
+// "host=ip" strings.
+type hostList map[string]string
+
+func (h *hostList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.SequenceNode {
+		type plain hostList
+		return node.Decode((*plain)(h)) //nolint:wrapcheck // decoding error is reported by the caller
+	}
+
+	var items []string
+	if err := node.Decode(&items); err != nil {
+		return err //nolint:wrapcheck // decoding error is reported by the caller
+	}
+
+	*h = make(hostList, len(items))
+	for _, item := range items {
+		i := strings.IndexAny(item, "=:")
+		if i < 0 {
+			return fmt.Errorf("line %d: invalid extra host %q", node.Line, item)
+		}
+
+		(*h)[item[:i]] = item[i+1:]
+	}
+
+	return nil
+}
+
+// healthTest is either a list starting with NONE, CMD or CMD-SHELL, or a
+// string that is run by the shell.
+type healthTest []string
+
+func (t *healthTest) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = healthTest{"CMD-SHELL", node.Value}
+		return nil
+	}
+
+	type plain healthTest
+	return node.Decode((*plain)(t)) //nolint:wrapcheck // decoding error is reported by the caller
+}
+
+// stringOrList is either a single string or a list of strings.
+type stringOrList []string
+
+func (s *stringOrList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = stringOrList{node.Value}
+		return nil
+	}
+
+	type plain stringOrList
+	return node.Decode((*plain)(s)) //nolint:wrapcheck // decoding error is reported by the caller
+}
+
+// command is either a list of arguments or a string that is split like a
+// shell would.
+type command []string
+
+func (c *command) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		args, err := splitShell(node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+
+		*c = args
+		return nil
+	}
+
+	type plain command
+	return node.Decode((*plain)(c)) //nolint:wrapcheck // decoding error is reported by the caller
+}
+
+// duration is a Go duration string such as "1m30s".
+type duration time.Duration
+
+func (d *duration) UnmarshalYAML(node *yaml.Node) error {
+	value, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+
+	*d = duration(value)
+	return nil
+}
+
+func (d *duration) ptr() *time.Duration {
+	if d == nil {
+		return nil
+	}
+
+	value := time.Duration(*d)
+	return &value
+}
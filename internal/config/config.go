@@ -36,11 +36,40 @@ type dockerConfig struct {
 	KeyFile    string        `koanf:"key_file"`
 }
 
+type gitConfig struct {
+	CacheDir string `koanf:"cache_dir"`
+}
+
+type pollerConfig struct {
+	Interval time.Duration `koanf:"interval"`
+	Jitter   time.Duration `koanf:"jitter"`
+}
+
+type deploymentsConfig struct {
+	Workers  int           `koanf:"workers"`
+	Timeout  time.Duration `koanf:"timeout"`
+	Recovery string        `koanf:"recovery"`
+}
+
+type declarativeConfig struct {
+	Dir      string        `koanf:"dir"`
+	Interval time.Duration `koanf:"interval"`
+	Prune    bool          `koanf:"prune"`
+}
+
 type Config struct {
 	HTTP http `koanf:"http"`
 
 	Storage storageConfig `koanf:"storage"`
 	Docker  dockerConfig  `koanf:"docker"`
+	Git     gitConfig     `koanf:"git"`
+	Poller  pollerConfig  `koanf:"poller"`
+
+	Deployments deploymentsConfig `koanf:"deployments"`
+	Declarative declarativeConfig `koanf:"declarative"`
+
+	// path is the config file, which may define stacks as well.
+	path string
 }
 
 func Default() Config {
@@ -61,6 +90,25 @@ func Default() Config {
 			APIVersion: "",
 			Timeout:    30 * time.Second,
 		},
+
+		Git: gitConfig{
+			CacheDir: "./repos",
+		},
+
+		Poller: pollerConfig{
+			Interval: time.Minute,
+			Jitter:   10 * time.Second,
+		},
+
+		Deployments: deploymentsConfig{
+			Workers:  2,
+			Timeout:  5 * time.Minute,
+			Recovery: "fail",
+		},
+
+		Declarative: declarativeConfig{
+			Interval: 30 * time.Second,
+		},
 	}
 }
 
@@ -68,7 +116,8 @@ func New() (Config, error) {
 	cfg := Default()
 
 	options := []config.Option{}
-	if yamlPath := os.Getenv("CONFIG_PATH"); yamlPath != "" {
+	yamlPath := os.Getenv("CONFIG_PATH")
+	if yamlPath != "" {
 		options = append(options, config.WithLocalYAML(yamlPath))
 	}
 
@@ -76,5 +125,7 @@ func New() (Config, error) {
 		return Config{}, fmt.Errorf("failed to load config: %w", err)
 	}
 
+	cfg.path = yamlPath
+
 	return cfg, nil
 }
@@ -1,6 +1,12 @@
 package config
 
 import (
+	"fmt"
+
+	"github.com/apiarycd/apiarycd/internal/declarative"
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/git"
+	"github.com/apiarycd/apiarycd/internal/poller"
 	"github.com/apiarycd/apiarycd/pkg/badgerfx"
 	"github.com/apiarycd/apiarycd/pkg/dockerfx"
 	"github.com/apiarycd/apiarycd/pkg/openapifx"
@@ -37,6 +43,37 @@ func Module() fx.Option {
 				},
 			}
 		}),
+		fx.Provide(func(cfg Config) git.Config {
+			return git.Config{
+				CacheDir: cfg.Git.CacheDir,
+			}
+		}),
+		fx.Provide(func(cfg Config) (deployments.Config, error) {
+			c := deployments.Config{
+				Workers:  cfg.Deployments.Workers,
+				Timeout:  cfg.Deployments.Timeout,
+				Recovery: deployments.RecoveryPolicy(cfg.Deployments.Recovery),
+			}
+			if err := c.Validate(); err != nil {
+				return c, fmt.Errorf("deployments: %w", err)
+			}
+
+			return c, nil
+		}),
+		fx.Provide(func(cfg Config) poller.Config {
+			return poller.Config{
+				Interval: cfg.Poller.Interval,
+				Jitter:   cfg.Poller.Jitter,
+			}
+		}),
+		fx.Provide(func(cfg Config) declarative.Config {
+			return declarative.Config{
+				File:     cfg.path,
+				Dir:      cfg.Declarative.Dir,
+				Interval: cfg.Declarative.Interval,
+				Prune:    cfg.Declarative.Prune,
+			}
+		}),
 		fx.Provide(func(cfg Config) openapifx.Config {
 			return openapifx.Config{
 				Enabled:    cfg.HTTP.OpenAPI.Enabled,
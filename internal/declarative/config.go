@@ -0,0 +1,21 @@
+package declarative
+
+import "time"
+
+// Config holds the configuration for declarative stacks.
+type Config struct {
+	// File is a YAML file whose `stacks:` section defines stacks, usually the
+	// configuration file. Ignored if empty.
+	File string
+
+	// Dir is a directory of YAML files defining stacks. Ignored if empty.
+	Dir string
+
+	// Interval between checks of the sources for changes. Changes are only
+	// applied on startup if zero.
+	Interval time.Duration
+
+	// Prune deletes the declarative stacks no longer defined along with their
+	// Swarm resources.
+	Prune bool
+}
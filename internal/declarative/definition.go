@@ -0,0 +1,171 @@
+package declarative
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	// LabelManagedBy marks the stacks created from definitions. Only those
+	// stacks are pruned.
+	LabelManagedBy = "com.apiarycd.managed-by"
+
+	managedBy = "declarative"
+)
+
+// document is a YAML file defining stacks, either as a `stacks:` list or as
+// a single stack at the top level.
+type document struct {
+	Stacks []definition `yaml:"stacks"`
+
+	definition `yaml:",inline"`
+}
+
+// definition is the declarative form of a stack.
+type definition struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+
+	GitURL      string        `yaml:"git_url"`
+	GitBranch   string        `yaml:"git_branch"`
+	GitAuth     gitAuthSource `yaml:"git_auth"`
+	ComposePath string        `yaml:"compose_path"`
+
+	WebhookSecret *secretSource `yaml:"webhook_secret"`
+
+	DeployTimeout time.Duration `yaml:"deploy_timeout"`
+	DeployPolicy  string        `yaml:"deploy_policy"`
+	AutoRollback  bool          `yaml:"auto_rollback"`
+
+	Variables map[string]string `yaml:"variables"`
+	Labels    map[string]string `yaml:"labels"`
+}
+
+type gitAuthSource struct {
+	Username string        `yaml:"username"`
+	Password *secretSource `yaml:"password"`
+
+	SSHKey           *secretSource `yaml:"ssh_key"`
+	SSHKeyPassphrase *secretSource `yaml:"ssh_key_passphrase"`
+	KnownHosts       string        `yaml:"known_hosts"`
+}
+
+// secretSource references a secret kept out of the definition, in a file or
+// an environment variable.
+type secretSource struct {
+	File string `yaml:"file"`
+	Env  string `yaml:"env"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler. Inline values are rejected.
+func (s *secretSource) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: %w", node.Line, errSecretSource)
+	}
+
+	type plain secretSource
+	if err := node.Decode((*plain)(s)); err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+
+	if (s.File == "") == (s.Env == "") {
+		return fmt.Errorf("line %d: %w", node.Line, errSecretSource)
+	}
+
+	return nil
+}
+
+// resolve reads the secret, an empty string if s is nil. Trailing line
+// breaks of files are trimmed.
+func (s *secretSource) resolve() (string, error) {
+	if s == nil {
+		return "", nil
+	}
+
+	if s.Env != "" {
+		value, ok := os.LookupEnv(s.Env)
+		if !ok {
+			return "", fmt.Errorf("%w: env variable %s is not set", ErrSecretMissing, s.Env)
+		}
+		return value, nil
+	}
+
+	data, err := os.ReadFile(s.File)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrSecretMissing, err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// validate checks the definition against the rules the API applies to
+// stacks. Secrets are only checked once resolved.
+func (d *definition) validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+
+	draft := d.draft(stacks.GitAuth{}, "")
+	if err := draft.Validate(); err != nil {
+		return fmt.Errorf("%w: stack %q: %w", ErrInvalid, d.Name, err)
+	}
+
+	return nil
+}
+
+// toDraft returns the stack defined by d with its secrets resolved.
+func (d *definition) toDraft() (stacks.StackDraft, error) {
+	secrets := []*secretSource{d.GitAuth.Password, d.GitAuth.SSHKey, d.GitAuth.SSHKeyPassphrase, d.WebhookSecret}
+	values := make([]string, len(secrets))
+	for i, secret := range secrets {
+		value, err := secret.resolve()
+		if err != nil {
+			return stacks.StackDraft{}, fmt.Errorf("stack %q: %w", d.Name, err)
+		}
+		values[i] = value
+	}
+
+	draft := d.draft(stacks.GitAuth{
+		Username:         d.GitAuth.Username,
+		Password:         values[0],
+		SSHKey:           values[1],
+		SSHKeyPassphrase: values[2],
+		KnownHosts:       d.GitAuth.KnownHosts,
+	}, values[3])
+	if err := draft.Validate(); err != nil {
+		return stacks.StackDraft{}, fmt.Errorf("%w: stack %q: %w", ErrInvalid, d.Name, err)
+	}
+
+	return draft, nil
+}
+
+// draft returns the stack defined by d with the given secrets.
+func (d *definition) draft(auth stacks.GitAuth, webhookSecret string) stacks.StackDraft {
+	labels := make(map[string]string, len(d.Labels)+1)
+	maps.Copy(labels, d.Labels)
+	labels[LabelManagedBy] = managedBy
+
+	return stacks.StackDraft{
+		Name:        d.Name,
+		Description: d.Description,
+		GitURL:      d.GitURL,
+		GitBranch:   d.GitBranch,
+		GitAuth:     auth,
+		ComposePath: d.ComposePath,
+
+		WebhookSecret: webhookSecret,
+
+		DeployTimeout: d.DeployTimeout,
+		DeployPolicy:  stacks.DeployPolicy(d.DeployPolicy),
+		AutoRollback:  d.AutoRollback,
+
+		Variables: d.Variables,
+		Labels:    labels,
+	}
+}
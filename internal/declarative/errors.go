@@ -0,0 +1,10 @@
+package declarative
+
+import "errors"
+
+var (
+	ErrInvalid       = errors.New("invalid stack definition")
+	ErrSecretMissing = errors.New("secret not found")
+
+	errSecretSource = errors.New("secrets must reference either a file or an env variable")
+)
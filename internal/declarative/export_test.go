@@ -0,0 +1,7 @@
+package declarative
+
+// Load exposes load to the tests.
+func Load(config Config) (int, error) {
+	definitions, err := load(config)
+	return len(definitions), err
+}
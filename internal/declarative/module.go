@@ -0,0 +1,27 @@
+package declarative
+
+import (
+	"context"
+
+	"github.com/go-core-fx/logger"
+	"go.uber.org/fx"
+)
+
+func Module() fx.Option {
+	return fx.Module(
+		"declarative",
+		logger.WithNamedLogger("declarative"),
+		fx.Provide(New),
+		fx.Invoke(func(lc fx.Lifecycle, s *Syncer) {
+			lc.Append(fx.Hook{
+				OnStart: func(_ context.Context) error {
+					s.Start()
+					return nil
+				},
+				OnStop: func(ctx context.Context) error {
+					return s.Stop(ctx)
+				},
+			})
+		}),
+	)
+}
@@ -0,0 +1,98 @@
+package declarative
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// load reads the stack definitions of the configured file and directory.
+func load(config Config) ([]definition, error) {
+	var definitions []definition
+
+	if config.File != "" {
+		data, err := os.ReadFile(config.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", config.File, err)
+		}
+
+		// The file holds other settings as well, so only the stacks are
+		// decoded.
+		var doc struct {
+			Stacks []definition `yaml:"stacks"`
+		}
+		if unmErr := yaml.Unmarshal(data, &doc); unmErr != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalid, config.File, unmErr)
+		}
+
+		definitions = append(definitions, doc.Stacks...)
+	}
+
+	if config.Dir != "" {
+		entries, err := os.ReadDir(config.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", config.Dir, err)
+		}
+
+		for _, entry := range entries {
+			if ext := filepath.Ext(entry.Name()); entry.IsDir() || (ext != ".yml" && ext != ".yaml") {
+				continue
+			}
+
+			path := filepath.Join(config.Dir, entry.Name())
+			items, readErr := loadFile(path)
+			if readErr != nil {
+				return nil, readErr
+			}
+
+			definitions = append(definitions, items...)
+		}
+	}
+
+	names := make(map[string]struct{}, len(definitions))
+	for i := range definitions {
+		d := &definitions[i]
+		if err := d.validate(); err != nil {
+			return nil, err
+		}
+
+		if _, ok := names[d.Name]; ok {
+			return nil, fmt.Errorf("%w: stack %q is defined more than once", ErrInvalid, d.Name)
+		}
+		names[d.Name] = struct{}{}
+	}
+
+	return definitions, nil
+}
+
+// loadFile reads the definitions of a stack file. Unknown fields are
+// rejected to catch typos.
+func loadFile(path string) ([]definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var doc document
+	if decErr := dec.Decode(&doc); decErr != nil {
+		if errors.Is(decErr, io.EOF) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalid, path, decErr)
+	}
+
+	definitions := doc.Stacks
+	if doc.Name != "" {
+		definitions = append(definitions, doc.definition)
+	}
+
+	return definitions, nil
+}
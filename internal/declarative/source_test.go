@@ -0,0 +1,111 @@
+package declarative_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/declarative"
+)
+
+func TestLoadValidation(t *testing.T) {
+	t.Parallel()
+
+	const valid = "name: app\ngit_url: https://example.com/app.git\ngit_branch: main\ncompose_path: docker-compose.yml\n"
+
+	tests := []struct {
+		name       string
+		definition string
+		wantErr    error
+	}{
+		{name: "valid", definition: valid},
+		{name: "ssh url", definition: strings.Replace(valid, "https://example.com/app.git", "git@example.com:app.git", 1)},
+		{
+			name: "missing name in a list",
+			definition: "stacks:\n  - git_url: https://example.com/app.git\n" +
+				"    git_branch: main\n    compose_path: docker-compose.yml\n",
+			wantErr: declarative.ErrInvalid,
+		},
+		{
+			name:       "name longer than the API accepts",
+			definition: strings.Replace(valid, "name: app", "name: "+strings.Repeat("a", 101), 1),
+			wantErr:    declarative.ErrInvalid,
+		},
+		{
+			name:       "invalid git url",
+			definition: strings.Replace(valid, "https://example.com/app.git", "example.com/app", 1),
+			wantErr:    declarative.ErrInvalid,
+		},
+		{
+			name:       "timeout longer than the API accepts",
+			definition: valid + "deploy_timeout: 25h\n",
+			wantErr:    declarative.ErrInvalid,
+		},
+		{
+			name:       "unknown policy",
+			definition: valid + "deploy_policy: drop\n",
+			wantErr:    declarative.ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "app.yml"), []byte(tt.definition), 0o600); err != nil {
+				t.Fatalf("failed to write definition: %v", err)
+			}
+
+			n, err := declarative.Load(declarative.Config{Dir: dir})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && n != 1 {
+				t.Errorf("Load() = %d definitions, want 1", n)
+			}
+		})
+	}
+}
+
+func TestLoadSecretSource(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{name: "env", secret: "\n    env: GIT_PASSWORD", wantErr: false},
+		{name: "file", secret: "\n    file: /run/secrets/git_password", wantErr: false},
+		{name: "literal", secret: " literal", wantErr: true},
+		{name: "quoted literal", secret: ` "literal"`, wantErr: true},
+		{name: "empty", secret: " {}", wantErr: true},
+		{name: "both", secret: "\n    env: GIT_PASSWORD\n    file: /run/secrets/git_password", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			content := definition("app") + "git_auth:\n  username: deploy\n  password:" + tt.secret + "\n"
+			if err := os.WriteFile(filepath.Join(dir, "app.yml"), []byte(content), 0o600); err != nil {
+				t.Fatalf("failed to write definition: %v", err)
+			}
+
+			_, err := declarative.Load(declarative.Config{Dir: dir})
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, declarative.ErrInvalid) || !strings.Contains(err.Error(), "file or an env variable") {
+				t.Errorf("Load() error = %v, want the secret source rejected", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package declarative
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	"go.uber.org/zap"
+)
+
+// Syncer applies the declarative stack definitions on startup and whenever
+// they change. Definitions are the source of truth: changes made to their
+// stacks through the API are reverted.
+type Syncer struct {
+	config Config
+
+	stacksSvc      *stacks.Service
+	deploymentsSvc *deployments.Service
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	logger *zap.Logger
+}
+
+func New(
+	config Config,
+	stacksSvc *stacks.Service,
+	deploymentsSvc *deployments.Service,
+	logger *zap.Logger,
+) *Syncer {
+	return &Syncer{
+		config: config,
+
+		stacksSvc:      stacksSvc,
+		deploymentsSvc: deploymentsSvc,
+
+		cancel: nil,
+		done:   nil,
+
+		logger: logger,
+	}
+}
+
+// Start applies the definitions and watches them for changes in the
+// background.
+func (s *Syncer) Start() {
+	if s.config.File == "" && s.config.Dir == "" {
+		s.logger.Info("declarative stacks disabled")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go s.run(ctx)
+
+	s.logger.Info("declarative stacks started",
+		zap.String("file", s.config.File),
+		zap.String("dir", s.config.Dir),
+		zap.Duration("interval", s.config.Interval),
+		zap.Bool("prune", s.config.Prune),
+	)
+}
+
+// Stop stops watching the definitions and waits for the current sync to
+// finish.
+func (s *Syncer) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+
+	s.cancel()
+
+	select {
+	case <-s.done:
+		s.logger.Info("declarative stacks stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to stop declarative stacks: %w", ctx.Err())
+	}
+}
+
+func (s *Syncer) run(ctx context.Context) {
+	defer close(s.done)
+
+	if err := s.Sync(ctx); err != nil {
+		s.logger.Error("failed to sync declarative stacks", zap.Error(err))
+	}
+
+	if s.config.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Sync(ctx); err != nil {
+				s.logger.Error("failed to sync declarative stacks", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Sync creates the defined stacks that do not exist, updates the ones that
+// differ from their definition and, with prune, deletes the declarative
+// stacks no longer defined. Stacks created through the API with a defined
+// name are taken over. Nothing is applied if a definition is invalid or one
+// of its secrets cannot be read.
+func (s *Syncer) Sync(ctx context.Context) error {
+	definitions, err := load(s.config)
+	if err != nil {
+		return err
+	}
+
+	drafts := make(map[string]stacks.StackDraft, len(definitions))
+	for _, d := range definitions {
+		draft, draftErr := d.toDraft()
+		if draftErr != nil {
+			return draftErr
+		}
+		drafts[d.Name] = draft
+	}
+
+	items, err := s.stacksSvc.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list stacks: %w", err)
+	}
+
+	existing := make(map[string]stacks.Stack, len(items))
+	for _, stack := range items {
+		existing[stack.Name] = stack
+	}
+
+	var errs []error
+	for name, draft := range drafts {
+		if applyErr := s.apply(ctx, existing, draft); applyErr != nil {
+			errs = append(errs, fmt.Errorf("stack %q: %w", name, applyErr))
+		}
+	}
+
+	if s.config.Prune {
+		if pruneErr := s.prune(ctx, drafts); pruneErr != nil {
+			errs = append(errs, pruneErr)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// apply creates or updates the stack of draft.
+func (s *Syncer) apply(ctx context.Context, existing map[string]stacks.Stack, draft stacks.StackDraft) error {
+	stack, ok := existing[draft.Name]
+	if !ok {
+		created, err := s.stacksSvc.Create(ctx, draft)
+		if err != nil {
+			return fmt.Errorf("failed to create stack: %w", err)
+		}
+
+		s.logger.Info("declarative stack created", zap.String("name", draft.Name), zap.String("id", created.ID.String()))
+		return nil
+	}
+
+	if equalDrafts(stack.StackDraft, draft) {
+		return nil
+	}
+
+	if stack.Labels[LabelManagedBy] != managedBy {
+		s.logger.Info("taking over stack", zap.String("name", draft.Name), zap.String("id", stack.ID.String()))
+	}
+
+	if err := s.stacksSvc.Update(ctx, stack.ID, func(current *stacks.Stack) error {
+		current.StackDraft = draft
+		return current.Validate()
+	}); err != nil {
+		return fmt.Errorf("failed to update stack: %w", err)
+	}
+
+	s.logger.Info("declarative stack updated", zap.String("name", draft.Name), zap.String("id", stack.ID.String()))
+	return nil
+}
+
+// prune deletes the declarative stacks that are not defined anymore along
+// with their Swarm resources.
+func (s *Syncer) prune(ctx context.Context, drafts map[string]stacks.StackDraft) error {
+	managed, err := s.stacksSvc.Find(ctx, stacks.Filter{
+		Statuses: nil,
+		Labels: []stacks.LabelSelector{
+			{Key: LabelManagedBy, Operator: stacks.OperatorEquals, Values: []string{managedBy}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find declarative stacks: %w", err)
+	}
+
+	var errs []error
+	for _, stack := range managed {
+		if _, ok := drafts[stack.Name]; ok {
+			continue
+		}
+
+		if delErr := s.deploymentsSvc.DeleteStack(ctx, stack.ID, true); delErr != nil {
+			errs = append(errs, fmt.Errorf("stack %q: failed to prune: %w", stack.Name, delErr))
+			continue
+		}
+
+		s.logger.Info("declarative stack pruned", zap.String("name", stack.Name), zap.String("id", stack.ID.String()))
+	}
+
+	return errors.Join(errs...)
+}
+
+// equalDrafts reports whether two stacks have the same configuration. Empty
+// and missing maps are equal.
+func equalDrafts(a, b stacks.StackDraft) bool {
+	if !maps.Equal(a.Variables, b.Variables) || !maps.Equal(a.Labels, b.Labels) {
+		return false
+	}
+
+	a.Variables, b.Variables = nil, nil
+	a.Labels, b.Labels = nil, nil
+
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,284 @@
+package declarative_test
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/compose"
+	"github.com/apiarycd/apiarycd/internal/declarative"
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/reconciler"
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	"github.com/apiarycd/apiarycd/internal/swarm/swarmtest"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/moby/moby/api/types/swarm"
+	"go.uber.org/zap"
+)
+
+// definition returns the definition of a stack named name.
+func definition(name string) string {
+	return "name: " + name + "\ngit_url: https://example.com/" + name + ".git\n" +
+		"git_branch: main\ncompose_path: docker-compose.yml\n"
+}
+
+// env is a syncer of the definitions of a directory over an in-memory
+// database and a fake Swarm.
+type env struct {
+	syncer *declarative.Syncer
+	stacks *stacks.Service
+	engine *swarmtest.Engine
+	dir    string
+}
+
+func newEnv(t *testing.T, prune bool) *env {
+	t.Helper()
+
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	logger := zap.NewNop()
+	eng := swarmtest.NewEngine()
+	stacksSvc := stacks.NewService(stacks.NewRepository(db), logger)
+	deploymentsSvc := deployments.NewService(
+		deployments.Config{},
+		deployments.NewRepository(db),
+		deployments.NewLogRepository(db),
+		deployments.NewManifestRepository(db),
+		stacksSvc,
+		nil,
+		reconciler.New(eng.Swarm(t), logger),
+		logger,
+	)
+
+	dir := t.TempDir()
+	syncer := declarative.New(declarative.Config{Dir: dir, Prune: prune}, stacksSvc, deploymentsSvc, logger)
+
+	return &env{syncer: syncer, stacks: stacksSvc, engine: eng, dir: dir}
+}
+
+func (e *env) write(t *testing.T, name, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(e.dir, name+".yml"), []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write definition: %v", err)
+	}
+}
+
+func (e *env) remove(t *testing.T, name string) {
+	t.Helper()
+
+	if err := os.Remove(filepath.Join(e.dir, name+".yml")); err != nil {
+		t.Fatalf("failed to remove definition: %v", err)
+	}
+}
+
+func (e *env) sync(t *testing.T) {
+	t.Helper()
+
+	if err := e.syncer.Sync(t.Context()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+}
+
+// list returns the stacks by name.
+func (e *env) list(t *testing.T) map[string]stacks.Stack {
+	t.Helper()
+
+	items, err := e.stacks.List(t.Context())
+	if err != nil {
+		t.Fatalf("failed to list stacks: %v", err)
+	}
+
+	byName := make(map[string]stacks.Stack, len(items))
+	for _, s := range items {
+		byName[s.Name] = s
+	}
+
+	return byName
+}
+
+func TestSyncerSync(t *testing.T) {
+	t.Parallel()
+
+	e := newEnv(t, false)
+	e.write(t, "app", definition("app")+"variables:\n  REPLICAS: \"2\"\n")
+
+	e.sync(t)
+	created, ok := e.list(t)["app"]
+	if !ok {
+		t.Fatal("stack app not created")
+	}
+	if created.Labels[declarative.LabelManagedBy] != "declarative" || created.Variables["REPLICAS"] != "2" {
+		t.Errorf("created stack labels = %v variables = %v, want the definition", created.Labels, created.Variables)
+	}
+
+	// Nothing is written when the definition is unchanged.
+	e.sync(t)
+	unchanged := e.list(t)["app"]
+	if !unchanged.UpdatedAt.Equal(created.UpdatedAt) {
+		t.Errorf("stack updated at %v by an unchanged definition, want %v", unchanged.UpdatedAt, created.UpdatedAt)
+	}
+
+	e.write(t, "app", definition("app")+"variables:\n  REPLICAS: \"3\"\n")
+	e.sync(t)
+	updated := e.list(t)["app"]
+	if updated.ID != created.ID || updated.Variables["REPLICAS"] != "3" {
+		t.Errorf("updated stack = %s variables %v, want %s with the new variables",
+			updated.ID, updated.Variables, created.ID)
+	}
+	if !updated.UpdatedAt.After(created.UpdatedAt) {
+		t.Errorf("stack updated at %v, want after %v", updated.UpdatedAt, created.UpdatedAt)
+	}
+}
+
+func TestSyncerRevertsChanges(t *testing.T) {
+	t.Parallel()
+
+	e := newEnv(t, false)
+	e.write(t, "app", definition("app"))
+	e.sync(t)
+
+	stack := e.list(t)["app"]
+	if err := e.stacks.Update(t.Context(), stack.ID, func(s *stacks.Stack) error {
+		s.GitBranch = "feature"
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to update stack: %v", err)
+	}
+
+	e.sync(t)
+	if got := e.list(t)["app"].GitBranch; got != "main" {
+		t.Errorf("stack branch = %q, want the defined main", got)
+	}
+}
+
+func TestSyncerTakeOver(t *testing.T) {
+	t.Parallel()
+
+	e := newEnv(t, false)
+	manual, err := e.stacks.Create(t.Context(), stacks.StackDraft{
+		Name:        "app",
+		GitURL:      "https://example.com/manual.git",
+		GitBranch:   "main",
+		ComposePath: "docker-compose.yml",
+		Labels:      map[string]string{"team": "web"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create stack: %v", err)
+	}
+
+	e.write(t, "app", definition("app"))
+	e.sync(t)
+
+	stacksByName := e.list(t)
+	if len(stacksByName) != 1 {
+		t.Fatalf("stacks = %v, want only app", slices.Sorted(maps.Keys(stacksByName)))
+	}
+	got := stacksByName["app"]
+	if got.ID != manual.ID {
+		t.Errorf("stack ID = %s, want the existing %s", got.ID, manual.ID)
+	}
+	if got.GitURL != "https://example.com/app.git" {
+		t.Errorf("stack git URL = %q, want the defined one", got.GitURL)
+	}
+	wantLabels := map[string]string{declarative.LabelManagedBy: "declarative"}
+	if !maps.Equal(got.Labels, wantLabels) {
+		t.Errorf("stack labels = %v, want %v", got.Labels, wantLabels)
+	}
+}
+
+func TestSyncerPrune(t *testing.T) {
+	t.Parallel()
+
+	for _, prune := range []bool{false, true} {
+		t.Run(map[bool]string{false: "disabled", true: "enabled"}[prune], func(t *testing.T) {
+			t.Parallel()
+
+			e := newEnv(t, prune)
+			e.write(t, "app", definition("app"))
+			e.write(t, "old", definition("old"))
+			e.sync(t)
+
+			if _, err := e.stacks.Create(t.Context(), stacks.StackDraft{
+				Name:        "manual",
+				GitURL:      "https://example.com/manual.git",
+				GitBranch:   "main",
+				ComposePath: "docker-compose.yml",
+			}); err != nil {
+				t.Fatalf("failed to create stack: %v", err)
+			}
+			for _, namespace := range []string{"app", "old", "manual"} {
+				e.engine.AddService(swarm.ServiceSpec{
+					Annotations: swarm.Annotations{
+						Name:   namespace + "_web",
+						Labels: map[string]string{compose.LabelNamespace: namespace},
+					},
+					TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "nginx"}},
+				})
+			}
+
+			e.remove(t, "old")
+			e.sync(t)
+
+			wantStacks := []string{"app", "manual", "old"}
+			wantServices := []string{"app_web", "manual_web", "old_web"}
+			if prune {
+				wantStacks = []string{"app", "manual"}
+				wantServices = []string{"app_web", "manual_web"}
+			}
+			if got := slices.Sorted(maps.Keys(e.list(t))); !slices.Equal(got, wantStacks) {
+				t.Errorf("stacks = %v, want %v", got, wantStacks)
+			}
+			if got := slices.Sorted(maps.Keys(e.engine.Services())); !slices.Equal(got, wantServices) {
+				t.Errorf("services = %v, want %v", got, wantServices)
+			}
+		})
+	}
+}
+
+func TestSyncerMissingSecret(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{name: "env", secret: "webhook_secret:\n  env: APIARYCD_TEST_UNSET_WEBHOOK_SECRET\n"},
+		{name: "file", secret: "git_auth:\n  password:\n    file: /nonexistent/password\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := newEnv(t, true)
+			e.write(t, "app", definition("app"))
+			e.sync(t)
+
+			e.write(t, "app", definition("app")+"description: changed\n")
+			e.write(t, "other", definition("other")+tt.secret)
+
+			err := e.syncer.Sync(t.Context())
+			if !errors.Is(err, declarative.ErrSecretMissing) {
+				t.Fatalf("Sync() error = %v, want %v", err, declarative.ErrSecretMissing)
+			}
+
+			// Neither the new stack nor the change of the valid definition
+			// is applied.
+			got := e.list(t)
+			if names := slices.Sorted(maps.Keys(got)); !slices.Equal(names, []string{"app"}) {
+				t.Errorf("stacks = %v, want [app]", names)
+			}
+			if got["app"].Description != "" {
+				t.Errorf("stack description = %q, want the previous empty one", got["app"].Description)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// cancellation is the cause of a deployment context cancelled on request.
+type cancellation struct {
+	action CancelAction
+}
+
+func (c *cancellation) Error() string {
+	return "deployment cancelled"
+}
+
+// Cancel stops a pending or running deployment and records who cancelled it.
+// A running deployment stops mutating the cluster; service updates it has
+// already started are rolled back with CancelActionRollback and otherwise left
+// to finish, as Swarm cannot pause an update on request.
+func (s *Service) Cancel(ctx context.Context, id uuid.UUID, req CancelRequest) (*Deployment, error) {
+	logger := s.logger.With(zap.String("deployment_id", id.String()))
+
+	var cancelled *Deployment
+	now := time.Now()
+	if err := s.update(ctx, id, func(d *Deployment) error {
+		if d.IsFinished() {
+			return fmt.Errorf("%w: deployment is already %s", ErrConflict, d.Status)
+		}
+
+		d.MarkCancelled(now, req.CancelledBy)
+		cancelled = d
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, id, LogLevelWarn, "deployment cancelled by %s", req.CancelledBy)
+
+	s.runningMu.Lock()
+	cancel, ok := s.running[id]
+	s.runningMu.Unlock()
+
+	if ok {
+		cancel(&cancellation{action: req.Action})
+	}
+
+	logger.Info("deployment cancelled",
+		zap.String("cancelled_by", req.CancelledBy),
+		zap.String("action", string(req.Action)),
+		zap.Bool("running", ok),
+	)
+
+	return cancelled, nil
+}
+
+// track registers the cancel function of a deployment being executed and
+// returns a function that unregisters it.
+func (s *Service) track(id uuid.UUID, cancel context.CancelCauseFunc) func() {
+	s.runningMu.Lock()
+	s.running[id] = cancel
+	s.runningMu.Unlock()
+
+	return func() {
+		s.runningMu.Lock()
+		delete(s.running, id)
+		s.runningMu.Unlock()
+	}
+}
@@ -0,0 +1,142 @@
+package deployments_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+)
+
+func TestServiceCancel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  deployments.Status
+		wantErr error
+	}{
+		{name: "pending", status: deployments.StatusPending, wantErr: nil},
+		{name: "running", status: deployments.StatusRunning, wantErr: nil},
+		{name: "success", status: deployments.StatusSuccess, wantErr: deployments.ErrConflict},
+		{name: "failed", status: deployments.StatusFailed, wantErr: deployments.ErrConflict},
+		{name: "cancelled", status: deployments.StatusCancelled, wantErr: deployments.ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := newEnv(t)
+			stack := e.createStack(t, "app")
+			d := e.createDeployment(t, stack.ID, tt.status)
+
+			before := time.Now()
+			cancelled, err := e.svc.Cancel(t.Context(), d.ID, deployments.CancelRequest{
+				CancelledBy: "alice",
+				Action:      deployments.CancelActionNone,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Cancel() error = %v, want %v", err, tt.wantErr)
+			}
+
+			got := e.getDeployment(t, d.ID)
+			if tt.wantErr != nil {
+				if got.Status != tt.status || got.CancelledBy != "" {
+					t.Errorf("deployment = %s cancelled by %q, want %s untouched", got.Status, got.CancelledBy, tt.status)
+				}
+				return
+			}
+
+			if cancelled.Status != deployments.StatusCancelled {
+				t.Errorf("Cancel() status = %s, want %s", cancelled.Status, deployments.StatusCancelled)
+			}
+			if got.Status != deployments.StatusCancelled || got.CancelledBy != "alice" {
+				t.Errorf("deployment = %s cancelled by %q, want %s by alice",
+					got.Status, got.CancelledBy, deployments.StatusCancelled)
+			}
+			if got.CancelledAt == nil || got.CancelledAt.Before(before) || got.CancelledAt.After(time.Now()) {
+				t.Errorf("deployment cancelled at = %v, want the time of the request", got.CancelledAt)
+			}
+		})
+	}
+}
+
+func TestServiceCancelRunning(t *testing.T) {
+	t.Parallel()
+
+	actions := []deployments.CancelAction{deployments.CancelActionNone, deployments.CancelActionRollback}
+
+	for _, action := range actions {
+		t.Run(string(action), func(t *testing.T) {
+			t.Parallel()
+
+			e := newEnv(t)
+			stack := e.createStack(t, "app")
+			d := e.createDeployment(t, stack.ID, deployments.StatusRunning)
+
+			// The execution of the deployment.
+			runCtx, cancel := context.WithCancelCause(t.Context())
+			defer cancel(nil)
+			defer e.svc.Track(d.ID, cancel)()
+
+			if _, err := e.svc.Cancel(t.Context(), d.ID, deployments.CancelRequest{
+				CancelledBy: "alice",
+				Action:      action,
+			}); err != nil {
+				t.Fatalf("Cancel() error = %v", err)
+			}
+
+			select {
+			case <-runCtx.Done():
+			default:
+				t.Fatal("execution context not cancelled")
+			}
+			got, ok := deployments.CancellationAction(context.Cause(runCtx))
+			if !ok || got != action {
+				t.Errorf("cancellation cause = %v, want action %s", context.Cause(runCtx), action)
+			}
+		})
+	}
+}
+
+func TestServiceCancelConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const callers = 8
+
+	e := newEnv(t)
+	stack := e.createStack(t, "app")
+	d := e.createDeployment(t, stack.ID, deployments.StatusPending)
+
+	errs := make(chan error, callers)
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := e.svc.Cancel(t.Context(), d.ID, deployments.CancelRequest{
+				CancelledBy: "alice",
+				Action:      deployments.CancelActionNone,
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, deployments.ErrConflict):
+			t.Errorf("Cancel() error = %v, want nil or %v", err, deployments.ErrConflict)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("successful Cancel() calls = %d, want 1", succeeded)
+	}
+}
@@ -0,0 +1,42 @@
+package deployments
+
+import (
+	"fmt"
+	"time"
+)
+
+// RecoveryPolicy decides what happens on startup to deployments that were
+// running when the previous process stopped.
+type RecoveryPolicy string
+
+const (
+	RecoveryPolicyFail   RecoveryPolicy = "fail"   // Mark them failed
+	RecoveryPolicyResume RecoveryPolicy = "resume" // Execute them again
+)
+
+// Config holds the configuration for deployments.
+type Config struct {
+	// Workers is the number of deployments executed concurrently.
+	Workers int
+
+	// Timeout is the default time a rollout may take to converge. Stacks may
+	// override it.
+	Timeout time.Duration
+
+	// Recovery is the policy for deployments interrupted by a restart, fail
+	// if empty.
+	Recovery RecoveryPolicy
+}
+
+// Validate reports an unknown recovery policy.
+func (c Config) Validate() error {
+	switch c.Recovery {
+	case "", RecoveryPolicyFail, RecoveryPolicyResume:
+		return nil
+	}
+
+	return fmt.Errorf(
+		"%w: unknown recovery policy %q, expected %q or %q",
+		ErrInvalidConfig, c.Recovery, RecoveryPolicyFail, RecoveryPolicyResume,
+	)
+}
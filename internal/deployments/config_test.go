@@ -0,0 +1,30 @@
+package deployments_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+)
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		recovery deployments.RecoveryPolicy
+		wantErr  error
+	}{
+		{recovery: "", wantErr: nil},
+		{recovery: deployments.RecoveryPolicyFail, wantErr: nil},
+		{recovery: deployments.RecoveryPolicyResume, wantErr: nil},
+		{recovery: "Resume", wantErr: deployments.ErrInvalidConfig},
+		{recovery: "retry", wantErr: deployments.ErrInvalidConfig},
+	}
+
+	for _, tt := range tests {
+		err := deployments.Config{Recovery: tt.recovery}.Validate()
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("Validate() with recovery %q error = %v, want %v", tt.recovery, err, tt.wantErr)
+		}
+	}
+}
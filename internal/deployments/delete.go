@@ -0,0 +1,125 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// deleteBatchSize is the number of deployments, or lines of a log, deleted
+// per transaction.
+const deleteBatchSize = 500
+
+// DeleteStack deletes a stack with its deployments and their logs. The history
+// is deleted in batches before the stack, so a failed deletion leaves the
+// stack in place and can be retried. With prune, the Swarm services, networks, configs and secrets
+// of the stack are removed first and the stack is kept if that fails, so the
+// deletion can be retried. Manifest snapshots are shared by their digest and
+// kept. Stacks with a deployment in progress cannot be deleted, and no
+// deployment can be triggered while the deletion is in progress.
+func (s *Service) DeleteStack(ctx context.Context, stackID uuid.UUID, prune bool) error {
+	logger := s.logger.With(zap.String("stack_id", stackID.String()), zap.Bool("prune", prune))
+
+	stack, err := s.stacksSvc.Get(ctx, stackID)
+	if err != nil {
+		return fmt.Errorf("failed to get stack: %w", err)
+	}
+
+	if beginErr := s.beginDelete(ctx, stackID); beginErr != nil {
+		return beginErr
+	}
+	defer s.endDelete(stackID)
+
+	if prune {
+		removeCtx, cancel := context.WithTimeout(ctx, s.timeout(stack))
+		defer cancel()
+
+		// Resources left by a rename that has not been deployed yet are
+		// removed as well.
+		namespaces := []string{stack.Namespace()}
+		if stack.PreviousNamespace != "" {
+			namespaces = append(namespaces, stack.PreviousNamespace)
+		}
+
+		for _, namespace := range namespaces {
+			result, rmErr := s.reconciler.Remove(removeCtx, namespace)
+			if rmErr != nil {
+				logger.Error("failed to remove stack resources", zap.String("namespace", namespace), zap.Error(rmErr))
+				return fmt.Errorf("failed to remove stack resources: %w", rmErr)
+			}
+
+			logger.Info("stack resources removed", zap.String("namespace", namespace), zap.Int("changes", len(result.Changes)))
+		}
+	}
+
+	if histErr := s.deleteHistory(ctx, stackID); histErr != nil {
+		logger.Error("failed to delete deployment history", zap.Error(histErr))
+		return histErr
+	}
+
+	if delErr := s.stacksSvc.Delete(ctx, stackID); delErr != nil {
+		return fmt.Errorf("failed to delete stack: %w", delErr)
+	}
+
+	logger.Info("stack deleted")
+	return nil
+}
+
+// beginDelete marks the stack as being deleted unless a deployment of the
+// stack is in progress.
+func (s *Service) beginDelete(ctx context.Context, stackID uuid.UUID) error {
+	s.triggerMu.Lock()
+	defer s.triggerMu.Unlock()
+
+	if s.deleting[stackID] {
+		return fmt.Errorf("%w: stack is being deleted", ErrConflict)
+	}
+
+	if err := s.checkIdle(ctx, stackID); err != nil {
+		return err
+	}
+
+	s.deleting[stackID] = true
+	return nil
+}
+
+func (s *Service) endDelete(stackID uuid.UUID) {
+	s.triggerMu.Lock()
+	defer s.triggerMu.Unlock()
+
+	delete(s.deleting, stackID)
+}
+
+// deleteHistory deletes the deployments of a stack and their logs, oldest
+// first. Each batch is committed on its own to stay below the transaction
+// size limit. The logs of a deployment are deleted before the deployment, so
+// an interrupted deletion leaves no orphaned logs behind.
+func (s *Service) deleteHistory(ctx context.Context, stackID uuid.UUID) error {
+	deleted := 0
+	for {
+		ids, err := s.deployments.listIDsByStack(ctx, stackID, deleteBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		for _, id := range ids {
+			if logErr := s.logs.Delete(ctx, id, deleteBatchSize); logErr != nil {
+				return fmt.Errorf("failed to delete logs of deployment %s: %w", id, logErr)
+			}
+		}
+
+		if delErr := s.deployments.deleteMany(ctx, ids); delErr != nil {
+			return delErr
+		}
+
+		deleted += len(ids)
+	}
+
+	s.logger.Info("deployments of stack deleted", zap.String("stack_id", stackID.String()), zap.Int("count", deleted))
+	return nil
+}
@@ -0,0 +1,216 @@
+package deployments_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/git"
+	"github.com/apiarycd/apiarycd/internal/reconciler"
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// env is a deployments service over an in-memory database. It has no git
+// client, so only operations that do not deploy can be tested, and no
+// reconciler unless it is created with newEnvWithReconciler or newEnvWith.
+type env struct {
+	svc *deployments.Service
+
+	stacks      *stacks.Service
+	deployments *deployments.Repository
+	logs        *deployments.LogRepository
+	manifests   *deployments.ManifestRepository
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+
+	return newEnvWithReconciler(t, nil)
+}
+
+func newEnvWithReconciler(t *testing.T, rec *reconciler.Reconciler) *env {
+	t.Helper()
+
+	return newEnvWith(t, nil, rec)
+}
+
+func newEnvWith(t *testing.T, gitClient *git.Client, rec *reconciler.Reconciler) *env {
+	t.Helper()
+
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	logger := zap.NewNop()
+	stacksSvc := stacks.NewService(stacks.NewRepository(db), logger)
+	repo := deployments.NewRepository(db)
+	logs := deployments.NewLogRepository(db)
+	manifests := deployments.NewManifestRepository(db)
+
+	svc := deployments.NewService(
+		deployments.Config{},
+		repo,
+		logs,
+		manifests,
+		stacksSvc,
+		gitClient,
+		rec,
+		logger,
+	)
+
+	return &env{svc: svc, stacks: stacksSvc, deployments: repo, logs: logs, manifests: manifests}
+}
+
+func (e *env) createStack(t *testing.T, name string) *stacks.Stack {
+	t.Helper()
+
+	stack, err := e.stacks.Create(t.Context(), stacks.StackDraft{
+		Name:        name,
+		GitURL:      "https://example.com/" + name + ".git",
+		GitBranch:   "main",
+		ComposePath: "docker-compose.yml",
+	})
+	if err != nil {
+		t.Fatalf("failed to create stack: %v", err)
+	}
+
+	return stack
+}
+
+func (e *env) createDeployment(t *testing.T, stackID uuid.UUID, status deployments.Status) *deployments.Deployment {
+	t.Helper()
+
+	d, err := e.deployments.Create(t.Context(), &deployments.DeploymentDraft{
+		StackID: stackID,
+		Version: uuid.NewString(),
+		GitRef:  "main",
+		Status:  status,
+	})
+	if err != nil {
+		t.Fatalf("failed to create deployment: %v", err)
+	}
+
+	return d
+}
+
+func (e *env) getDeployment(t *testing.T, id uuid.UUID) *deployments.Deployment {
+	t.Helper()
+
+	d, err := e.deployments.GetByID(t.Context(), id)
+	if err != nil {
+		t.Fatalf("failed to get deployment: %v", err)
+	}
+
+	return d
+}
+
+func (e *env) updateDeployment(t *testing.T, id uuid.UUID, updater func(*deployments.Deployment)) {
+	t.Helper()
+
+	if err := e.deployments.Update(t.Context(), id, func(d *deployments.Deployment) error {
+		updater(d)
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to update deployment: %v", err)
+	}
+}
+
+func (e *env) appendLogs(t *testing.T, deploymentID uuid.UUID, n int) {
+	t.Helper()
+
+	for range n {
+		if _, err := e.logs.Append(t.Context(), deploymentID, deployments.LogLevelInfo, "line"); err != nil {
+			t.Fatalf("failed to append log: %v", err)
+		}
+	}
+}
+
+func TestServiceDeleteStack(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		deployments int
+		logLines    int
+	}{
+		{name: "no history", deployments: 0, logLines: 0},
+		{name: "short history", deployments: 3, logLines: 10},
+		{name: "more deployments than a batch", deployments: 1100, logLines: 0},
+		{name: "longer log than a batch", deployments: 1, logLines: 1200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := newEnv(t)
+			stack := e.createStack(t, "app")
+			other := e.createStack(t, "other")
+			kept := e.createDeployment(t, other.ID, deployments.StatusSuccess)
+			e.appendLogs(t, kept.ID, 1)
+
+			ids := make([]uuid.UUID, 0, tt.deployments)
+			for range tt.deployments {
+				ids = append(ids, e.createDeployment(t, stack.ID, deployments.StatusSuccess).ID)
+			}
+			if len(ids) > 0 {
+				e.appendLogs(t, ids[0], tt.logLines)
+			}
+
+			if err := e.svc.DeleteStack(t.Context(), stack.ID, false); err != nil {
+				t.Fatalf("DeleteStack() error = %v", err)
+			}
+
+			if _, err := e.stacks.Get(t.Context(), stack.ID); err == nil {
+				t.Error("stack still exists")
+			}
+
+			history, err := e.deployments.ListByStack(t.Context(), stack.ID)
+			if err != nil {
+				t.Fatalf("ListByStack() error = %v", err)
+			}
+			if len(history) != 0 {
+				t.Errorf("ListByStack() = %d deployments, want 0", len(history))
+			}
+
+			for _, id := range ids {
+				lines, listErr := e.logs.List(t.Context(), id, 0, 1)
+				if listErr != nil {
+					t.Fatalf("List() error = %v", listErr)
+				}
+				if len(lines) != 0 {
+					t.Fatalf("log of deployment %s not deleted", id)
+				}
+			}
+
+			// Other stacks are left alone.
+			if _, getErr := e.deployments.GetByID(t.Context(), kept.ID); getErr != nil {
+				t.Errorf("deployment of another stack deleted: %v", getErr)
+			}
+			if lines, _ := e.logs.List(t.Context(), kept.ID, 0, 1); len(lines) != 1 {
+				t.Error("log of another stack deleted")
+			}
+		})
+	}
+}
+
+func TestServiceDeleteStackInProgress(t *testing.T) {
+	t.Parallel()
+
+	e := newEnv(t)
+	stack := e.createStack(t, "app")
+	e.createDeployment(t, stack.ID, deployments.StatusRunning)
+
+	if err := e.svc.DeleteStack(t.Context(), stack.ID, false); !errors.Is(err, deployments.ErrConflict) {
+		t.Fatalf("DeleteStack() error = %v, want %v", err, deployments.ErrConflict)
+	}
+
+	if _, err := e.stacks.Get(t.Context(), stack.ID); err != nil {
+		t.Errorf("stack deleted: %v", err)
+	}
+}
@@ -3,6 +3,8 @@ package deployments
 import (
 	"time"
 
+	"github.com/apiarycd/apiarycd/internal/compose"
+	"github.com/apiarycd/apiarycd/internal/reconciler"
 	"github.com/google/uuid"
 )
 
@@ -17,14 +19,38 @@ const (
 	StatusRolledBack Status = "rolled_back" // Deployment was rolled back
 )
 
+func (s Status) isValid() bool {
+	switch s {
+	case StatusPending, StatusRunning, StatusSuccess, StatusFailed, StatusCancelled, StatusRolledBack:
+		return true
+	}
+
+	return false
+}
+
 type DeploymentRequest struct {
 	// References
 	StackID uuid.UUID
 
+	// Deployment Details
+	Ref string // Branch, tag or commit to deploy; defaults to the stack branch
+
 	// Deployment Configuration
 	Variables map[string]string // Deployment-specific variables
 }
 
+type CancelAction string
+
+const (
+	CancelActionNone     CancelAction = "none"     // Let started service updates finish
+	CancelActionRollback CancelAction = "rollback" // Roll back started service updates
+)
+
+type CancelRequest struct {
+	CancelledBy string       // Who cancelled the deployment
+	Action      CancelAction // What to do with service updates already started
+}
+
 type DeploymentDraft struct {
 	// References
 	StackID uuid.UUID
@@ -36,6 +62,7 @@ type DeploymentDraft struct {
 
 	// Deployment Configuration
 	Variables map[string]string // Deployment-specific variables
+	Manifest  string            // Digest of the rendered manifest snapshot, set once rendered
 
 	// Status
 	Status      Status     // pending, running, success, failed, cancelled
@@ -43,11 +70,14 @@ type DeploymentDraft struct {
 	CompletedAt *time.Time // When deployment completed/failed
 	Error       string     // Error message if failed
 
-	// Logs and Metrics
-	Logs []string // Deployment logs
+	// Cancellation
+	CancelledBy string     // Who cancelled the deployment
+	CancelledAt *time.Time // When the deployment was cancelled
 
 	// Rollback Information
 	PreviousDeployment *uuid.UUID // Previous deployment ID for rollback
+	Origin             *uuid.UUID // Deployment redeployed by a rollback
+	RollbackOf         *uuid.UUID // Failed deployment reverted by an automatic rollback
 }
 
 type Deployment struct {
@@ -58,12 +88,100 @@ type Deployment struct {
 	UpdatedAt time.Time
 }
 
+func (d *Deployment) MarkRunning(startedAt time.Time) {
+	d.Status = StatusRunning
+	d.StartedAt = &startedAt
+}
+
 func (d *Deployment) MarkDeployedAt(deployedAt time.Time) {
 	d.Status = StatusSuccess
 	d.CompletedAt = &deployedAt
 }
 
-func (d *Deployment) MarkRolledBack(rolledBackAt time.Time) {
+// MarkRolledBack records that the changes of the deployment have been rolled
+// back, with the reason reported by err.
+func (d *Deployment) MarkRolledBack(rolledBackAt time.Time, err error) {
 	d.Status = StatusRolledBack
 	d.CompletedAt = &rolledBackAt
+	d.Error = err.Error()
+}
+
+func (d *Deployment) MarkCancelled(cancelledAt time.Time, cancelledBy string) {
+	d.Status = StatusCancelled
+	d.CompletedAt = &cancelledAt
+	d.CancelledAt = &cancelledAt
+	d.CancelledBy = cancelledBy
+}
+
+// IsFinished reports whether the deployment has reached a final status.
+func (d *Deployment) IsFinished() bool {
+	return d.Status != StatusPending && d.Status != StatusRunning
+}
+
+func (d *Deployment) MarkFailed(failedAt time.Time, err error) {
+	d.Status = StatusFailed
+	d.CompletedAt = &failedAt
+	d.Error = err.Error()
+}
+
+type SortOrder string
+
+const (
+	SortOrderAsc  SortOrder = "asc"  // Oldest first
+	SortOrderDesc SortOrder = "desc" // Newest first
+)
+
+// Filter selects deployments. Zero values match all deployments.
+type Filter struct {
+	StackID  *uuid.UUID
+	Statuses []Status
+	Since    *time.Time // Created at or after
+	Until    *time.Time // Created before
+}
+
+// PageRequest selects a page of deployments ordered by creation time.
+type PageRequest struct {
+	Order  SortOrder // Newest first by default
+	Cursor string    // Next cursor of the previous page, empty for the first page
+	Limit  int
+}
+
+// Page is a page of deployments.
+type Page struct {
+	Items []Deployment
+	Next  string // Cursor of the next page, empty on the last page
+}
+
+type LogLevel string
+
+const (
+	LogLevelInfo  LogLevel = "info"
+	LogLevelWarn  LogLevel = "warn"
+	LogLevelError LogLevel = "error"
+)
+
+// LogEntry is a line of a deployment log.
+type LogEntry struct {
+	Seq     uint64 // Position in the log, starting at 1
+	Time    time.Time
+	Level   LogLevel
+	Message string
+}
+
+// Manifest is an immutable snapshot of the rendered Swarm resources of a
+// deployment, addressed by a digest of its content.
+type Manifest struct {
+	Digest string // "sha256:" followed by the hex digest
+	Stack  *compose.Stack
+}
+
+// Plan describes the changes a deployment of a commit would make to the
+// services of a stack.
+type Plan struct {
+	Version string // Git commit SHA
+	GitRef  string // Reference the commit was resolved from
+	Message string // Git commit message
+
+	Namespace string
+	Services  []reconciler.ServiceChange
 }
@@ -0,0 +1,57 @@
+package deployments_test
+
+import (
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/compose"
+	"github.com/apiarycd/apiarycd/internal/reconciler"
+	"github.com/apiarycd/apiarycd/internal/swarm/swarmtest"
+	swarmtypes "github.com/moby/moby/api/types/swarm"
+	"go.uber.org/zap"
+)
+
+// engine is a fake Docker Engine API serving the resources of a Swarm.
+type engine struct {
+	*swarmtest.Engine
+}
+
+func newEngine() *engine {
+	return &engine{Engine: swarmtest.NewEngine()}
+}
+
+// addService adds a service of the namespace. replicas is nil for a global
+// service.
+func (e *engine) addService(namespace, name string, replicas *uint64) {
+	var spec swarmtypes.ServiceSpec
+	spec.Name = namespace + "_" + name
+	spec.Labels = map[string]string{compose.LabelNamespace: namespace}
+	spec.TaskTemplate.ContainerSpec = &swarmtypes.ContainerSpec{Image: "nginx:latest"}
+	if replicas != nil {
+		spec.Mode.Replicated = &swarmtypes.ReplicatedService{Replicas: replicas}
+	} else {
+		spec.Mode.Global = &swarmtypes.GlobalService{}
+	}
+
+	e.AddService(spec)
+}
+
+// replicas returns the replica counts of the replicated services by name.
+func (e *engine) replicas() map[string]uint64 {
+	services := e.Services()
+
+	replicas := make(map[string]uint64, len(services))
+	for name, s := range services {
+		if s.Spec.Mode.Replicated != nil {
+			replicas[name] = *s.Spec.Mode.Replicated.Replicas
+		}
+	}
+
+	return replicas
+}
+
+// newReconciler returns a reconciler managing the Swarm of the engine.
+func (e *engine) newReconciler(t *testing.T) *reconciler.Reconciler {
+	t.Helper()
+
+	return reconciler.New(e.Swarm(t), zap.NewNop())
+}
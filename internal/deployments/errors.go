@@ -5,4 +5,9 @@ import "errors"
 var (
 	ErrNotFound   = errors.New("deployment not found")
 	ErrNotAllowed = errors.New("operation not allowed")
+	ErrConflict   = errors.New("deployment conflict")
+
+	ErrInterrupted = errors.New("interrupted by restart")
+
+	ErrInvalidConfig = errors.New("invalid configuration")
 )
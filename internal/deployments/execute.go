@@ -0,0 +1,144 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/compose"
+	"github.com/apiarycd/apiarycd/internal/reconciler"
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	"go.uber.org/zap"
+)
+
+// execute renders the compose file of the deployment, removes the resources
+// left by a rename, applies it to the cluster and waits for the rollout to
+// converge.
+// The result of the apply is returned even if it fails half way.
+func (s *Service) execute(ctx context.Context, stack *stacks.Stack, d *Deployment) (*reconciler.Result, error) {
+	manifest, err := s.manifest(ctx, stack, d)
+	if err != nil {
+		return nil, err
+	}
+
+	if rmErr := s.removePreviousNamespace(ctx, stack, d); rmErr != nil {
+		return nil, rmErr
+	}
+
+	result, err := s.reconciler.Apply(ctx, manifest)
+	if result != nil {
+		for _, c := range result.Changes {
+			s.record(ctx, d.ID, LogLevelInfo, "%s %s: %s", c.Kind, c.Name, c.Action)
+		}
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to apply stack: %w", err)
+	}
+
+	s.logger.Info("stack applied",
+		zap.String("deployment_id", d.ID.String()),
+		zap.Int("changes", len(result.Changes)),
+	)
+
+	timeout := s.timeout(stack)
+
+	s.record(ctx, d.ID, LogLevelInfo, "waiting up to %s for the rollout to converge", timeout)
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if waitErr := s.reconciler.Wait(waitCtx, result, func(service, status string) {
+		s.record(ctx, d.ID, LogLevelInfo, "service %s: %s", service, status)
+	}); waitErr != nil {
+		return result, fmt.Errorf("rollout failed: %w", waitErr)
+	}
+
+	s.logger.Info("rollout converged", zap.String("deployment_id", d.ID.String()))
+
+	return result, nil
+}
+
+// timeout returns the time a rollout of the stack may take.
+func (s *Service) timeout(stack *stacks.Stack) time.Duration {
+	if stack.DeployTimeout > 0 {
+		return stack.DeployTimeout
+	}
+
+	return s.config.Timeout
+}
+
+// manifest returns the Swarm resources to apply for a deployment: the
+// snapshot it has been queued with, or the compose file rendered at its
+// commit, which is snapshotted before it is applied.
+func (s *Service) manifest(ctx context.Context, stack *stacks.Stack, d *Deployment) (*compose.Stack, error) {
+	if d.Manifest != "" {
+		snapshot, err := s.manifests.Get(ctx, d.Manifest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get manifest snapshot: %w", err)
+		}
+
+		// Snapshots are bound to the namespace they were rendered for.
+		if snapshot.Stack.Namespace == stack.Namespace() {
+			s.record(ctx, d.ID, LogLevelInfo, "reapplying manifest %s", snapshot.Digest)
+			return snapshot.Stack, nil
+		}
+
+		s.record(ctx, d.ID, LogLevelWarn, "manifest %s was rendered for namespace %s, rendering again",
+			snapshot.Digest, snapshot.Stack.Namespace)
+	}
+
+	manifest, err := s.render(ctx, stack, d.Version, d.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, d.ID, LogLevelInfo, "rendered %d services, %d networks, %d configs and %d secrets",
+		len(manifest.Services), len(manifest.Networks), len(manifest.Configs), len(manifest.Secrets))
+
+	digest, err := s.manifests.Put(ctx, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot manifest: %w", err)
+	}
+
+	if updErr := s.update(ctx, d.ID, func(current *Deployment) error {
+		current.Manifest = digest
+		return nil
+	}); updErr != nil {
+		return nil, fmt.Errorf("failed to record manifest snapshot: %w", updErr)
+	}
+
+	s.record(ctx, d.ID, LogLevelInfo, "manifest snapshot %s", digest)
+
+	return manifest, nil
+}
+
+// render reads the compose file of the stack at the given commit and
+// translates it into Swarm resources with variables interpolated.
+func (s *Service) render(
+	ctx context.Context,
+	stack *stacks.Stack,
+	sha string,
+	variables map[string]string,
+) (*compose.Stack, error) {
+	src := stack.GitSource()
+
+	data, err := s.git.ReadFile(ctx, src, sha, stack.ComposePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compose file: %w", err)
+	}
+
+	dir := path.Dir(stack.ComposePath)
+	manifest, err := compose.Load(data, compose.Options{
+		Namespace: stack.Namespace(),
+		Variables: variables,
+		ReadFile: func(name string) ([]byte, error) {
+			return s.git.ReadFile(ctx, src, sha, path.Join(dir, name)) //nolint:wrapcheck // wrapped by compose
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render compose file: %w", err)
+	}
+
+	return manifest, nil
+}
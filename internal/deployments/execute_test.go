@@ -0,0 +1,147 @@
+package deployments_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/git"
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// newRemote returns the URL of a bare repository with a "main" branch of one
+// commit adding a docker-compose.yml file.
+func newRemote(t *testing.T, composeFile string) string {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "remote.git")
+	if _, err := gogit.PlainInit(dir, true); err != nil {
+		t.Fatalf("failed to init bare repository: %v", err)
+	}
+
+	workDir := t.TempDir()
+	work, err := gogit.PlainInitWithOptions(workDir, &gogit.PlainInitOptions{
+		InitOptions: gogit.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("main")},
+		Bare:        false,
+	})
+	if err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+	writeErr := os.WriteFile(filepath.Join(workDir, "docker-compose.yml"), []byte(composeFile), 0o600)
+	if writeErr != nil {
+		t.Fatalf("failed to write file: %v", writeErr)
+	}
+
+	wt, err := work.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	if _, addErr := wt.Add("docker-compose.yml"); addErr != nil {
+		t.Fatalf("failed to add file: %v", addErr)
+	}
+	if _, commitErr := wt.Commit("initial", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	}); commitErr != nil {
+		t.Fatalf("failed to commit: %v", commitErr)
+	}
+
+	if _, remoteErr := work.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{dir}}); remoteErr != nil {
+		t.Fatalf("failed to create remote: %v", remoteErr)
+	}
+	if pushErr := work.Push(&gogit.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{"refs/heads/*:refs/heads/*"},
+	}); pushErr != nil {
+		t.Fatalf("failed to push: %v", pushErr)
+	}
+
+	return "file://" + dir
+}
+
+// waitFinished polls a deployment until it is finished.
+func (e *env) waitFinished(t *testing.T, id uuid.UUID) *deployments.Deployment {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		d := e.getDeployment(t, id)
+		if d.IsFinished() {
+			return d
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("deployment is still %s", d.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServiceExecuteMissingVariable(t *testing.T) {
+	t.Parallel()
+
+	const composeFile = "services:\n  db:\n    image: postgres\n" +
+		"    environment:\n      POSTGRES_PASSWORD: ${DB_PASSWORD:?set the database password}\n"
+
+	tests := []struct {
+		name      string
+		variables map[string]string
+	}{
+		{name: "unset", variables: nil},
+		{name: "empty", variables: map[string]string{"DB_PASSWORD": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			eng := newEngine()
+			e := newEnvWith(t, git.NewClient(git.Config{CacheDir: t.TempDir()}, zap.NewNop()), eng.newReconciler(t))
+
+			stack, err := e.stacks.Create(t.Context(), stacks.StackDraft{
+				Name:        "app",
+				GitURL:      newRemote(t, composeFile),
+				GitBranch:   "main",
+				ComposePath: "docker-compose.yml",
+				Variables:   tt.variables,
+			})
+			if err != nil {
+				t.Fatalf("failed to create stack: %v", err)
+			}
+
+			if startErr := e.svc.Start(t.Context()); startErr != nil {
+				t.Fatalf("Start() error = %v", startErr)
+			}
+			// The test context is already cancelled when cleanups run.
+			t.Cleanup(func() {
+				if stopErr := e.svc.Stop(context.Background()); stopErr != nil {
+					t.Errorf("Stop() error = %v", stopErr)
+				}
+			})
+
+			queued, err := e.svc.Trigger(t.Context(), deployments.DeploymentRequest{StackID: stack.ID})
+			if err != nil {
+				t.Fatalf("Trigger() error = %v", err)
+			}
+
+			d := e.waitFinished(t, queued.ID)
+			if d.Status != deployments.StatusFailed {
+				t.Errorf("deployment status = %s, want %s", d.Status, deployments.StatusFailed)
+			}
+			if !strings.Contains(d.Error, "DB_PASSWORD") || !strings.Contains(d.Error, "set the database password") {
+				t.Errorf("deployment error = %q, want the variable and its message", d.Error)
+			}
+			if requests := eng.Requests(); requests != 0 {
+				t.Errorf("engine requests = %d, want none", requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// AutoRollback exposes autoRollback to the tests.
+func (s *Service) AutoRollback(ctx context.Context, d *Deployment) {
+	s.autoRollback(ctx, d)
+}
+
+// LogWaiters returns the number of deployments whose logs are followed.
+func (s *Service) LogWaiters() int {
+	s.notifier.mu.Lock()
+	defer s.notifier.mu.Unlock()
+
+	return len(s.notifier.waiters)
+}
+
+// Track exposes track to the tests.
+func (s *Service) Track(id uuid.UUID, cancel context.CancelCauseFunc) func() {
+	return s.track(id, cancel)
+}
+
+// CancellationAction returns the action of a cancellation cause.
+func CancellationAction(cause error) (CancelAction, bool) {
+	var c *cancellation
+	if !errors.As(cause, &c) {
+		return "", false
+	}
+
+	return c.action, true
+}
+
+// Queue exposes queue to the tests.
+type Queue = queue
+
+// NewQueue exposes newQueue to the tests.
+func NewQueue() *Queue {
+	return newQueue()
+}
+
+// Push exposes push to the tests.
+func (q *queue) Push(id uuid.UUID) {
+	q.push(id)
+}
+
+// Pop exposes pop to the tests.
+func (q *queue) Pop(ctx context.Context) (uuid.UUID, bool) {
+	return q.pop(ctx)
+}
+
+// StackLocks exposes stackLocks to the tests.
+type StackLocks = stackLocks
+
+// NewStackLocks exposes newStackLocks to the tests.
+func NewStackLocks() *StackLocks {
+	return newStackLocks()
+}
+
+// Acquire exposes acquire to the tests.
+func (l *stackLocks) Acquire(stackID, id uuid.UUID) bool {
+	return l.acquire(stackID, id)
+}
+
+// Release exposes release to the tests.
+func (l *stackLocks) Release(stackID uuid.UUID) (uuid.UUID, bool) {
+	return l.release(stackID)
+}
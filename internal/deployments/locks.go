@@ -0,0 +1,58 @@
+package deployments
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// stackLocks serializes the execution of deployments per stack. Deployments
+// of a busy stack are parked and handed over in order once it is released.
+type stackLocks struct {
+	mu      sync.Mutex
+	busy    map[uuid.UUID]bool
+	waiting map[uuid.UUID][]uuid.UUID
+}
+
+func newStackLocks() *stackLocks {
+	return &stackLocks{
+		mu:      sync.Mutex{},
+		busy:    make(map[uuid.UUID]bool),
+		waiting: make(map[uuid.UUID][]uuid.UUID),
+	}
+}
+
+// acquire locks the stack for deployment id. If the stack is busy, id is
+// parked and false is returned.
+func (l *stackLocks) acquire(stackID, id uuid.UUID) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.busy[stackID] {
+		l.waiting[stackID] = append(l.waiting[stackID], id)
+		return false
+	}
+
+	l.busy[stackID] = true
+	return true
+}
+
+// release returns the next parked deployment of the stack, which keeps the
+// lock, or unlocks the stack if there is none.
+func (l *stackLocks) release(stackID uuid.UUID) (uuid.UUID, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if waiting := l.waiting[stackID]; len(waiting) > 0 {
+		next := waiting[0]
+		if len(waiting) == 1 {
+			delete(l.waiting, stackID)
+		} else {
+			l.waiting[stackID] = waiting[1:]
+		}
+		return next, true
+	}
+
+	delete(l.busy, stackID)
+	return uuid.Nil, false
+}
@@ -0,0 +1,219 @@
+package deployments_test
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/git"
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestStackLocks(t *testing.T) {
+	t.Parallel()
+
+	l := deployments.NewStackLocks()
+	stack, other := uuid.New(), uuid.New()
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+
+	if !l.Acquire(stack, first) {
+		t.Fatal("Acquire() of a free stack = false, want true")
+	}
+	if l.Acquire(stack, second) || l.Acquire(stack, third) {
+		t.Fatal("Acquire() of a busy stack = true, want false")
+	}
+	if !l.Acquire(other, uuid.New()) {
+		t.Fatal("Acquire() of another stack = false, want true")
+	}
+
+	// Parked deployments keep the lock in the order they were parked.
+	for _, want := range []uuid.UUID{second, third} {
+		next, ok := l.Release(stack)
+		if !ok || next != want {
+			t.Fatalf("Release() = %s, %t, want %s, true", next, ok, want)
+		}
+	}
+	if next, ok := l.Release(stack); ok {
+		t.Fatalf("Release() of an idle stack = %s, true, want false", next)
+	}
+
+	if !l.Acquire(stack, uuid.New()) {
+		t.Error("Acquire() of a released stack = false, want true")
+	}
+}
+
+func TestStackLocksConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const (
+		stackCount = 3
+		perStack   = 50
+	)
+
+	l := deployments.NewStackLocks()
+
+	type run struct {
+		holders atomic.Int32
+		mu      sync.Mutex
+		order   []uuid.UUID
+	}
+	runs := make(map[uuid.UUID]*run, stackCount)
+	for range stackCount {
+		runs[uuid.New()] = &run{}
+	}
+
+	var wg sync.WaitGroup
+	for stackID, r := range runs {
+		for range perStack {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				// Like a worker: run the deployment if the stack is free,
+				// then the ones parked in the meantime.
+				id := uuid.New()
+				if !l.Acquire(stackID, id) {
+					return
+				}
+				for {
+					if holders := r.holders.Add(1); holders != 1 {
+						t.Errorf("stack %s held by %d deployments, want 1", stackID, holders)
+					}
+					r.mu.Lock()
+					r.order = append(r.order, id)
+					r.mu.Unlock()
+					r.holders.Add(-1)
+
+					next, ok := l.Release(stackID)
+					if !ok {
+						return
+					}
+					id = next
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	for stackID, r := range runs {
+		if len(r.order) != perStack {
+			t.Errorf("stack %s ran %d deployments, want %d", stackID, len(r.order), perStack)
+		}
+		unique := slices.Compact(slices.SortedFunc(slices.Values(r.order), func(a, b uuid.UUID) int {
+			return slices.Compare(a[:], b[:])
+		}))
+		if len(unique) != len(r.order) {
+			t.Errorf("stack %s ran %d distinct deployments, want %d", stackID, len(unique), len(r.order))
+		}
+	}
+}
+
+// newPolicyEnv returns an env able to trigger deployments of a stack with
+// the policy.
+func newPolicyEnv(t *testing.T, policy stacks.DeployPolicy) (*env, *stacks.Stack) {
+	t.Helper()
+
+	e := newEnvWith(t, git.NewClient(git.Config{CacheDir: t.TempDir()}, zap.NewNop()), nil)
+
+	stack, err := e.stacks.Create(t.Context(), stacks.StackDraft{
+		Name:         "app",
+		GitURL:       newRemote(t, "services:\n  web:\n    image: nginx\n"),
+		GitBranch:    "main",
+		ComposePath:  "docker-compose.yml",
+		DeployPolicy: policy,
+	})
+	if err != nil {
+		t.Fatalf("failed to create stack: %v", err)
+	}
+
+	return e, stack
+}
+
+func TestServiceTriggerPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		policy  stacks.DeployPolicy
+		active  deployments.Status
+		wantErr error
+	}{
+		{name: "queue while pending", policy: stacks.DeployPolicyQueue, active: deployments.StatusPending},
+		{name: "queue while running", policy: stacks.DeployPolicyQueue, active: deployments.StatusRunning},
+		{
+			name:    "reject while pending",
+			policy:  stacks.DeployPolicyReject,
+			active:  deployments.StatusPending,
+			wantErr: deployments.ErrConflict,
+		},
+		{
+			name:    "reject while running",
+			policy:  stacks.DeployPolicyReject,
+			active:  deployments.StatusRunning,
+			wantErr: deployments.ErrConflict,
+		},
+		{name: "reject when finished", policy: stacks.DeployPolicyReject, active: deployments.StatusSuccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e, stack := newPolicyEnv(t, tt.policy)
+			active := e.createDeployment(t, stack.ID, tt.active)
+
+			d, err := e.svc.Trigger(t.Context(), deployments.DeploymentRequest{StackID: stack.ID})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Trigger() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if d != nil {
+					t.Errorf("Trigger() = %v, want nil", d)
+				}
+				return
+			}
+			if d.Status != deployments.StatusPending || d.ID == active.ID {
+				t.Errorf("Trigger() = %s %s, want a new pending deployment", d.ID, d.Status)
+			}
+		})
+	}
+}
+
+func TestServiceTriggerRejectConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const callers = 8
+
+	e, stack := newPolicyEnv(t, stacks.DeployPolicyReject)
+
+	errs := make(chan error, callers)
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := e.svc.Trigger(t.Context(), deployments.DeploymentRequest{StackID: stack.ID})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	queued := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			queued++
+		case !errors.Is(err, deployments.ErrConflict):
+			t.Errorf("Trigger() error = %v, want nil or %v", err, deployments.ErrConflict)
+		}
+	}
+	if queued != 1 {
+		t.Errorf("queued deployments = %d, want 1", queued)
+	}
+}
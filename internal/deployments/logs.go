@@ -0,0 +1,108 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// logNotifier wakes up the followers of deployment logs.
+type logNotifier struct {
+	mu      sync.Mutex
+	waiters map[uuid.UUID]*logWaiter
+}
+
+// logWaiter is the channel shared by the followers of a deployment log until
+// the next notification.
+type logWaiter struct {
+	ch   chan struct{}
+	refs int
+}
+
+func newLogNotifier() *logNotifier {
+	return &logNotifier{
+		mu:      sync.Mutex{},
+		waiters: make(map[uuid.UUID]*logWaiter),
+	}
+}
+
+// wait returns a channel that is closed on the next notification for id and
+// a function releasing it. The channel is forgotten once notified or released
+// by all of its followers.
+func (n *logNotifier) wait(id uuid.UUID) (<-chan struct{}, func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	w, ok := n.waiters[id]
+	if !ok {
+		w = &logWaiter{ch: make(chan struct{}), refs: 0}
+		n.waiters[id] = w
+	}
+	w.refs++
+
+	var once sync.Once
+	return w.ch, func() {
+		once.Do(func() {
+			n.mu.Lock()
+			defer n.mu.Unlock()
+
+			w.refs--
+			if w.refs == 0 && n.waiters[id] == w {
+				delete(n.waiters, id)
+			}
+		})
+	}
+}
+
+func (n *logNotifier) notify(id uuid.UUID) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if w, ok := n.waiters[id]; ok {
+		close(w.ch)
+		delete(n.waiters, id)
+	}
+}
+
+// ListLogs retrieves up to limit lines of the log of a deployment following
+// the line with sequence number after.
+func (s *Service) ListLogs(ctx context.Context, id uuid.UUID, after uint64, limit int) ([]LogEntry, error) {
+	if _, err := s.deployments.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.logs.List(ctx, id, after, limit)
+	if err != nil {
+		s.logger.Error("failed to list deployment logs", zap.String("id", id.String()), zap.Error(err))
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// WatchLogs returns a channel that is closed when a line is appended to the
+// log of a deployment, and a function to call once the channel is no longer
+// waited on.
+func (s *Service) WatchLogs(id uuid.UUID) (<-chan struct{}, func()) {
+	return s.notifier.wait(id)
+}
+
+// record appends a line to the log of a deployment. Failures are logged but
+// do not affect the deployment.
+func (s *Service) record(ctx context.Context, id uuid.UUID, level LogLevel, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+
+	if _, err := s.logs.Append(ctx, id, level, message); err != nil {
+		s.logger.Error("failed to append deployment log",
+			zap.String("id", id.String()),
+			zap.String("message", message),
+			zap.Error(err),
+		)
+		return
+	}
+
+	s.notifier.notify(id)
+}
@@ -0,0 +1,190 @@
+package deployments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/apiarycd/apiarycd/pkg/badgerfx"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/google/uuid"
+)
+
+const (
+	prefixLog = prefix + "log:"
+)
+
+// LogRepository stores deployment logs as one key per line, so appending a
+// line never rewrites the deployment.
+type LogRepository struct {
+	db *badger.DB
+
+	// Badger does not detect concurrent writes of the same new key, so
+	// appends are serialized to keep sequence numbers unique.
+	appendMu sync.Mutex
+}
+
+func NewLogRepository(db *badger.DB) *LogRepository {
+	return &LogRepository{
+		db: db,
+
+		appendMu: sync.Mutex{},
+	}
+}
+
+// Append adds a line to the log of a deployment.
+func (r *LogRepository) Append(
+	_ context.Context,
+	deploymentID uuid.UUID,
+	level LogLevel,
+	message string,
+) (LogEntry, error) {
+	model := &logEntryModel{
+		Seq:     0,
+		Time:    time.Now(),
+		Level:   level,
+		Message: message,
+	}
+
+	r.appendMu.Lock()
+	defer r.appendMu.Unlock()
+
+	err := r.db.Update(func(txn *badger.Txn) error {
+		last, lastErr := r.lastSeq(txn, deploymentID)
+		if lastErr != nil {
+			return lastErr
+		}
+
+		model.Seq = last + 1
+
+		data, mErr := json.Marshal(model)
+		if mErr != nil {
+			return fmt.Errorf("failed to marshal log entry: %w", mErr)
+		}
+
+		if setErr := txn.Set(r.getKey(deploymentID, model.Seq), data); setErr != nil {
+			return fmt.Errorf("failed to set log entry: %w", setErr)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return LogEntry{}, fmt.Errorf("failed to append log entry: %w", err)
+	}
+
+	return newLogEntry(model), nil
+}
+
+// List retrieves up to limit lines of the log of a deployment following the
+// line with sequence number after.
+func (r *LogRepository) List(_ context.Context, deploymentID uuid.UUID, after uint64, limit int) ([]LogEntry, error) {
+	entries := make([]LogEntry, 0, limit)
+
+	err := r.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = limit
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefix := r.getPrefix(deploymentID)
+		for it.Seek(r.getKey(deploymentID, after+1)); it.ValidForPrefix(prefix) && len(entries) < limit; it.Next() {
+			if err := it.Item().Value(func(val []byte) error {
+				var model logEntryModel
+				if err := json.Unmarshal(val, &model); err != nil {
+					return fmt.Errorf("failed to unmarshal log entry: %w", err)
+				}
+
+				entries = append(entries, newLogEntry(&model))
+				return nil
+			}); err != nil {
+				return err //nolint:wrapcheck // already wrapped
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Delete deletes the log of a deployment. Lines are deleted in transactions
+// of at most batchSize lines, so logs of any length can be deleted.
+func (r *LogRepository) Delete(_ context.Context, deploymentID uuid.UUID, batchSize int) error {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+
+	prefix := r.getPrefix(deploymentID)
+	for {
+		deleted := 0
+
+		err := r.db.Update(func(txn *badger.Txn) error {
+			keys := make([][]byte, 0, batchSize)
+
+			it := txn.NewIterator(opts)
+			for it.Seek(prefix); it.ValidForPrefix(prefix) && len(keys) < batchSize; it.Next() {
+				keys = append(keys, it.Item().KeyCopy(nil))
+			}
+			it.Close()
+
+			for _, key := range keys {
+				if err := txn.Delete(key); err != nil {
+					return fmt.Errorf("failed to delete log entry: %w", err)
+				}
+			}
+
+			deleted = len(keys)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete log: %w", err)
+		}
+
+		if deleted < batchSize {
+			return nil
+		}
+	}
+}
+
+// lastSeq returns the sequence number of the last line of a deployment log,
+// or 0 if it is empty.
+func (r *LogRepository) lastSeq(txn *badger.Txn, deploymentID uuid.UUID) (uint64, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.Reverse = true
+	opts.PrefetchValues = false
+
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	prefix := r.getPrefix(deploymentID)
+	it.Seek(append(prefix, badgerfx.SeekEnd))
+	if !it.ValidForPrefix(prefix) {
+		return 0, nil
+	}
+
+	key := it.Item().Key()
+	seq, err := strconv.ParseUint(string(key[len(prefix):]), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse log key %q: %w", key, err)
+	}
+
+	return seq, nil
+}
+
+// getPrefix generates the prefix for the log of a deployment.
+func (r *LogRepository) getPrefix(deploymentID uuid.UUID) []byte {
+	return []byte(prefixLog + deploymentID.String() + ":")
+}
+
+// getKey generates the key for a line of a deployment log
+// `deployment:log:<deployment_id>:<seq>`.
+func (r *LogRepository) getKey(deploymentID uuid.UUID, seq uint64) []byte {
+	return fmt.Appendf(r.getPrefix(deploymentID), "%020d", seq)
+}
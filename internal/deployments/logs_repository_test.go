@@ -0,0 +1,126 @@
+package deployments_test
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/google/uuid"
+)
+
+func TestLogRepositoryList(t *testing.T) {
+	t.Parallel()
+
+	e := newEnv(t)
+	id := uuid.New()
+	other := uuid.New()
+
+	// More than 9 lines, so that sequence numbers of different lengths sort.
+	e.appendLogs(t, id, 12)
+	e.appendLogs(t, other, 3)
+
+	tests := []struct {
+		name  string
+		after uint64
+		limit int
+		want  []uint64
+	}{
+		{name: "first page", after: 0, limit: 5, want: []uint64{1, 2, 3, 4, 5}},
+		{name: "next page", after: 5, limit: 5, want: []uint64{6, 7, 8, 9, 10}},
+		{name: "last page", after: 10, limit: 5, want: []uint64{11, 12}},
+		{name: "whole log", after: 0, limit: 100, want: []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}},
+		{name: "after the end", after: 12, limit: 5, want: []uint64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			entries, err := e.logs.List(t.Context(), id, tt.after, tt.limit)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+
+			got := make([]uint64, 0, len(entries))
+			for _, entry := range entries {
+				got = append(got, entry.Seq)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("List(%d, %d) = %v, want %v", tt.after, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogRepositoryAppend(t *testing.T) {
+	t.Parallel()
+
+	e := newEnv(t)
+	id := uuid.New()
+
+	first, err := e.logs.Append(t.Context(), id, deployments.LogLevelWarn, "first")
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if first.Seq != 1 || first.Level != deployments.LogLevelWarn || first.Message != "first" || first.Time.IsZero() {
+		t.Errorf("Append() = %+v, want the first warning line", first)
+	}
+
+	// Concurrent appends get distinct, consecutive sequence numbers.
+	const writers = 20
+	var wg sync.WaitGroup
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, appendErr := e.logs.Append(t.Context(), id, deployments.LogLevelInfo, "line"); appendErr != nil {
+				t.Errorf("Append() error = %v", appendErr)
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, err := e.logs.List(t.Context(), id, 0, writers+10)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(entries) != writers+1 {
+		t.Fatalf("List() = %d lines, want %d", len(entries), writers+1)
+	}
+	for i, entry := range entries {
+		if entry.Seq != uint64(i+1) {
+			t.Fatalf("line %d has sequence number %d", i, entry.Seq)
+		}
+	}
+}
+
+func TestServiceListLogs(t *testing.T) {
+	t.Parallel()
+
+	e := newEnv(t)
+	stack := e.createStack(t, "app")
+	d := e.createDeployment(t, stack.ID, deployments.StatusSuccess)
+	e.appendLogs(t, d.ID, 2)
+
+	tests := []struct {
+		name    string
+		id      uuid.UUID
+		want    int
+		wantErr error
+	}{
+		{name: "deployment", id: d.ID, want: 2},
+		{name: "unknown deployment", id: uuid.New(), wantErr: deployments.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		entries, err := e.svc.ListLogs(t.Context(), tt.id, 0, 10)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%s: ListLogs() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if len(entries) != tt.want {
+			t.Errorf("%s: ListLogs() = %d lines, want %d", tt.name, len(entries), tt.want)
+		}
+	}
+}
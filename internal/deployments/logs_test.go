@@ -0,0 +1,69 @@
+package deployments_test
+
+import (
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+)
+
+func closed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestServiceWatchLogs(t *testing.T) {
+	t.Parallel()
+
+	e := newEnv(t)
+	stack := e.createStack(t, "app")
+	d := e.createDeployment(t, stack.ID, deployments.StatusPending)
+
+	// Followers share a waiter until all of them release it.
+	first, unwatchFirst := e.svc.WatchLogs(d.ID)
+	second, unwatchSecond := e.svc.WatchLogs(d.ID)
+	if first != second {
+		t.Error("followers of a deployment wait on different channels")
+	}
+	unwatchFirst()
+	unwatchFirst()
+	if got := e.svc.LogWaiters(); got != 1 {
+		t.Fatalf("LogWaiters() = %d after one follower left, want 1", got)
+	}
+	unwatchSecond()
+	if got := e.svc.LogWaiters(); got != 0 {
+		t.Fatalf("LogWaiters() = %d after all followers left, want 0", got)
+	}
+	if closed(first) {
+		t.Fatal("channel closed without a log line")
+	}
+
+	// A line wakes up the followers and forgets the waiter.
+	notified, unwatchNotified := e.svc.WatchLogs(d.ID)
+	if _, err := e.svc.Cancel(t.Context(), d.ID, deployments.CancelRequest{
+		CancelledBy: "alice",
+		Action:      deployments.CancelActionNone,
+	}); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if !closed(notified) {
+		t.Fatal("channel not closed by a log line")
+	}
+	if got := e.svc.LogWaiters(); got != 0 {
+		t.Fatalf("LogWaiters() = %d after a log line, want 0", got)
+	}
+
+	// Releasing a notified channel leaves the next waiter alone.
+	next, unwatchNext := e.svc.WatchLogs(d.ID)
+	unwatchNotified()
+	if got := e.svc.LogWaiters(); got != 1 {
+		t.Fatalf("LogWaiters() = %d, want 1", got)
+	}
+	unwatchNext()
+	if got := e.svc.LogWaiters(); got != 0 || closed(next) {
+		t.Fatalf("LogWaiters() = %d, channel closed = %t, want 0 and open", got, closed(next))
+	}
+}
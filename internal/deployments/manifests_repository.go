@@ -0,0 +1,98 @@
+package deployments
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/apiarycd/apiarycd/internal/compose"
+	"github.com/dgraph-io/badger/v4"
+)
+
+const (
+	prefixManifest = prefix + "manifest:"
+
+	digestAlgorithm = "sha256:"
+)
+
+// ManifestRepository stores rendered manifests addressed by the digest of
+// their content, so deployments of the same manifest share one snapshot.
+type ManifestRepository struct {
+	db *badger.DB
+}
+
+func NewManifestRepository(db *badger.DB) *ManifestRepository {
+	return &ManifestRepository{
+		db: db,
+	}
+}
+
+// Put stores a snapshot of stack unless an identical one exists and returns
+// its digest.
+func (r *ManifestRepository) Put(_ context.Context, stack *compose.Stack) (string, error) {
+	data, err := json.Marshal(newManifestModel(stack))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	digest := digestAlgorithm + hex.EncodeToString(sum[:])
+
+	err = r.db.Update(func(txn *badger.Txn) error {
+		key := r.getKey(digest)
+
+		_, getErr := txn.Get(key)
+		if getErr == nil {
+			return nil
+		}
+		if !errors.Is(getErr, badger.ErrKeyNotFound) {
+			return fmt.Errorf("failed to get manifest: %w", getErr)
+		}
+
+		if setErr := txn.Set(key, data); setErr != nil {
+			return fmt.Errorf("failed to set manifest: %w", setErr)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to store manifest: %w", err)
+	}
+
+	return digest, nil
+}
+
+// Get retrieves a snapshot by its digest.
+func (r *ManifestRepository) Get(_ context.Context, digest string) (*Manifest, error) {
+	var model manifestModel
+
+	err := r.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(r.getKey(digest))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("%w: manifest %s", ErrNotFound, digest)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get manifest: %w", err)
+		}
+
+		if valErr := item.Value(func(val []byte) error { return json.Unmarshal(val, &model) }); valErr != nil {
+			return fmt.Errorf("failed to unmarshal manifest: %w", valErr)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err //nolint:wrapcheck // already wrapped
+	}
+
+	return newManifest(digest, &model), nil
+}
+
+// getKey generates the key for storing a manifest
+// `deployment:manifest:sha256:<hex>`.
+func (r *ManifestRepository) getKey(digest string) []byte {
+	return []byte(prefixManifest + digest)
+}
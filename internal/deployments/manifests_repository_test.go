@@ -0,0 +1,162 @@
+package deployments_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/compose"
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/moby/moby/api/types/swarm"
+)
+
+func newManifestStack(namespace string, images ...string) *compose.Stack {
+	services := make([]swarm.ServiceSpec, 0, len(images))
+	for i, image := range images {
+		services = append(services, swarm.ServiceSpec{
+			Annotations: swarm.Annotations{Name: fmt.Sprintf("%s_svc%d", namespace, i)},
+			TaskTemplate: swarm.TaskSpec{
+				ContainerSpec: &swarm.ContainerSpec{Image: image},
+			},
+		})
+	}
+
+	return &compose.Stack{
+		Namespace: namespace,
+		Services:  services,
+		Networks: []compose.Network{
+			{Name: namespace + "_default", Driver: "overlay", Labels: map[string]string{compose.LabelNamespace: namespace}},
+		},
+		Configs: nil,
+		Secrets: nil,
+	}
+}
+
+func TestManifestRepositoryPut(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		first    *compose.Stack
+		second   *compose.Stack
+		wantSame bool
+	}{
+		{
+			name:     "identical stacks",
+			first:    newManifestStack("app", "nginx:1"),
+			second:   newManifestStack("app", "nginx:1"),
+			wantSame: true,
+		},
+		{
+			name:     "other image",
+			first:    newManifestStack("app", "nginx:1"),
+			second:   newManifestStack("app", "nginx:2"),
+			wantSame: false,
+		},
+		{
+			name:     "other namespace",
+			first:    newManifestStack("app", "nginx:1"),
+			second:   newManifestStack("web", "nginx:1"),
+			wantSame: false,
+		},
+		{
+			name:     "more services",
+			first:    newManifestStack("app", "nginx:1"),
+			second:   newManifestStack("app", "nginx:1", "redis:7"),
+			wantSame: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := newEnv(t)
+
+			first, err := e.manifests.Put(t.Context(), tt.first)
+			if err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			second, err := e.manifests.Put(t.Context(), tt.second)
+			if err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+
+			if !strings.HasPrefix(first, "sha256:") {
+				t.Errorf("Put() = %q, want a sha256 digest", first)
+			}
+			if (first == second) != tt.wantSame {
+				t.Errorf("Put() digests %s and %s, want same = %t", first, second, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestManifestRepositoryGet(t *testing.T) {
+	t.Parallel()
+
+	e := newEnv(t)
+	stack := newManifestStack("app", "nginx:1", "redis:7")
+
+	digest, err := e.manifests.Put(t.Context(), stack)
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	manifest, err := e.manifests.Get(t.Context(), digest)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	if manifest.Digest != digest || manifest.Stack.Namespace != "app" {
+		t.Errorf("Get() = %s of %q, want %s of %q", manifest.Digest, manifest.Stack.Namespace, digest, "app")
+	}
+	if len(manifest.Stack.Services) != 2 || manifest.Stack.Services[1].TaskTemplate.ContainerSpec.Image != "redis:7" {
+		t.Errorf("Get() services = %+v, want the stored services", manifest.Stack.Services)
+	}
+	if len(manifest.Stack.Networks) != 1 || manifest.Stack.Networks[0].Labels[compose.LabelNamespace] != "app" {
+		t.Errorf("Get() networks = %+v, want the stored network", manifest.Stack.Networks)
+	}
+
+	if _, getErr := e.manifests.Get(t.Context(), "sha256:unknown"); !errors.Is(getErr, deployments.ErrNotFound) {
+		t.Errorf("Get() of an unknown digest error = %v, want %v", getErr, deployments.ErrNotFound)
+	}
+}
+
+func TestServiceManifests(t *testing.T) {
+	t.Parallel()
+
+	e := newEnv(t)
+	stack := e.createStack(t, "app")
+
+	digest, err := e.manifests.Put(t.Context(), newManifestStack("app", "nginx:1"))
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	rendered := e.createDeployment(t, stack.ID, deployments.StatusSuccess)
+	e.updateDeployment(t, rendered.ID, func(d *deployments.Deployment) { d.Manifest = digest })
+	unrendered := e.createDeployment(t, stack.ID, deployments.StatusFailed)
+
+	if _, getErr := e.svc.GetManifest(t.Context(), unrendered.ID); !errors.Is(getErr, deployments.ErrNotFound) {
+		t.Errorf("GetManifest() without a snapshot error = %v, want %v", getErr, deployments.ErrNotFound)
+	}
+
+	manifest, err := e.svc.GetManifest(t.Context(), rendered.ID)
+	if err != nil {
+		t.Fatalf("GetManifest() error = %v", err)
+	}
+	if manifest.Digest != digest {
+		t.Errorf("GetManifest() digest = %s, want %s", manifest.Digest, digest)
+	}
+
+	// Rollbacks reapply the snapshot rather than rendering the commit again.
+	rollback, err := e.svc.Rollback(t.Context(), stack.ID, &rendered.ID)
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if rollback.Manifest != digest {
+		t.Errorf("Rollback() manifest = %q, want %q", rollback.Manifest, digest)
+	}
+}
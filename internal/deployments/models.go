@@ -3,8 +3,10 @@ package deployments
 import (
 	"time"
 
+	"github.com/apiarycd/apiarycd/internal/compose"
 	"github.com/apiarycd/apiarycd/internal/storage"
 	"github.com/google/uuid"
+	"github.com/moby/moby/api/types/swarm"
 )
 
 // deploymentModel represents a stack deployment instance.
@@ -20,7 +22,8 @@ type deploymentModel struct {
 	Message string `json:"message"` // Git commit message
 
 	// Deployment Configuration
-	Variables map[string]string `json:"variables"` // Deployment-specific variables
+	Variables map[string]string `json:"variables"`          // Deployment-specific variables
+	Manifest  string            `json:"manifest,omitempty"` // Digest of the rendered manifest snapshot
 
 	// Status
 	Status      Status     `json:"status"`       // pending, running, success, failed, cancelled
@@ -28,11 +31,14 @@ type deploymentModel struct {
 	CompletedAt *time.Time `json:"completed_at"` // When deployment completed/failed
 	Error       string     `json:"error"`        // Error message if failed
 
-	// Logs and Metrics
-	Logs []string `json:"logs"` // Deployment logs
+	// Cancellation
+	CancelledBy string     `json:"cancelled_by,omitempty"` // Who cancelled the deployment
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"` // When the deployment was cancelled
 
 	// Rollback Information
-	PreviousDeployment *uuid.UUID `json:"previous_deployment"` // Previous deployment ID for rollback
+	PreviousDeployment *uuid.UUID `json:"previous_deployment"`   // Previous deployment ID for rollback
+	Origin             *uuid.UUID `json:"origin,omitempty"`      // Deployment redeployed by a rollback
+	RollbackOf         *uuid.UUID `json:"rollback_of,omitempty"` // Failed deployment reverted by an automatic rollback
 }
 
 func newDeploymentModel(draft *DeploymentDraft) *deploymentModel {
@@ -52,24 +58,19 @@ func newDeploymentModel(draft *DeploymentDraft) *deploymentModel {
 		GitRef:             draft.GitRef,
 		Message:            draft.Message,
 		Variables:          draft.Variables,
+		Manifest:           draft.Manifest,
 		Status:             draft.Status,
 		StartedAt:          draft.StartedAt,
 		CompletedAt:        draft.CompletedAt,
 		Error:              draft.Error,
-		Logs:               draft.Logs,
+		CancelledBy:        draft.CancelledBy,
+		CancelledAt:        draft.CancelledAt,
 		PreviousDeployment: draft.PreviousDeployment,
+		Origin:             draft.Origin,
+		RollbackOf:         draft.RollbackOf,
 	}
 }
 
-func newDeploymentUpdateModel(source *deploymentModel, draft *DeploymentDraft) *deploymentModel {
-	updated := newDeploymentModel(draft)
-	updated.ID = source.ID
-	updated.CreatedAt = source.CreatedAt
-	updated.UpdatedAt = time.Now()
-
-	return updated
-}
-
 func newDeployment(model *deploymentModel) *Deployment {
 	if model == nil {
 		return nil
@@ -82,15 +83,102 @@ func newDeployment(model *deploymentModel) *Deployment {
 			GitRef:             model.GitRef,
 			Message:            model.Message,
 			Variables:          model.Variables,
+			Manifest:           model.Manifest,
 			Status:             model.Status,
 			StartedAt:          model.StartedAt,
 			CompletedAt:        model.CompletedAt,
 			Error:              model.Error,
-			Logs:               model.Logs,
+			CancelledBy:        model.CancelledBy,
+			CancelledAt:        model.CancelledAt,
 			PreviousDeployment: model.PreviousDeployment,
+			Origin:             model.Origin,
+			RollbackOf:         model.RollbackOf,
 		},
 		ID:        model.ID,
 		CreatedAt: model.CreatedAt,
 		UpdatedAt: model.UpdatedAt,
 	}
 }
+
+// logEntryModel represents a line of a deployment log.
+type logEntryModel struct {
+	Seq     uint64    `json:"seq"`
+	Time    time.Time `json:"time"`
+	Level   LogLevel  `json:"level"`
+	Message string    `json:"message"`
+}
+
+func newLogEntry(model *logEntryModel) LogEntry {
+	return LogEntry{
+		Seq:     model.Seq,
+		Time:    model.Time,
+		Level:   model.Level,
+		Message: model.Message,
+	}
+}
+
+// manifestModel represents a rendered manifest snapshot. Its JSON encoding is
+// the content the snapshot is addressed by.
+type manifestModel struct {
+	Namespace string              `json:"namespace"`
+	Services  []swarm.ServiceSpec `json:"services"`
+	Networks  []networkModel      `json:"networks"`
+	Configs   []swarm.ConfigSpec  `json:"configs"`
+	Secrets   []swarm.SecretSpec  `json:"secrets"`
+}
+
+type networkModel struct {
+	Name       string            `json:"name"`
+	Driver     string            `json:"driver"`
+	DriverOpts map[string]string `json:"driver_opts"`
+	Labels     map[string]string `json:"labels"`
+	Attachable bool              `json:"attachable"`
+	Internal   bool              `json:"internal"`
+}
+
+func newManifestModel(stack *compose.Stack) *manifestModel {
+	networks := make([]networkModel, 0, len(stack.Networks))
+	for _, n := range stack.Networks {
+		networks = append(networks, networkModel{
+			Name:       n.Name,
+			Driver:     n.Driver,
+			DriverOpts: n.DriverOpts,
+			Labels:     n.Labels,
+			Attachable: n.Attachable,
+			Internal:   n.Internal,
+		})
+	}
+
+	return &manifestModel{
+		Namespace: stack.Namespace,
+		Services:  stack.Services,
+		Networks:  networks,
+		Configs:   stack.Configs,
+		Secrets:   stack.Secrets,
+	}
+}
+
+func newManifest(digest string, model *manifestModel) *Manifest {
+	networks := make([]compose.Network, 0, len(model.Networks))
+	for _, n := range model.Networks {
+		networks = append(networks, compose.Network{
+			Name:       n.Name,
+			Driver:     n.Driver,
+			DriverOpts: n.DriverOpts,
+			Labels:     n.Labels,
+			Attachable: n.Attachable,
+			Internal:   n.Internal,
+		})
+	}
+
+	return &Manifest{
+		Digest: digest,
+		Stack: &compose.Stack{
+			Namespace: model.Namespace,
+			Services:  model.Services,
+			Networks:  networks,
+			Configs:   model.Configs,
+			Secrets:   model.Secrets,
+		},
+	}
+}
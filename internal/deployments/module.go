@@ -10,6 +10,14 @@ func Module() fx.Option {
 		"deployments",
 		logger.WithNamedLogger("deployments"),
 		fx.Provide(NewRepository, fx.Private),
+		fx.Provide(NewLogRepository, fx.Private),
+		fx.Provide(NewManifestRepository, fx.Private),
 		fx.Provide(NewService),
+		fx.Invoke(func(lc fx.Lifecycle, s *Service) {
+			lc.Append(fx.Hook{
+				OnStart: s.Start,
+				OnStop:  s.Stop,
+			})
+		}),
 	)
 }
@@ -0,0 +1,245 @@
+package deployments
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apiarycd/apiarycd/pkg/badgerfx"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/google/uuid"
+)
+
+// cursor is the position of a deployment in the time-ordered indexes.
+type cursor struct {
+	unixNano int64
+	id       uuid.UUID
+}
+
+func newCursor(d *Deployment) string {
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%d:%s", d.CreatedAt.UnixNano(), d.ID))
+}
+
+func parseCursor(s string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor", ErrNotAllowed)
+	}
+
+	ts, id, ok := strings.Cut(string(data), ":")
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid cursor", ErrNotAllowed)
+	}
+
+	unixNano, tsErr := strconv.ParseInt(ts, 10, 64)
+	parsed, idErr := uuid.Parse(id)
+	if tsErr != nil || idErr != nil {
+		return nil, fmt.Errorf("%w: invalid cursor", ErrNotAllowed)
+	}
+
+	return &cursor{unixNano: unixNano, id: parsed}, nil
+}
+
+// Find retrieves a page of the deployments matching filter ordered by creation
+// time. Deployments of a stack are read from the stack index, all others from
+// the time index.
+func (r *Repository) Find(_ context.Context, filter Filter, req PageRequest) (*Page, error) {
+	after, err := r.pageStart(req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := []byte(prefixByTime)
+	keyOf := r.getTimeKey
+	if filter.StackID != nil {
+		prefix = r.getStackPrefix(*filter.StackID)
+		keyOf = func(unixNano int64, _ uuid.UUID) []byte {
+			return fmt.Appendf(r.getStackPrefix(*filter.StackID), "%020d", unixNano)
+		}
+	}
+
+	reverse := req.Order != SortOrderAsc
+
+	// One more item than requested tells whether there is a next page.
+	items := make([]Deployment, 0, req.Limit+1)
+	err = r.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = reverse
+		opts.PrefetchSize = req.Limit + 1
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		var skip []byte
+		seek := r.seekKey(prefix, keyOf, filter, reverse)
+		if after != nil {
+			skip = keyOf(after.unixNano, after.id)
+			seek = skip
+		}
+
+		for it.Seek(seek); it.ValidForPrefix(prefix) && len(items) <= req.Limit; it.Next() {
+			if skip != nil && bytes.Equal(it.Item().Key(), skip) {
+				continue
+			}
+
+			d, done, itemErr := r.pageItem(txn, it.Item(), filter, reverse)
+			if itemErr != nil {
+				return itemErr
+			}
+			if done {
+				break
+			}
+			if d != nil {
+				items = append(items, *d)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find deployments: %w", err)
+	}
+
+	page := &Page{Items: items, Next: ""}
+	if len(items) > req.Limit {
+		page.Items = items[:req.Limit]
+		page.Next = newCursor(&page.Items[req.Limit-1])
+	}
+
+	return page, nil
+}
+
+func (r *Repository) pageStart(s string) (*cursor, error) {
+	if s == "" {
+		return nil, nil //nolint:nilnil // first page
+	}
+
+	return parseCursor(s)
+}
+
+// seekKey returns the key to start iterating at for the time range of filter.
+func (r *Repository) seekKey(
+	prefix []byte,
+	keyOf func(int64, uuid.UUID) []byte,
+	filter Filter,
+	reverse bool,
+) []byte {
+	switch {
+	case reverse && filter.Until != nil:
+		return keyOf(filter.Until.UnixNano(), uuid.Nil)
+	case reverse:
+		return append(slices.Clone(prefix), badgerfx.SeekEnd)
+	case filter.Since != nil:
+		return keyOf(filter.Since.UnixNano(), uuid.Nil)
+	default:
+		return prefix
+	}
+}
+
+// pageItem loads the deployment of an index entry. It returns nil if the
+// deployment does not match filter, and done once the iteration has left the
+// time range.
+func (r *Repository) pageItem(
+	txn *badger.Txn,
+	item *badger.Item,
+	filter Filter,
+	reverse bool,
+) (*Deployment, bool, error) {
+	var id uuid.UUID
+	if err := item.Value(func(val []byte) error { return json.Unmarshal(val, &id) }); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal deployment ID: %w", err)
+	}
+
+	model, err := r.getByID(txn, id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	d := newDeployment(model)
+	if outOfRange(d.CreatedAt, filter, reverse) {
+		return nil, true, nil
+	}
+	if !inRange(d.CreatedAt, filter) {
+		return nil, false, nil
+	}
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, d.Status) {
+		return nil, false, nil
+	}
+
+	return d, false, nil
+}
+
+// outOfRange reports whether t is past the end of the time range of filter in
+// the iteration order.
+func outOfRange(t time.Time, filter Filter, reverse bool) bool {
+	if reverse {
+		return filter.Since != nil && t.Before(*filter.Since)
+	}
+
+	return filter.Until != nil && !t.Before(*filter.Until)
+}
+
+func inRange(t time.Time, filter Filter) bool {
+	return (filter.Since == nil || !t.Before(*filter.Since)) && (filter.Until == nil || t.Before(*filter.Until))
+}
+
+// Reindex adds the deployments stored before the time index was introduced
+// to it.
+func (r *Repository) Reindex(_ context.Context) (int, error) {
+	var models []deploymentModel
+
+	err := r.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 10
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefix := []byte(prefixByID)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var model deploymentModel
+			if err := it.Item().Value(func(val []byte) error { return json.Unmarshal(val, &model) }); err != nil {
+				return fmt.Errorf("failed to unmarshal deployment: %w", err)
+			}
+
+			_, getErr := txn.Get(r.getTimeKey(model.CreatedAt.UnixNano(), model.ID))
+			if errors.Is(getErr, badger.ErrKeyNotFound) {
+				models = append(models, model)
+			} else if getErr != nil {
+				return fmt.Errorf("failed to get time index: %w", getErr)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan deployments: %w", err)
+	}
+
+	batch := r.db.NewWriteBatch()
+	defer batch.Cancel()
+
+	for _, model := range models {
+		data, mErr := json.Marshal(model.ID)
+		if mErr != nil {
+			return 0, fmt.Errorf("failed to marshal deployment ID: %w", mErr)
+		}
+
+		if setErr := batch.Set(r.getTimeKey(model.CreatedAt.UnixNano(), model.ID), data); setErr != nil {
+			return 0, fmt.Errorf("failed to set time index: %w", setErr)
+		}
+	}
+
+	if flushErr := batch.Flush(); flushErr != nil {
+		return 0, fmt.Errorf("failed to write time index: %w", flushErr)
+	}
+
+	return len(models), nil
+}
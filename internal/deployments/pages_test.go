@@ -0,0 +1,159 @@
+package deployments_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/google/uuid"
+)
+
+func TestServiceList(t *testing.T) {
+	t.Parallel()
+
+	e := newEnv(t)
+	app := e.createStack(t, "app")
+	web := e.createStack(t, "web")
+
+	// Deployments alternate between the stacks; every third one failed.
+	created := make([]*deployments.Deployment, 0, 8)
+	for i := range 8 {
+		stackID := app.ID
+		if i%2 == 1 {
+			stackID = web.ID
+		}
+		status := deployments.StatusSuccess
+		if i%3 == 2 {
+			status = deployments.StatusFailed
+		}
+		created = append(created, e.createDeployment(t, stackID, status))
+	}
+
+	// pick returns the indexes of created matching the predicate, oldest first.
+	pick := func(predicate func(i int, d *deployments.Deployment) bool) []int {
+		var indexes []int
+		for i, d := range created {
+			if predicate(i, d) {
+				indexes = append(indexes, i)
+			}
+		}
+		return indexes
+	}
+	all := func(int, *deployments.Deployment) bool { return true }
+
+	tests := []struct {
+		name   string
+		filter deployments.Filter
+		order  deployments.SortOrder
+		limit  int
+		want   []int
+	}{
+		{name: "newest first", order: "", limit: 3, want: pick(all)},
+		{name: "oldest first", order: deployments.SortOrderAsc, limit: 3, want: pick(all)},
+		{name: "single page", order: deployments.SortOrderAsc, limit: 100, want: pick(all)},
+		{name: "exact pages", order: deployments.SortOrderAsc, limit: 4, want: pick(all)},
+		{
+			name:   "stack",
+			filter: deployments.Filter{StackID: &web.ID},
+			limit:  2,
+			want:   pick(func(_ int, d *deployments.Deployment) bool { return d.StackID == web.ID }),
+		},
+		{
+			name:   "stack oldest first",
+			filter: deployments.Filter{StackID: &app.ID},
+			order:  deployments.SortOrderAsc,
+			limit:  3,
+			want:   pick(func(_ int, d *deployments.Deployment) bool { return d.StackID == app.ID }),
+		},
+		{
+			name:   "status",
+			filter: deployments.Filter{Statuses: []deployments.Status{deployments.StatusFailed}},
+			limit:  1,
+			want:   pick(func(_ int, d *deployments.Deployment) bool { return d.Status == deployments.StatusFailed }),
+		},
+		{
+			name:   "time range",
+			filter: deployments.Filter{Since: &created[2].CreatedAt, Until: &created[6].CreatedAt},
+			limit:  3,
+			want:   []int{2, 3, 4, 5},
+		},
+		{
+			name:   "time range oldest first",
+			filter: deployments.Filter{Since: &created[2].CreatedAt, Until: &created[6].CreatedAt},
+			order:  deployments.SortOrderAsc,
+			limit:  3,
+			want:   []int{2, 3, 4, 5},
+		},
+		{
+			name: "stack, status and time range",
+			filter: deployments.Filter{
+				StackID:  &app.ID,
+				Statuses: []deployments.Status{deployments.StatusSuccess},
+				Since:    &created[1].CreatedAt,
+			},
+			limit: 2,
+			want:  []int{4, 6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			want := make([]uuid.UUID, 0, len(tt.want))
+			for _, i := range tt.want {
+				want = append(want, created[i].ID)
+			}
+			if tt.order != deployments.SortOrderAsc {
+				slices.Reverse(want)
+			}
+
+			var got []uuid.UUID
+			req := deployments.PageRequest{Order: tt.order, Cursor: "", Limit: tt.limit}
+			for range len(created) + 1 {
+				page, err := e.svc.List(t.Context(), tt.filter, req)
+				if err != nil {
+					t.Fatalf("List() error = %v", err)
+				}
+				if len(page.Items) > tt.limit {
+					t.Fatalf("List() = %d items, want at most %d", len(page.Items), tt.limit)
+				}
+				for _, d := range page.Items {
+					got = append(got, d.ID)
+				}
+				if page.Next == "" {
+					break
+				}
+				req.Cursor = page.Next
+			}
+
+			if !slices.Equal(got, want) {
+				t.Errorf("List() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestServiceListInvalid(t *testing.T) {
+	t.Parallel()
+
+	e := newEnv(t)
+
+	tests := []struct {
+		name   string
+		filter deployments.Filter
+		cursor string
+	}{
+		{name: "unknown status", filter: deployments.Filter{Statuses: []deployments.Status{"done"}}},
+		{name: "malformed cursor", cursor: "%%%"},
+		{name: "cursor without ID", cursor: "MTIz"},
+	}
+
+	for _, tt := range tests {
+		_, err := e.svc.List(t.Context(), tt.filter, deployments.PageRequest{Cursor: tt.cursor})
+		if !errors.Is(err, deployments.ErrNotAllowed) {
+			t.Errorf("%s: List() error = %v, want %v", tt.name, err, deployments.ErrNotAllowed)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package deployments
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// queue is a FIFO of deployment IDs waiting for a worker. It is unbounded as
+// every queued deployment is persisted as pending and only its ID is kept in
+// memory.
+type queue struct {
+	mu    sync.Mutex
+	items []uuid.UUID
+	ready chan struct{}
+}
+
+func newQueue() *queue {
+	return &queue{
+		mu:    sync.Mutex{},
+		items: []uuid.UUID{},
+		ready: make(chan struct{}, 1),
+	}
+}
+
+// push appends id to the queue and wakes up a waiting worker.
+func (q *queue) push(id uuid.UUID) {
+	q.mu.Lock()
+	q.items = append(q.items, id)
+	q.mu.Unlock()
+
+	q.signal()
+}
+
+// pop blocks until an ID is available or ctx is done.
+func (q *queue) pop(ctx context.Context) (uuid.UUID, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			id := q.items[0]
+			q.items = q.items[1:]
+			remaining := len(q.items)
+			q.mu.Unlock()
+
+			// Pass the wake-up on to the next worker.
+			if remaining > 0 {
+				q.signal()
+			}
+
+			return id, true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return uuid.Nil, false
+		case <-q.ready:
+		}
+	}
+}
+
+func (q *queue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,166 @@
+package deployments_test
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/google/uuid"
+)
+
+func TestQueueOrder(t *testing.T) {
+	t.Parallel()
+
+	q := deployments.NewQueue()
+
+	want := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	for _, id := range want {
+		q.Push(id)
+	}
+
+	got := make([]uuid.UUID, 0, len(want))
+	for range want {
+		id, ok := q.Pop(t.Context())
+		if !ok {
+			t.Fatal("Pop() ok = false, want true")
+		}
+		got = append(got, id)
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("Pop() order = %v, want %v", got, want)
+	}
+}
+
+func TestQueuePopWaits(t *testing.T) {
+	t.Parallel()
+
+	q := deployments.NewQueue()
+	want := uuid.New()
+
+	popped := make(chan uuid.UUID)
+	go func() {
+		id, _ := q.Pop(t.Context())
+		popped <- id
+	}()
+
+	select {
+	case id := <-popped:
+		t.Fatalf("Pop() = %s on an empty queue, want it to wait", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	q.Push(want)
+
+	select {
+	case id := <-popped:
+		if id != want {
+			t.Errorf("Pop() = %s, want %s", id, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Pop() still waiting after Push()")
+	}
+}
+
+func TestQueuePopCancelled(t *testing.T) {
+	t.Parallel()
+
+	q := deployments.NewQueue()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan bool)
+	go func() {
+		_, ok := q.Pop(ctx)
+		done <- ok
+	}()
+
+	cancel()
+
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("Pop() ok = true after cancellation, want false")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Pop() still waiting after cancellation")
+	}
+}
+
+func TestQueueConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const (
+		producers = 4
+		consumers = 4
+		perWorker = 100
+	)
+
+	q := deployments.NewQueue()
+
+	var (
+		mu     sync.Mutex
+		popped = make(map[uuid.UUID]int)
+		wg     sync.WaitGroup
+	)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	for range consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				id, ok := q.Pop(ctx)
+				if !ok {
+					return
+				}
+				mu.Lock()
+				popped[id]++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	pushed := make(chan uuid.UUID, producers*perWorker)
+	var producersWg sync.WaitGroup
+	for range producers {
+		producersWg.Add(1)
+		go func() {
+			defer producersWg.Done()
+			for range perWorker {
+				id := uuid.New()
+				pushed <- id
+				q.Push(id)
+			}
+		}()
+	}
+	producersWg.Wait()
+	close(pushed)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		count := len(popped)
+		mu.Unlock()
+		if count == producers*perWorker {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("popped %d IDs, want %d", count, producers*perWorker)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	wg.Wait()
+
+	for id := range pushed {
+		if n := popped[id]; n != 1 {
+			t.Errorf("ID %s popped %d times, want once", id, n)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/reconciler"
+	"go.uber.org/zap"
+)
+
+// driftCheckTimeout bounds the comparison of an interrupted deployment with
+// the cluster, which delays the start of the workers.
+const driftCheckTimeout = 30 * time.Second
+
+// recoverInterrupted handles a deployment left running by a previous run
+// according to the recovery policy. It reports whether the deployment is
+// pending again and has to be queued.
+func (s *Service) recoverInterrupted(ctx context.Context, d *Deployment) bool {
+	logger := s.logger.With(zap.String("deployment_id", d.ID.String()), zap.String("stack_id", d.StackID.String()))
+
+	if s.config.Recovery == RecoveryPolicyResume {
+		if err := s.update(ctx, d.ID, func(current *Deployment) error {
+			if current.Status != StatusRunning {
+				return fmt.Errorf("%w: deployment is %s", ErrConflict, current.Status)
+			}
+
+			current.Status = StatusPending
+			current.StartedAt = nil
+			return nil
+		}); err != nil {
+			logger.Error("failed to resume interrupted deployment", zap.Error(err))
+			return false
+		}
+
+		logger.Warn("resuming interrupted deployment")
+		s.record(ctx, d.ID, LogLevelWarn, "deployment interrupted by restart, resuming")
+		return true
+	}
+
+	s.complete(ctx, d.ID, ErrInterrupted, logger)
+
+	// Without a manifest snapshot the deployment has not reached the cluster.
+	if d.Manifest == "" {
+		return false
+	}
+
+	s.reportDrift(ctx, d)
+	s.autoRollback(ctx, d)
+
+	return false
+}
+
+// reportDrift records how the services in the cluster differ from the
+// manifest of an interrupted deployment.
+func (s *Service) reportDrift(ctx context.Context, d *Deployment) {
+	manifest, err := s.manifests.Get(ctx, d.Manifest)
+	if err != nil {
+		s.record(ctx, d.ID, LogLevelWarn, "failed to get manifest snapshot: %v", err)
+		return
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, driftCheckTimeout)
+	defer cancel()
+
+	plan, err := s.reconciler.Plan(checkCtx, manifest.Stack)
+	if err != nil {
+		s.record(ctx, d.ID, LogLevelWarn, "failed to compare the cluster with the manifest: %v", err)
+		return
+	}
+
+	if len(plan.Services) == 0 {
+		s.record(ctx, d.ID, LogLevelInfo, "services in the cluster match the manifest")
+		return
+	}
+
+	changes := make([]string, 0, len(plan.Services))
+	for _, c := range plan.Services {
+		changes = append(changes, describeChange(c))
+	}
+
+	s.record(ctx, d.ID, LogLevelWarn, "services in the cluster differ from the manifest: %s", strings.Join(changes, ", "))
+}
+
+func describeChange(c reconciler.ServiceChange) string {
+	switch c.Action {
+	case reconciler.ActionCreate:
+		return c.Name + " is missing"
+	case reconciler.ActionRemove:
+		return c.Name + " is not in the manifest"
+	case reconciler.ActionUpdate:
+		return c.Name + " has a different spec"
+	}
+
+	return c.Name
+}
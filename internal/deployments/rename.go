@@ -0,0 +1,173 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Rename renames a stack. A rename that changes the namespace of a deployed
+// stack requires migrate, as its Swarm resources would be left behind
+// otherwise. With migrate, the next deployment removes the resources of the
+// previous namespace before deploying to the new one, and the last successful
+// deployment is redeployed right away. The redeployment is returned if any.
+func (s *Service) Rename(
+	ctx context.Context,
+	stackID uuid.UUID,
+	name string,
+	migrate bool,
+) (*stacks.Stack, *Deployment, error) {
+	logger := s.logger.With(zap.String("stack_id", stackID.String()), zap.String("name", name))
+
+	stack, err := s.getStack(ctx, stackID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	renamed, last, err := s.rename(ctx, stack, name, migrate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if renamed.PreviousNamespace == "" || last == nil {
+		return renamed, nil, nil
+	}
+
+	logger.Info("migrating namespace",
+		zap.String("from", renamed.PreviousNamespace),
+		zap.String("to", renamed.Namespace()),
+		zap.String("version", last.Version),
+	)
+
+	// The rename is done at this point; if the redeployment cannot be queued,
+	// the next deployment of the stack migrates the namespace.
+	d, err := s.enqueue(ctx, renamed, newRedeployDraft(last))
+	if err != nil {
+		logger.Error("failed to queue redeployment", zap.Error(err))
+		return renamed, nil, nil
+	}
+
+	s.record(ctx, d.ID, LogLevelInfo, "redeploying deployment %s to migrate namespace %s to %s",
+		last.ID, renamed.PreviousNamespace, renamed.Namespace())
+
+	return renamed, d, nil
+}
+
+// rename renames the stack unless a deployment of the stack is in progress
+// and returns it with its last successful deployment.
+func (s *Service) rename(
+	ctx context.Context,
+	stack *stacks.Stack,
+	name string,
+	migrate bool,
+) (*stacks.Stack, *Deployment, error) {
+	s.triggerMu.Lock()
+	defer s.triggerMu.Unlock()
+
+	if s.deleting[stack.ID] {
+		return nil, nil, fmt.Errorf("%w: stack is being deleted", ErrConflict)
+	}
+
+	last, err := s.deployments.GetLatestByStack(ctx, stack.ID, func(d *Deployment) bool {
+		return d.Status == StatusSuccess
+	})
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, nil, fmt.Errorf("failed to get last successful deployment: %w", err)
+	}
+
+	if to := stacks.Namespace(name); to != stack.Namespace() {
+		if idleErr := s.checkIdle(ctx, stack.ID); idleErr != nil {
+			return nil, nil, idleErr
+		}
+
+		switch {
+		case last != nil && !migrate:
+			return nil, nil, fmt.Errorf("%w: renaming changes the namespace from %s to %s, migrate the deployed resources",
+				ErrNotAllowed, stack.Namespace(), to)
+		case migrate && stack.Status == stacks.StatusInactive:
+			return nil, nil, fmt.Errorf("%w: stack is suspended", ErrConflict)
+		}
+	}
+
+	renamed, err := s.stacksSvc.Rename(ctx, stack.ID, name, migrate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to rename stack: %w", err)
+	}
+
+	return renamed, last, nil
+}
+
+// removePreviousNamespace removes the Swarm resources left in the namespace
+// the stack had before it was renamed. They are removed before the services
+// are created in the new namespace, as published ports would conflict.
+func (s *Service) removePreviousNamespace(ctx context.Context, stack *stacks.Stack, d *Deployment) error {
+	previous := stack.PreviousNamespace
+	if previous == "" || previous == stack.Namespace() {
+		return nil
+	}
+
+	// Resources are removed by their namespace label, which another stack
+	// must not share.
+	users, err := s.stacksSvc.FindByNamespace(ctx, previous)
+	if err != nil {
+		return fmt.Errorf("failed to check previous namespace %s: %w", previous, err)
+	}
+	for _, user := range users {
+		if user.ID != stack.ID {
+			return fmt.Errorf("%w: previous namespace %s is used by stack %s, not removing it",
+				ErrConflict, previous, user.Name)
+		}
+	}
+
+	s.record(ctx, d.ID, LogLevelInfo, "removing resources of previous namespace %s", previous)
+
+	removeCtx, cancel := context.WithTimeout(ctx, s.timeout(stack))
+	defer cancel()
+
+	result, err := s.reconciler.Remove(removeCtx, previous)
+	if result != nil {
+		for _, c := range result.Changes {
+			s.record(ctx, d.ID, LogLevelInfo, "%s %s: %s", c.Kind, c.Name, c.Action)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove previous namespace %s: %w", previous, err)
+	}
+
+	if updErr := s.stacksSvc.Update(ctx, stack.ID, func(current *stacks.Stack) error {
+		if current.PreviousNamespace == previous {
+			current.PreviousNamespace = ""
+		}
+		return nil
+	}); updErr != nil {
+		return fmt.Errorf("failed to update stack: %w", updErr)
+	}
+
+	s.record(ctx, d.ID, LogLevelInfo, "previous namespace %s removed", previous)
+	return nil
+}
+
+// newRedeployDraft returns a deployment of the commit and variables of d.
+func newRedeployDraft(d *Deployment) DeploymentDraft {
+	return DeploymentDraft{
+		StackID:            d.StackID,
+		Version:            d.Version,
+		GitRef:             d.GitRef,
+		Message:            d.Message,
+		Variables:          d.Variables,
+		Manifest:           d.Manifest,
+		Status:             StatusPending,
+		StartedAt:          nil,
+		CompletedAt:        nil,
+		Error:              "",
+		CancelledBy:        "",
+		CancelledAt:        nil,
+		PreviousDeployment: nil,
+		Origin:             nil,
+		RollbackOf:         nil,
+	}
+}
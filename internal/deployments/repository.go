@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/apiarycd/apiarycd/pkg/badgerfx"
@@ -17,6 +18,10 @@ const (
 
 	prefixByID    = prefix + "id:"
 	prefixByStack = prefix + "stack:"
+	prefixByTime  = prefix + "time:"
+
+	// updateAttempts bounds the retries of an update racing with another one.
+	updateAttempts = 10
 )
 
 // Repository implements the DeploymentRepository interface.
@@ -114,8 +119,21 @@ func (r *Repository) GetLatestByStack(
 	return newDeployment(latest), err
 }
 
-// Update updates an existing deployment.
+// Update updates an existing deployment. The updater is run again on the
+// current deployment when a concurrent update commits first.
 func (r *Repository) Update(_ context.Context, id uuid.UUID, updater func(*Deployment) error) error {
+	var err error
+	for range updateAttempts {
+		err = r.update(id, updater)
+		if !errors.Is(err, badger.ErrConflict) {
+			break
+		}
+	}
+
+	return err
+}
+
+func (r *Repository) update(id uuid.UUID, updater func(*Deployment) error) error {
 	err := r.db.Update(func(txn *badger.Txn) error {
 		old, err := r.getByID(txn, id)
 		if err != nil {
@@ -156,71 +174,6 @@ func (r *Repository) Update(_ context.Context, id uuid.UUID, updater func(*Deplo
 	return nil
 }
 
-func (r *Repository) UpdateDual(
-	_ context.Context,
-	first, second uuid.UUID,
-	updater func(*Deployment, *Deployment) error,
-) error {
-	if first == second {
-		return fmt.Errorf("%w: cannot update the same deployment twice (id=%s)", ErrNotAllowed, first)
-	}
-
-	err := r.db.Update(func(txn *badger.Txn) error {
-		oldFirst, err := r.getByID(txn, first)
-		if err != nil {
-			return fmt.Errorf("failed to get first deployment before update: %w", err)
-		}
-
-		oldSecond, err := r.getByID(txn, second)
-		if err != nil {
-			return fmt.Errorf("failed to get second deployment before update: %w", err)
-		}
-
-		firstDeployment := newDeployment(oldFirst)
-		secondDeployment := newDeployment(oldSecond)
-
-		if updErr := updater(firstDeployment, secondDeployment); updErr != nil {
-			return fmt.Errorf("failed to update deployments: %w", updErr)
-		}
-
-		if firstDeployment.StackID != oldFirst.StackID {
-			return fmt.Errorf(
-				"%w: cannot change deployment StackID (first old=%s new=%s)",
-				ErrNotAllowed,
-				oldFirst.StackID,
-				firstDeployment.StackID,
-			)
-		}
-		if secondDeployment.StackID != oldSecond.StackID {
-			return fmt.Errorf(
-				"%w: cannot change deployment StackID (second old=%s new=%s)",
-				ErrNotAllowed,
-				oldSecond.StackID,
-				secondDeployment.StackID,
-			)
-		}
-
-		firstDeploymentModel := newDeploymentUpdateModel(oldFirst, &firstDeployment.DeploymentDraft)
-		secondDeploymentModel := newDeploymentUpdateModel(oldSecond, &secondDeployment.DeploymentDraft)
-
-		if firstErr := r.write(txn, firstDeploymentModel); firstErr != nil {
-			return firstErr
-		}
-
-		if secondErr := r.write(txn, secondDeploymentModel); secondErr != nil {
-			return secondErr
-		}
-
-		return nil
-	})
-
-	if err != nil {
-		return fmt.Errorf("failed to update deployments: %w", err)
-	}
-
-	return nil
-}
-
 func (r *Repository) write(txn *badger.Txn, deployment *deploymentModel) error {
 	// Serialize the deployment
 	data, err := json.Marshal(deployment)
@@ -272,6 +225,63 @@ func (r *Repository) Delete(_ context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// listIDsByStack returns the IDs of up to limit of the oldest deployments of
+// a stack.
+func (r *Repository) listIDsByStack(_ context.Context, stackID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, limit)
+
+	err := r.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := r.getStackPrefix(stackID)
+		for it.Seek(prefix); it.ValidForPrefix(prefix) && len(ids) < limit; it.Next() {
+			var id uuid.UUID
+			if err := it.Item().Value(func(val []byte) error { return json.Unmarshal(val, &id) }); err != nil {
+				return fmt.Errorf("failed to unmarshal deployment ID: %w", err)
+			}
+
+			ids = append(ids, id)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments of stack: %w", err)
+	}
+
+	return ids, nil
+}
+
+// deleteMany deletes deployments and their indexes in one transaction.
+func (r *Repository) deleteMany(_ context.Context, ids []uuid.UUID) error {
+	err := r.db.Update(func(txn *badger.Txn) error {
+		for _, id := range ids {
+			deployment, err := r.getByID(txn, id)
+			if err != nil {
+				return err
+			}
+
+			if delErr := txn.Delete(r.getKey(id)); delErr != nil {
+				return fmt.Errorf("failed to delete deployment: %w", delErr)
+			}
+
+			if rmErr := r.removeIndexes(txn, deployment); rmErr != nil {
+				return fmt.Errorf("failed to remove deployment indexes: %w", rmErr)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to delete deployments: %w", err)
+	}
+
+	return nil
+}
+
 // List retrieves deployments based on filter criteria.
 func (r *Repository) List(_ context.Context) ([]Deployment, error) {
 	var deployments []Deployment
@@ -289,6 +299,26 @@ func (r *Repository) List(_ context.Context) ([]Deployment, error) {
 	return deployments, nil
 }
 
+// ListByStatus retrieves the deployments in any of the given statuses, oldest
+// first.
+func (r *Repository) ListByStatus(_ context.Context, statuses ...Status) ([]Deployment, error) {
+	var deployments []Deployment
+
+	err := r.db.View(func(txn *badger.Txn) error {
+		var err error
+		deployments, err = r.list(txn, func(d *Deployment) bool {
+			return slices.Contains(statuses, d.Status)
+		})
+		return err
+	})
+
+	if err != nil {
+		return deployments, fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	return deployments, nil
+}
+
 func (r *Repository) list(txn *badger.Txn, predicate func(*Deployment) bool) ([]Deployment, error) {
 	var deployments []Deployment
 
@@ -390,6 +420,11 @@ func (r *Repository) getKey(id uuid.UUID) []byte {
 	return []byte(prefixByID + id.String())
 }
 
+// getTimeKey generates the key of a deployment in the time index.
+func (r *Repository) getTimeKey(unixNano int64, id uuid.UUID) []byte {
+	return fmt.Appendf(nil, "%s%020d:%s", prefixByTime, unixNano, id.String())
+}
+
 // getStackPrefix generates the prefix for stack-specific deployments.
 func (r *Repository) getStackPrefix(stackID uuid.UUID) []byte {
 	return []byte(prefixByStack + stackID.String() + ":")
@@ -409,6 +444,11 @@ func (r *Repository) createIndexes(txn *badger.Txn, deployment *deploymentModel)
 		return fmt.Errorf("failed to set stack index: %w", setErr)
 	}
 
+	// Time index `deployment:time:<unix_nano>:<id>`
+	if setErr := txn.Set(r.getTimeKey(deployment.CreatedAt.UnixNano(), deployment.ID), stackData); setErr != nil {
+		return fmt.Errorf("failed to set time index: %w", setErr)
+	}
+
 	return nil
 }
 
@@ -422,5 +462,11 @@ func (r *Repository) removeIndexes(txn *badger.Txn, deployment *deploymentModel)
 		return fmt.Errorf("failed to delete stack index: %w", err)
 	}
 
+	// Time index
+	timeKey := r.getTimeKey(deployment.CreatedAt.UnixNano(), deployment.ID)
+	if err := txn.Delete(timeKey); err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+		return fmt.Errorf("failed to delete time index: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,188 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// maxOriginDepth bounds how many rollbacks of rollbacks are followed to find
+// the deployment a rollback runs.
+const maxOriginDepth = 16
+
+// Rollback queues a deployment of the manifest snapshot of target, or of its
+// commit and variables if it has none. Without a target, it rolls back to the
+// successful deployment preceding the applied one; if the applied deployment
+// is itself a rollback, the one preceding its origin, so that repeated
+// rollbacks walk further back in history.
+func (s *Service) Rollback(ctx context.Context, stackID uuid.UUID, target *uuid.UUID) (*Deployment, error) {
+	logger := s.logger.With(zap.String("stack_id", stackID.String()))
+
+	stack, err := s.stacksSvc.Get(ctx, stackID)
+	if err != nil {
+		logger.Error("failed to get stack for rollback", zap.Error(err))
+		return nil, fmt.Errorf("failed to get stack for rollback: %w", err)
+	}
+
+	var origin *Deployment
+	if target != nil {
+		origin, err = s.deployments.GetByID(ctx, *target)
+		if err != nil {
+			return nil, err
+		}
+		if origin.StackID != stack.ID {
+			return nil, fmt.Errorf("%w: %s in stack %s", ErrNotFound, target, stack.ID)
+		}
+		if origin.Status != StatusSuccess {
+			return nil, fmt.Errorf("%w: cannot roll back to a deployment that is %s", ErrNotAllowed, origin.Status)
+		}
+	} else {
+		origin, err = s.rollbackTarget(ctx, stack.ID)
+		if err != nil {
+			logger.Error("failed to find rollback target", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	logger.Info("rolling back", zap.String("origin", origin.ID.String()), zap.String("version", origin.Version))
+
+	return s.enqueue(ctx, stack, newRollbackDraft(origin, nil))
+}
+
+// rollbackTarget returns the last successful deployment, not counting
+// rollbacks, that precedes the deployment applied to the stack. The applied
+// deployment is the latest finished one, whatever its outcome: a failed
+// deployment has still changed the cluster. Deployments cancelled before they
+// started have not and are ignored.
+func (s *Service) rollbackTarget(ctx context.Context, stackID uuid.UUID) (*Deployment, error) {
+	current, err := s.deployments.GetLatestByStack(ctx, stackID, func(d *Deployment) bool {
+		return d.IsFinished() && (d.Status != StatusCancelled || d.StartedAt != nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A rollback runs the deployment it originates from.
+	for range maxOriginDepth {
+		if current.Origin == nil {
+			break
+		}
+
+		current, err = s.deployments.GetByID(ctx, *current.Origin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rollback origin: %w", err)
+		}
+	}
+
+	target, err := s.deployments.GetLatestByStack(ctx, stackID, func(d *Deployment) bool {
+		return d.Status == StatusSuccess && d.Origin == nil && d.CreatedAt.Before(current.CreatedAt)
+	})
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("%w: no successful deployment before %s", ErrNotFound, current.ID)
+	}
+
+	return target, err
+}
+
+// autoRollback queues a redeployment of the last successful deployment of the
+// stack after d has failed, if the stack opted in. A deployment whose service
+// update Swarm has rolled back is rolled back as well, since the other services
+// of the stack may have been updated. Automatic rollbacks are not rolled back
+// themselves, and pending deployments of the stack supersede them.
+//
+// There is no separate notification channel: deployment logs are how clients
+// follow deployments, so the rollback is announced in the logs of both
+// deployments, and the rollback deployment links to the failed one through
+// RollbackOf.
+func (s *Service) autoRollback(ctx context.Context, d *Deployment) {
+	logger := s.logger.With(zap.String("deployment_id", d.ID.String()), zap.String("stack_id", d.StackID.String()))
+
+	stack, err := s.stacksSvc.Get(ctx, d.StackID)
+	if err != nil {
+		logger.Error("failed to get stack for automatic rollback", zap.Error(err))
+		return
+	}
+	if !stack.AutoRollback || d.RollbackOf != nil {
+		return
+	}
+
+	failed, err := s.deployments.GetByID(ctx, d.ID)
+	if err != nil {
+		logger.Error("failed to get failed deployment", zap.Error(err))
+		return
+	}
+	if failed.Status != StatusFailed && failed.Status != StatusRolledBack {
+		return
+	}
+
+	pending, err := s.deployments.GetLatestByStack(ctx, stack.ID, func(p *Deployment) bool {
+		return p.Status == StatusPending
+	})
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		logger.Error("failed to get pending deployment", zap.Error(err))
+		return
+	}
+	if pending != nil {
+		s.record(ctx, d.ID, LogLevelWarn, "automatic rollback skipped: deployment %s is pending", pending.ID)
+		return
+	}
+
+	target, err := s.deployments.GetLatestByStack(ctx, stack.ID, func(p *Deployment) bool {
+		return p.Status == StatusSuccess
+	})
+	if errors.Is(err, ErrNotFound) {
+		s.record(ctx, d.ID, LogLevelWarn, "automatic rollback skipped: no successful deployment")
+		return
+	}
+	if err != nil {
+		logger.Error("failed to get last successful deployment", zap.Error(err))
+		return
+	}
+
+	// A rollback runs the deployment it originates from.
+	if target.Origin != nil {
+		if origin, originErr := s.deployments.GetByID(ctx, *target.Origin); originErr == nil {
+			target = origin
+		}
+	}
+
+	rollback, err := s.enqueue(ctx, stack, newRollbackDraft(target, &d.ID))
+	if err != nil {
+		logger.Error("failed to queue automatic rollback", zap.Error(err))
+		s.record(ctx, d.ID, LogLevelError, "failed to queue automatic rollback: %v", err)
+		return
+	}
+
+	logger.Warn("automatic rollback queued",
+		zap.String("rollback_id", rollback.ID.String()),
+		zap.String("target_id", target.ID.String()),
+		zap.String("failed_status", string(failed.Status)),
+	)
+	s.record(ctx, d.ID, LogLevelInfo, "automatic rollback to deployment %s queued as deployment %s",
+		target.ID, rollback.ID)
+}
+
+// newRollbackDraft returns a pending redeployment of origin. rollbackOf is
+// the failed deployment reverted by an automatic rollback, nil otherwise.
+func newRollbackDraft(origin *Deployment, rollbackOf *uuid.UUID) DeploymentDraft {
+	return DeploymentDraft{
+		StackID:            origin.StackID,
+		Version:            origin.Version,
+		GitRef:             origin.GitRef,
+		Message:            origin.Message,
+		Variables:          origin.Variables,
+		Manifest:           origin.Manifest,
+		Status:             StatusPending,
+		StartedAt:          nil,
+		CompletedAt:        nil,
+		Error:              "",
+		CancelledBy:        "",
+		CancelledAt:        nil,
+		PreviousDeployment: nil,
+		Origin:             &origin.ID,
+		RollbackOf:         rollbackOf,
+	}
+}
@@ -0,0 +1,254 @@
+package deployments_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	"github.com/google/uuid"
+)
+
+// historyEntry describes a deployment of a stack history. origin is the
+// index of the deployment redeployed by a rollback, -1 for none.
+type historyEntry struct {
+	status  deployments.Status
+	started bool
+	origin  int
+}
+
+func TestServiceRollbackTarget(t *testing.T) {
+	t.Parallel()
+
+	success := historyEntry{status: deployments.StatusSuccess, started: true, origin: -1}
+
+	tests := []struct {
+		name    string
+		history []historyEntry
+		want    int // Index of the expected origin of the rollback
+		wantErr error
+	}{
+		{
+			name:    "previous success",
+			history: []historyEntry{success, success},
+			want:    0,
+		},
+		{
+			name: "failed deployment is the applied one",
+			history: []historyEntry{
+				success,
+				success,
+				{status: deployments.StatusFailed, started: true, origin: -1},
+			},
+			want: 1,
+		},
+		{
+			name: "deployment rolled back by Swarm is the applied one",
+			history: []historyEntry{
+				success,
+				success,
+				{status: deployments.StatusRolledBack, started: true, origin: -1},
+			},
+			want: 1,
+		},
+		{
+			name: "cancelled before starting",
+			history: []historyEntry{
+				success,
+				success,
+				{status: deployments.StatusCancelled, started: false, origin: -1},
+			},
+			want: 0,
+		},
+		{
+			name: "cancelled while running",
+			history: []historyEntry{
+				success,
+				success,
+				{status: deployments.StatusCancelled, started: true, origin: -1},
+			},
+			want: 1,
+		},
+		{
+			name: "pending deployment",
+			history: []historyEntry{
+				success,
+				success,
+				{status: deployments.StatusPending, started: false, origin: -1},
+			},
+			want: 0,
+		},
+		{
+			name: "repeated rollback walks further back",
+			history: []historyEntry{
+				success,
+				success,
+				success,
+				{status: deployments.StatusSuccess, started: true, origin: 1},
+			},
+			want: 0,
+		},
+		{
+			name:    "no earlier deployment",
+			history: []historyEntry{success},
+			wantErr: deployments.ErrNotFound,
+		},
+		{
+			name:    "no history",
+			history: nil,
+			wantErr: deployments.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := newEnv(t)
+			stack := e.createStack(t, "app")
+
+			ids := make([]uuid.UUID, 0, len(tt.history))
+			for _, entry := range tt.history {
+				d := e.createDeployment(t, stack.ID, entry.status)
+				if entry.started || entry.origin >= 0 {
+					e.updateDeployment(t, d.ID, func(d *deployments.Deployment) {
+						if entry.started {
+							now := time.Now()
+							d.StartedAt = &now
+						}
+						if entry.origin >= 0 {
+							d.Origin = &ids[entry.origin]
+						}
+					})
+				}
+				ids = append(ids, d.ID)
+			}
+
+			rollback, err := e.svc.Rollback(t.Context(), stack.ID, nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Rollback() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Rollback() error = %v", err)
+			}
+
+			if rollback.Origin == nil || *rollback.Origin != ids[tt.want] {
+				t.Errorf("Rollback() origin = %v, want %s", rollback.Origin, ids[tt.want])
+			}
+			if rollback.Status != deployments.StatusPending {
+				t.Errorf("Rollback() status = %s, want %s", rollback.Status, deployments.StatusPending)
+			}
+		})
+	}
+}
+
+func TestServiceRollbackExplicitTarget(t *testing.T) {
+	t.Parallel()
+
+	e := newEnv(t)
+	stack := e.createStack(t, "app")
+	other := e.createStack(t, "other")
+
+	succeeded := e.createDeployment(t, stack.ID, deployments.StatusSuccess)
+	failed := e.createDeployment(t, stack.ID, deployments.StatusFailed)
+	foreign := e.createDeployment(t, other.ID, deployments.StatusSuccess)
+
+	tests := []struct {
+		name    string
+		target  uuid.UUID
+		wantErr error
+	}{
+		{name: "successful deployment", target: succeeded.ID, wantErr: nil},
+		{name: "failed deployment", target: failed.ID, wantErr: deployments.ErrNotAllowed},
+		{name: "deployment of another stack", target: foreign.ID, wantErr: deployments.ErrNotFound},
+		{name: "unknown deployment", target: uuid.New(), wantErr: deployments.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		rollback, err := e.svc.Rollback(t.Context(), stack.ID, &tt.target)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%s: Rollback() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if err == nil && (rollback.Origin == nil || *rollback.Origin != tt.target) {
+			t.Errorf("%s: Rollback() origin = %v, want %s", tt.name, rollback.Origin, tt.target)
+		}
+	}
+}
+
+func TestServiceAutoRollback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		autoRollback bool
+		status       deployments.Status
+		automatic    bool // The deployment is itself an automatic rollback
+		pending      bool // Another deployment is pending
+		want         bool
+	}{
+		{name: "failed", autoRollback: true, status: deployments.StatusFailed, want: true},
+		{name: "rolled back by Swarm", autoRollback: true, status: deployments.StatusRolledBack, want: true},
+		{name: "cancelled", autoRollback: true, status: deployments.StatusCancelled, want: false},
+		{name: "succeeded", autoRollback: true, status: deployments.StatusSuccess, want: false},
+		{name: "not opted in", autoRollback: false, status: deployments.StatusFailed, want: false},
+		{name: "automatic rollback", autoRollback: true, status: deployments.StatusFailed, automatic: true, want: false},
+		{name: "superseded", autoRollback: true, status: deployments.StatusFailed, pending: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := newEnv(t)
+			stack := e.createStack(t, "app")
+			if err := e.stacks.Update(t.Context(), stack.ID, func(s *stacks.Stack) error {
+				s.AutoRollback = tt.autoRollback
+				return nil
+			}); err != nil {
+				t.Fatalf("failed to update stack: %v", err)
+			}
+
+			target := e.createDeployment(t, stack.ID, deployments.StatusSuccess)
+			d := e.createDeployment(t, stack.ID, tt.status)
+			if tt.automatic {
+				e.updateDeployment(t, d.ID, func(d *deployments.Deployment) {
+					d.Origin = &target.ID
+					d.RollbackOf = &target.ID
+				})
+				d = e.getDeployment(t, d.ID)
+			}
+			if tt.pending {
+				e.createDeployment(t, stack.ID, deployments.StatusPending)
+			}
+
+			e.svc.AutoRollback(t.Context(), d)
+
+			rollback, err := e.deployments.GetLatestByStack(t.Context(), stack.ID, func(p *deployments.Deployment) bool {
+				return p.RollbackOf != nil && *p.RollbackOf == d.ID
+			})
+			if errors.Is(err, deployments.ErrNotFound) {
+				if tt.want {
+					t.Fatal("no automatic rollback queued")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetLatestByStack() error = %v", err)
+			}
+			if !tt.want {
+				t.Fatalf("automatic rollback %s queued", rollback.ID)
+			}
+
+			if rollback.Origin == nil || *rollback.Origin != target.ID {
+				t.Errorf("rollback origin = %v, want %s", rollback.Origin, target.ID)
+			}
+			if rollback.Status != deployments.StatusPending {
+				t.Errorf("rollback status = %s, want %s", rollback.Status, deployments.StatusPending)
+			}
+		})
+	}
+}
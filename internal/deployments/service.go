@@ -5,26 +5,74 @@ import (
 	"errors"
 	"fmt"
 	"maps"
+	"sync"
 	"time"
 
+	"github.com/apiarycd/apiarycd/internal/git"
+	"github.com/apiarycd/apiarycd/internal/reconciler"
 	"github.com/apiarycd/apiarycd/internal/stacks"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+const defaultPageLimit = 50
+
 type Service struct {
+	config Config
+
 	deployments *Repository
+	logs        *LogRepository
+	manifests   *ManifestRepository
+	notifier    *logNotifier
+
+	stacksSvc  *stacks.Service
+	git        *git.Client
+	reconciler *reconciler.Reconciler
 
-	stacksSvc *stacks.Service
+	queue   *queue
+	locks   *stackLocks
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+
+	triggerMu sync.Mutex
+	deleting  map[uuid.UUID]bool // Stacks being deleted, guarded by triggerMu
+	runningMu sync.Mutex
+	running   map[uuid.UUID]context.CancelCauseFunc // Cancels the deployments being executed
 
 	logger *zap.Logger
 }
 
-func NewService(deployments *Repository, stacksSvc *stacks.Service, logger *zap.Logger) *Service {
+func NewService(
+	config Config,
+	deployments *Repository,
+	logs *LogRepository,
+	manifests *ManifestRepository,
+	stacksSvc *stacks.Service,
+	git *git.Client,
+	reconciler *reconciler.Reconciler,
+	logger *zap.Logger,
+) *Service {
 	return &Service{
+		config: config,
+
 		deployments: deployments,
+		logs:        logs,
+		manifests:   manifests,
+		notifier:    newLogNotifier(),
+
+		stacksSvc:  stacksSvc,
+		git:        git,
+		reconciler: reconciler,
 
-		stacksSvc: stacksSvc,
+		queue:   newQueue(),
+		locks:   newStackLocks(),
+		cancel:  nil,
+		workers: sync.WaitGroup{},
+
+		triggerMu: sync.Mutex{},
+		deleting:  make(map[uuid.UUID]bool),
+		runningMu: sync.Mutex{},
+		running:   make(map[uuid.UUID]context.CancelCauseFunc),
 
 		logger: logger,
 	}
@@ -62,17 +110,78 @@ func (s *Service) Get(ctx context.Context, id uuid.UUID) (*Deployment, error) {
 	return deployment, nil
 }
 
-// ListByStack retrieves all deployments.
-func (s *Service) ListByStack(ctx context.Context, stackID uuid.UUID) ([]Deployment, error) {
+// GetLatestByStack retrieves the latest deployment of a stack matching predicate.
+func (s *Service) GetLatestByStack(
+	ctx context.Context,
+	stackID uuid.UUID,
+	predicate func(*Deployment) bool,
+) (*Deployment, error) {
+	s.logger.Debug("getting latest deployment", zap.String("stack_id", stackID.String()))
+
+	deployment, err := s.deployments.GetLatestByStack(ctx, stackID, predicate)
+	if err != nil {
+		return nil, err
+	}
+
+	return deployment, nil
+}
+
+// GetManifest retrieves the manifest snapshot applied by a deployment.
+func (s *Service) GetManifest(ctx context.Context, id uuid.UUID) (*Manifest, error) {
+	d, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d.Manifest == "" {
+		return nil, fmt.Errorf("%w: deployment %s has no manifest snapshot", ErrNotFound, id)
+	}
+
+	manifest, err := s.manifests.Get(ctx, d.Manifest)
+	if err != nil {
+		s.logger.Error("failed to get manifest", zap.String("id", id.String()), zap.Error(err))
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// List retrieves a page of the deployments matching filter.
+func (s *Service) List(ctx context.Context, filter Filter, req PageRequest) (*Page, error) {
 	s.logger.Debug("listing deployments")
 
-	deployments, err := s.deployments.ListByStack(ctx, stackID)
+	for _, status := range filter.Statuses {
+		if !status.isValid() {
+			return nil, fmt.Errorf("%w: unknown status %q", ErrNotAllowed, status)
+		}
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = defaultPageLimit
+	}
+
+	page, err := s.deployments.Find(ctx, filter, req)
 	if err != nil {
 		s.logger.Error("failed to list deployments", zap.Error(err))
 		return nil, err
 	}
 
-	return deployments, nil
+	return page, nil
+}
+
+// checkIdle fails with ErrConflict if a deployment of the stack is pending or
+// running. Callers hold triggerMu.
+func (s *Service) checkIdle(ctx context.Context, stackID uuid.UUID) error {
+	active, err := s.deployments.GetLatestByStack(ctx, stackID, func(d *Deployment) bool {
+		return !d.IsFinished()
+	})
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to get active deployment: %w", err)
+	}
+	if active != nil {
+		return fmt.Errorf("%w: deployment %s of the stack is %s", ErrConflict, active.ID, active.Status)
+	}
+
+	return nil
 }
 
 // update updates an existing deployment.
@@ -95,125 +204,147 @@ func (s *Service) update(ctx context.Context, id uuid.UUID, updater func(*Deploy
 	return nil
 }
 
-// Trigger triggers a deployment (placeholder for deployment logic).
+// Trigger resolves the git ref of a stack and queues a pending deployment of
+// the commit. The deployment is executed by a worker in the background, after
+// any deployment of the stack queued before it. Stacks with the reject policy
+// return ErrConflict instead while another deployment is pending or running.
 func (s *Service) Trigger(ctx context.Context, req DeploymentRequest) (*Deployment, error) {
-	logger := s.logger.With(zap.String("stack_id", req.StackID.String()))
+	s.logger.Info("triggering deployment", zap.String("stack_id", req.StackID.String()))
 
-	logger.Info("triggering deployment")
+	stack, draft, err := s.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
 
-	// Get the stack
-	stack, err := s.stacksSvc.Get(ctx, req.StackID)
+	return s.enqueue(ctx, stack, *draft)
+}
+
+// Plan renders the compose file of a stack at the requested ref and returns
+// the changes deploying it would make to the services in the cluster. Nothing
+// is mutated and no deployment is recorded.
+func (s *Service) Plan(ctx context.Context, req DeploymentRequest) (*Plan, error) {
+	logger := s.logger.With(zap.String("stack_id", req.StackID.String()))
+
+	stack, draft, err := s.prepare(ctx, req)
 	if err != nil {
-		logger.Error("failed to get stack for trigger", zap.Error(err))
-		return nil, fmt.Errorf("failed to get stack for trigger: %w", err)
+		return nil, err
 	}
 
-	latest, err := s.deployments.GetLatestByStack(
-		ctx,
-		stack.ID,
-		func(d *Deployment) bool { return d.Status == StatusSuccess },
-	)
-	if err != nil && !errors.Is(err, ErrNotFound) {
-		logger.Error("failed to get latest deployment", zap.Error(err))
+	manifest, err := s.render(ctx, stack, draft.Version, draft.Variables)
+	if err != nil {
+		logger.Error("failed to render stack for plan", zap.Error(err))
 		return nil, err
 	}
 
-	var previousDeploymentID *uuid.UUID
-	if latest != nil {
-		previousDeploymentID = &latest.ID
+	changes, err := s.reconciler.Plan(ctx, manifest)
+	if err != nil {
+		logger.Error("failed to plan stack", zap.Error(err))
+		return nil, fmt.Errorf("failed to plan stack: %w", err)
 	}
 
-	variables := maps.Clone(stack.Variables)
-	maps.Copy(variables, req.Variables)
+	return &Plan{
+		Version:   draft.Version,
+		GitRef:    draft.GitRef,
+		Message:   draft.Message,
+		Namespace: changes.Namespace,
+		Services:  changes.Services,
+	}, nil
+}
 
-	// TODO: Clone repository and determine git ref
+// prepare resolves the git ref of a deployment request and returns the stack
+// with a pending deployment of the commit.
+func (s *Service) prepare(ctx context.Context, req DeploymentRequest) (*stacks.Stack, *DeploymentDraft, error) {
+	logger := s.logger.With(zap.String("stack_id", req.StackID.String()))
 
-	// Update status to running and set started time
-	now := time.Now()
-	d, err := s.create(ctx, DeploymentDraft{
-		StackID:            stack.ID,
-		Version:            "placeholder",
-		GitRef:             "placeholder",
-		Message:            "placeholder",
-		Variables:          variables,
-		Status:             StatusPending,
-		StartedAt:          &now,
-		CompletedAt:        nil,
-		Error:              "",
-		Logs:               []string{},
-		PreviousDeployment: previousDeploymentID,
-	})
+	// Get the stack
+	stack, err := s.stacksSvc.Get(ctx, req.StackID)
 	if err != nil {
-		logger.Error("failed to create deployment", zap.Error(err))
-		return nil, err
+		logger.Error("failed to get stack", zap.Error(err))
+		return nil, nil, fmt.Errorf("failed to get stack: %w", err)
 	}
 
-	logger = logger.With(zap.String("deployment_id", d.ID.String()))
-
-	// TODO: Implement actual deployment logic here (e.g., Docker Compose deployment)
+	variables := make(map[string]string, len(stack.Variables)+len(req.Variables))
+	maps.Copy(variables, stack.Variables)
+	maps.Copy(variables, req.Variables)
 
-	// For now, simulate deployment completion
-	time.Sleep(time.Second) // Simulate some work
+	ref := req.Ref
+	if ref == "" {
+		ref = stack.GitBranch
+	}
 
-	now = time.Now()
-	err = s.update(ctx, d.ID, func(d *Deployment) error {
-		d.MarkDeployedAt(now)
-		return nil
-	})
+	commit, err := s.git.Resolve(ctx, stack.GitSource(), ref)
 	if err != nil {
-		logger.Error(
-			"failed to update deployment status after trigger",
-			zap.Error(err),
-		)
-		return nil, fmt.Errorf("failed to update deployment status: %w", err)
+		logger.Error("failed to resolve git ref", zap.String("ref", ref), zap.Error(err))
+		return nil, nil, fmt.Errorf("failed to resolve git ref: %w", err)
 	}
 
-	logger.Info("deployment triggered successfully")
-	return d, nil
+	return stack, &DeploymentDraft{
+		StackID:            stack.ID,
+		Version:            commit.SHA,
+		GitRef:             commit.Ref,
+		Message:            commit.Message,
+		Variables:          variables,
+		Manifest:           "",
+		Status:             StatusPending,
+		StartedAt:          nil,
+		CompletedAt:        nil,
+		Error:              "",
+		CancelledBy:        "",
+		CancelledAt:        nil,
+		PreviousDeployment: nil,
+		Origin:             nil,
+		RollbackOf:         nil,
+	}, nil
 }
 
-func (s *Service) Rollback(ctx context.Context, stackID uuid.UUID) (*Deployment, *Deployment, error) {
-	logger := s.logger.With(zap.String("stack_id", stackID.String()))
+// enqueue creates a pending deployment and queues it for execution. Stacks
+// with the reject policy return ErrConflict instead while another deployment
+// is pending or running.
+func (s *Service) enqueue(ctx context.Context, stack *stacks.Stack, draft DeploymentDraft) (*Deployment, error) {
+	logger := s.logger.With(zap.String("stack_id", stack.ID.String()), zap.String("version", draft.Version))
 
-	latest, err := s.deployments.GetLatestByStack(
-		ctx,
-		stackID,
-		func(d *Deployment) bool { return d.Status == StatusSuccess },
-	)
-	if err != nil {
-		logger.Error("failed to get latest deployment", zap.Error(err))
-		return nil, nil, err
+	// Checking for deployments in progress and creating the new one must not
+	// interleave with another deployment of the stack.
+	s.triggerMu.Lock()
+	defer s.triggerMu.Unlock()
+
+	if s.deleting[stack.ID] {
+		return nil, fmt.Errorf("%w: stack is being deleted", ErrConflict)
 	}
 
-	logger = logger.With(zap.String("latest_deployment_id", latest.ID.String()))
+	// The stack may have been suspended since it was read.
+	current, err := s.stacksSvc.Get(ctx, stack.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stack: %w", err)
+	}
+	if current.Status == stacks.StatusInactive {
+		return nil, fmt.Errorf("%w: stack is suspended", ErrConflict)
+	}
 
-	if latest.PreviousDeployment == nil {
-		logger.Error("no previous deployment found")
-		return nil, nil, fmt.Errorf("%w: no previous deployment found", ErrNotFound)
+	if stack.DeployPolicy == stacks.DeployPolicyReject {
+		if idleErr := s.checkIdle(ctx, stack.ID); idleErr != nil {
+			logger.Warn("deployment rejected", zap.Error(idleErr))
+			return nil, idleErr
+		}
 	}
 
-	previous, err := s.deployments.GetByID(ctx, *latest.PreviousDeployment)
+	d, err := s.create(ctx, draft)
 	if err != nil {
-		logger.Error("failed to get previous deployment", zap.Error(err))
-		return nil, nil, err
+		logger.Error("failed to create deployment", zap.Error(err))
+		return nil, err
 	}
 
-	// TODO: Rollback deployment
-
-	now := time.Now()
-	if updErr := s.deployments.UpdateDual(
-		ctx,
-		latest.ID,
-		previous.ID,
-		func(d1, d2 *Deployment) error {
-			d1.MarkRolledBack(now)
-			d2.MarkDeployedAt(now)
-			return nil
-		},
-	); updErr != nil {
-		s.logger.Error("failed to update deployments", zap.Error(updErr))
-		return nil, nil, updErr
+	switch {
+	case d.RollbackOf != nil:
+		s.record(ctx, d.ID, LogLevelInfo, "automatic rollback of failed deployment %s to deployment %s of %s (%s) queued",
+			d.RollbackOf, d.Origin, d.Version, d.GitRef)
+	case d.Origin != nil:
+		s.record(ctx, d.ID, LogLevelInfo, "rollback to deployment %s of %s (%s) queued", d.Origin, d.Version, d.GitRef)
+	default:
+		s.record(ctx, d.ID, LogLevelInfo, "deployment of %s (%s) queued", d.Version, d.GitRef)
 	}
+	s.queue.push(d.ID)
 
-	return latest, previous, nil
+	logger.Info("deployment queued", zap.String("deployment_id", d.ID.String()))
+	return d, nil
 }
@@ -0,0 +1,130 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Suspend stops the synchronization of a stack and scales its replicated
+// services to zero, remembering their replica counts for Resume. Deployments
+// of a suspended stack are refused. Stacks with a deployment in progress
+// cannot be suspended. Suspending a suspended stack scales down the services
+// scaled up since.
+func (s *Service) Suspend(ctx context.Context, stackID uuid.UUID) (*stacks.Stack, error) {
+	logger := s.logger.With(zap.String("stack_id", stackID.String()))
+
+	stack, err := s.getStack(ctx, stackID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deployments are refused from now on, so the services are not scaled
+	// back up by a deployment triggered meanwhile.
+	if susErr := s.markSuspended(ctx, stackID); susErr != nil {
+		return nil, susErr
+	}
+
+	scaled, scErr := s.reconciler.ScaleDown(ctx, stack.Namespace())
+
+	// Services scaled down before a failure are remembered as well.
+	if updErr := s.stacksSvc.Update(ctx, stackID, func(current *stacks.Stack) error {
+		if current.SuspendedReplicas == nil {
+			current.SuspendedReplicas = make(map[string]uint64, len(scaled))
+		}
+		for name, replicas := range scaled {
+			current.SuspendedReplicas[name] = replicas
+		}
+		return nil
+	}); updErr != nil {
+		logger.Error("failed to save replica counts", zap.Any("replicas", scaled), zap.Error(updErr))
+		return nil, fmt.Errorf("failed to save replica counts: %w", updErr)
+	}
+
+	if scErr != nil {
+		logger.Error("failed to scale down stack", zap.Error(scErr))
+		return nil, fmt.Errorf("failed to scale down stack: %w", scErr)
+	}
+
+	logger.Info("stack suspended", zap.Int("services", len(scaled)))
+	return s.getStack(ctx, stackID)
+}
+
+// Resume restores the replica counts of the services scaled down by Suspend
+// and resumes the synchronization of the stack. Services that no longer exist
+// are skipped. If some services cannot be scaled, the stack stays suspended
+// with their replica counts and Resume can be retried.
+func (s *Service) Resume(ctx context.Context, stackID uuid.UUID) (*stacks.Stack, error) {
+	logger := s.logger.With(zap.String("stack_id", stackID.String()))
+
+	stack, err := s.getStack(ctx, stackID)
+	if err != nil {
+		return nil, err
+	}
+	if stack.Status != stacks.StatusInactive {
+		return nil, fmt.Errorf("%w: stack is not suspended", ErrConflict)
+	}
+
+	restored, scErr := s.reconciler.Scale(ctx, stack.Namespace(), stack.SuspendedReplicas)
+
+	if updErr := s.stacksSvc.Update(ctx, stackID, func(current *stacks.Stack) error {
+		for name := range current.SuspendedReplicas {
+			if slices.Contains(restored, name) {
+				delete(current.SuspendedReplicas, name)
+			}
+		}
+		if scErr == nil {
+			current.SuspendedReplicas = nil
+			current.Status = stacks.StatusActive
+		}
+		return nil
+	}); updErr != nil {
+		logger.Error("failed to update stack", zap.Error(updErr))
+		return nil, fmt.Errorf("failed to update stack: %w", updErr)
+	}
+
+	if scErr != nil {
+		logger.Error("failed to scale up stack", zap.Strings("restored", restored), zap.Error(scErr))
+		return nil, fmt.Errorf("failed to scale up stack: %w", scErr)
+	}
+
+	logger.Info("stack resumed", zap.Int("services", len(restored)))
+	return s.getStack(ctx, stackID)
+}
+
+// markSuspended sets the status of the stack to inactive unless it is being
+// deleted or deployed.
+func (s *Service) markSuspended(ctx context.Context, stackID uuid.UUID) error {
+	s.triggerMu.Lock()
+	defer s.triggerMu.Unlock()
+
+	if s.deleting[stackID] {
+		return fmt.Errorf("%w: stack is being deleted", ErrConflict)
+	}
+
+	if err := s.checkIdle(ctx, stackID); err != nil {
+		return err
+	}
+
+	if err := s.stacksSvc.Update(ctx, stackID, func(current *stacks.Stack) error {
+		current.Status = stacks.StatusInactive
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to update stack status: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) getStack(ctx context.Context, stackID uuid.UUID) (*stacks.Stack, error) {
+	stack, err := s.stacksSvc.Get(ctx, stackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stack: %w", err)
+	}
+
+	return stack, nil
+}
@@ -0,0 +1,163 @@
+package deployments_test
+
+import (
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/stacks"
+)
+
+func replicas(n uint64) *uint64 {
+	return &n
+}
+
+func newSuspendEnv(t *testing.T) (*env, *engine, *stacks.Stack) {
+	t.Helper()
+
+	eng := newEngine()
+	eng.addService("app", "web", replicas(3))
+	eng.addService("app", "worker", replicas(1))
+	eng.addService("app", "idle", replicas(0))
+	eng.addService("app", "agent", nil)
+
+	e := newEnvWithReconciler(t, eng.newReconciler(t))
+	return e, eng, e.createStack(t, "app")
+}
+
+func TestServiceSuspendResume(t *testing.T) {
+	t.Parallel()
+
+	e, eng, stack := newSuspendEnv(t)
+	initial := eng.replicas()
+
+	suspended, err := e.svc.Suspend(t.Context(), stack.ID)
+	if err != nil {
+		t.Fatalf("Suspend() error = %v", err)
+	}
+	if suspended.Status != stacks.StatusInactive {
+		t.Errorf("Suspend() status = %s, want %s", suspended.Status, stacks.StatusInactive)
+	}
+	// Services already scaled to zero and global services are left alone.
+	wantSuspended := map[string]uint64{"app_web": 3, "app_worker": 1}
+	if !maps.Equal(suspended.SuspendedReplicas, wantSuspended) {
+		t.Errorf("Suspend() suspended replicas = %v, want %v", suspended.SuspendedReplicas, wantSuspended)
+	}
+	wantScaled := map[string]uint64{"app_web": 0, "app_worker": 0, "app_idle": 0}
+	if got := eng.replicas(); !maps.Equal(got, wantScaled) {
+		t.Errorf("replicas after Suspend() = %v, want %v", got, wantScaled)
+	}
+
+	resumed, err := e.svc.Resume(t.Context(), stack.ID)
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if resumed.Status != stacks.StatusActive {
+		t.Errorf("Resume() status = %s, want %s", resumed.Status, stacks.StatusActive)
+	}
+	if resumed.SuspendedReplicas != nil {
+		t.Errorf("Resume() suspended replicas = %v, want nil", resumed.SuspendedReplicas)
+	}
+	if got := eng.replicas(); !maps.Equal(got, initial) {
+		t.Errorf("replicas after Resume() = %v, want %v", got, initial)
+	}
+
+	if _, err = e.svc.Resume(t.Context(), stack.ID); !errors.Is(err, deployments.ErrConflict) {
+		t.Errorf("Resume() of an active stack error = %v, want %v", err, deployments.ErrConflict)
+	}
+}
+
+func TestServiceSuspendTwice(t *testing.T) {
+	t.Parallel()
+
+	e, eng, stack := newSuspendEnv(t)
+
+	if _, err := e.svc.Suspend(t.Context(), stack.ID); err != nil {
+		t.Fatalf("Suspend() error = %v", err)
+	}
+
+	// A service scaled up while suspended is scaled down again and its new
+	// replica count is restored.
+	rec := eng.newReconciler(t)
+	if _, err := rec.Scale(t.Context(), "app", map[string]uint64{"app_web": 2}); err != nil {
+		t.Fatalf("Scale() error = %v", err)
+	}
+
+	suspended, err := e.svc.Suspend(t.Context(), stack.ID)
+	if err != nil {
+		t.Fatalf("Suspend() error = %v", err)
+	}
+	want := map[string]uint64{"app_web": 2, "app_worker": 1}
+	if !maps.Equal(suspended.SuspendedReplicas, want) {
+		t.Errorf("Suspend() suspended replicas = %v, want %v", suspended.SuspendedReplicas, want)
+	}
+	if got := eng.replicas()["app_web"]; got != 0 {
+		t.Errorf("app_web replicas = %d, want 0", got)
+	}
+}
+
+func TestServiceResumeRemovedService(t *testing.T) {
+	t.Parallel()
+
+	e, eng, stack := newSuspendEnv(t)
+
+	if _, err := e.svc.Suspend(t.Context(), stack.ID); err != nil {
+		t.Fatalf("Suspend() error = %v", err)
+	}
+	eng.RemoveService("app_worker")
+
+	resumed, err := e.svc.Resume(t.Context(), stack.ID)
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if resumed.Status != stacks.StatusActive || resumed.SuspendedReplicas != nil {
+		t.Errorf("Resume() = %s with %v, want %s with nil",
+			resumed.Status, resumed.SuspendedReplicas, stacks.StatusActive)
+	}
+	if got := eng.replicas()["app_web"]; got != 3 {
+		t.Errorf("app_web replicas = %d, want 3", got)
+	}
+}
+
+func TestServiceSuspendInProgress(t *testing.T) {
+	t.Parallel()
+
+	e, eng, stack := newSuspendEnv(t)
+	e.createDeployment(t, stack.ID, deployments.StatusRunning)
+
+	if _, err := e.svc.Suspend(t.Context(), stack.ID); !errors.Is(err, deployments.ErrConflict) {
+		t.Fatalf("Suspend() error = %v, want %v", err, deployments.ErrConflict)
+	}
+
+	current, err := e.stacks.Get(t.Context(), stack.ID)
+	if err != nil {
+		t.Fatalf("failed to get stack: %v", err)
+	}
+	if current.Status != stacks.StatusActive || current.SuspendedReplicas != nil {
+		t.Errorf("stack = %s with %v, want %s with nil",
+			current.Status, current.SuspendedReplicas, stacks.StatusActive)
+	}
+	if got := eng.replicas()["app_web"]; got != 3 {
+		t.Errorf("app_web replicas = %d, want 3", got)
+	}
+}
+
+func TestServiceDeploySuspended(t *testing.T) {
+	t.Parallel()
+
+	e, _, stack := newSuspendEnv(t)
+	succeeded := e.createDeployment(t, stack.ID, deployments.StatusSuccess)
+	e.createDeployment(t, stack.ID, deployments.StatusSuccess)
+
+	if _, err := e.svc.Suspend(t.Context(), stack.ID); err != nil {
+		t.Fatalf("Suspend() error = %v", err)
+	}
+
+	if _, err := e.svc.Rollback(t.Context(), stack.ID, &succeeded.ID); !errors.Is(err, deployments.ErrConflict) {
+		t.Errorf("Rollback() error = %v, want %v", err, deployments.ErrConflict)
+	}
+	if _, err := e.svc.Rollback(t.Context(), stack.ID, nil); !errors.Is(err, deployments.ErrConflict) {
+		t.Errorf("Rollback() to the previous deployment error = %v, want %v", err, deployments.ErrConflict)
+	}
+}
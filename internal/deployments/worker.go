@@ -0,0 +1,255 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/reconciler"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Start recovers the deployments left running by a previous run, enqueues
+// the pending ones and starts the workers.
+func (s *Service) Start(ctx context.Context) error {
+	reindexed, err := s.deployments.Reindex(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reindex deployments: %w", err)
+	}
+	if reindexed > 0 {
+		s.logger.Info("deployments reindexed", zap.Int("count", reindexed))
+	}
+
+	unfinished, err := s.deployments.ListByStatus(ctx, StatusPending, StatusRunning)
+	if err != nil {
+		return fmt.Errorf("failed to list unfinished deployments: %w", err)
+	}
+
+	// Deployments are queued oldest first to keep the order of each stack.
+	pending := 0
+	for _, d := range unfinished {
+		if d.Status == StatusRunning && !s.recoverInterrupted(ctx, &d) {
+			continue
+		}
+
+		s.queue.push(d.ID)
+		pending++
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	workers := max(s.config.Workers, 1)
+	for range workers {
+		s.workers.Add(1)
+		go func() {
+			defer s.workers.Done()
+			s.work(runCtx)
+		}()
+	}
+
+	s.logger.Info("deployment workers started",
+		zap.Int("workers", workers),
+		zap.Int("pending", pending),
+	)
+
+	return nil
+}
+
+// Stop stops the workers and waits for them to finish. Deployments that are
+// interrupted stay running until Start recovers them.
+func (s *Service) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.logger.Info("deployment workers stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to stop deployment workers: %w", ctx.Err())
+	}
+}
+
+func (s *Service) work(ctx context.Context) {
+	for {
+		id, ok := s.queue.pop(ctx)
+		if !ok {
+			return
+		}
+
+		s.dispatch(ctx, id)
+	}
+}
+
+// dispatch runs a queued deployment once its stack is free, followed by the
+// deployments of the stack queued in the meantime.
+func (s *Service) dispatch(ctx context.Context, id uuid.UUID) {
+	d, err := s.deployments.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("failed to get queued deployment", zap.String("deployment_id", id.String()), zap.Error(err))
+		return
+	}
+
+	if !s.locks.acquire(d.StackID, id) {
+		s.logger.Info("deployment waiting for stack",
+			zap.String("deployment_id", id.String()),
+			zap.String("stack_id", d.StackID.String()),
+		)
+		return
+	}
+
+	for ctx.Err() == nil {
+		s.process(ctx, id)
+
+		next, ok := s.locks.release(d.StackID)
+		if !ok {
+			return
+		}
+		id = next
+	}
+}
+
+// process runs a pending deployment to completion.
+func (s *Service) process(ctx context.Context, id uuid.UUID) {
+	logger := s.logger.With(zap.String("deployment_id", id.String()))
+
+	queued, err := s.deployments.GetByID(ctx, id)
+	if err != nil {
+		logger.Error("failed to get queued deployment", zap.Error(err))
+		return
+	}
+	if queued.Status != StatusPending {
+		logger.Info("skipping deployment", zap.String("status", string(queued.Status)))
+		return
+	}
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	defer s.track(id, cancel)()
+
+	// The previous deployment is only known once the deployments of the stack
+	// queued before this one have completed.
+	previous, err := s.deployments.GetLatestByStack(
+		ctx,
+		queued.StackID,
+		func(d *Deployment) bool { return d.Status == StatusSuccess },
+	)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		logger.Error("failed to get latest deployment", zap.Error(err))
+		return
+	}
+
+	var d *Deployment
+	now := time.Now()
+	if err = s.update(ctx, id, func(current *Deployment) error {
+		if current.Status != StatusPending {
+			return fmt.Errorf("%w: deployment is %s", ErrConflict, current.Status)
+		}
+
+		if previous != nil {
+			current.PreviousDeployment = &previous.ID
+		}
+		current.MarkRunning(now)
+		d = current
+		return nil
+	}); err != nil {
+		logger.Info("skipping deployment", zap.Error(err))
+		return
+	}
+
+	logger = logger.With(zap.String("stack_id", d.StackID.String()), zap.String("version", d.Version))
+	logger.Info("deployment started")
+	s.record(ctx, id, LogLevelInfo, "deployment started")
+
+	result, execErr := s.run(runCtx, d)
+
+	var c *cancellation
+	if errors.As(context.Cause(runCtx), &c) {
+		// The cancellation has already been recorded.
+		logger.Info("deployment execution stopped")
+		s.record(ctx, id, LogLevelWarn, "execution stopped")
+
+		if c.action == CancelActionRollback && result != nil {
+			s.record(ctx, id, LogLevelInfo, "rolling back started service updates")
+			if rbErr := s.reconciler.Rollback(ctx, result); rbErr != nil {
+				logger.Error("failed to roll back cancelled deployment", zap.Error(rbErr))
+				s.record(ctx, id, LogLevelError, "failed to roll back: %v", rbErr)
+			}
+		}
+		return
+	}
+
+	if execErr != nil && ctx.Err() != nil && errors.Is(execErr, context.Canceled) {
+		logger.Warn("deployment interrupted by shutdown")
+		s.record(ctx, id, LogLevelWarn, "deployment interrupted by shutdown, it will be recovered on the next start")
+		return
+	}
+
+	s.complete(ctx, d.ID, execErr, logger)
+
+	// Only deployments that changed the cluster need to be reverted.
+	if execErr != nil && result != nil {
+		s.autoRollback(ctx, d)
+	}
+}
+
+// complete records the outcome of a deployment unless it has been cancelled
+// in the meantime. Deployments whose service updates Swarm has rolled back are
+// rolled back rather than failed.
+func (s *Service) complete(ctx context.Context, id uuid.UUID, execErr error, logger *zap.Logger) {
+	rolledBack := errors.Is(execErr, reconciler.ErrRolledBack)
+
+	now := time.Now()
+	if updErr := s.update(ctx, id, func(d *Deployment) error {
+		if d.IsFinished() {
+			return fmt.Errorf("%w: deployment is already %s", ErrConflict, d.Status)
+		}
+
+		switch {
+		case rolledBack:
+			d.MarkRolledBack(now, execErr)
+		case execErr != nil:
+			d.MarkFailed(now, execErr)
+		default:
+			d.MarkDeployedAt(now)
+		}
+		return nil
+	}); updErr != nil {
+		logger.Error("failed to update deployment status", zap.Error(updErr))
+		return
+	}
+
+	switch {
+	case rolledBack:
+		logger.Warn("deployment rolled back by Swarm", zap.Error(execErr))
+		s.record(ctx, id, LogLevelError, "deployment rolled back by Swarm: %v", execErr)
+	case execErr != nil:
+		logger.Error("deployment failed", zap.Error(execErr))
+		s.record(ctx, id, LogLevelError, "deployment failed: %v", execErr)
+	default:
+		logger.Info("deployment succeeded")
+		s.record(ctx, id, LogLevelInfo, "deployment succeeded")
+	}
+}
+
+// run loads the stack of the deployment and executes it.
+func (s *Service) run(ctx context.Context, d *Deployment) (*reconciler.Result, error) {
+	stack, err := s.stacksSvc.Get(ctx, d.StackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stack: %w", err)
+	}
+
+	return s.execute(ctx, stack, d)
+}
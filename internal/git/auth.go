@@ -0,0 +1,98 @@
+package git
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/skeema/knownhosts"
+)
+
+const (
+	defaultSSHUser = "git"
+	defaultSSHPort = 22
+)
+
+// method returns the transport authentication for url.
+func (a Auth) method(url string) (transport.AuthMethod, error) {
+	if a.SSHKey != "" {
+		return a.sshMethod(url)
+	}
+
+	if a.Username == "" && a.Password == "" {
+		return nil, nil //nolint:nilnil // no authentication
+	}
+
+	return &githttp.BasicAuth{
+		Username: a.Username,
+		Password: a.Password,
+	}, nil
+}
+
+func (a Auth) sshMethod(url string) (transport.AuthMethod, error) {
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid repository URL: %w", ErrInvalidAuth, err)
+	}
+
+	user := endpoint.User
+	if user == "" {
+		user = a.Username
+	}
+	if user == "" {
+		user = defaultSSHUser
+	}
+
+	keys, err := gitssh.NewPublicKeys(user, []byte(a.SSHKey), a.SSHKeyPassphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse SSH key: %w", ErrInvalidAuth, err)
+	}
+
+	if a.KnownHosts == "" {
+		return keys, nil
+	}
+
+	db, err := newKnownHostsDB(a.KnownHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	port := endpoint.Port
+	if port == 0 {
+		port = defaultSSHPort
+	}
+
+	keys.HostKeyCallback = db.HostKeyCallback()
+	keys.HostKeyAlgorithms = db.HostKeyAlgorithms(net.JoinHostPort(endpoint.Host, strconv.Itoa(port)))
+
+	return keys, nil
+}
+
+// newKnownHostsDB parses known_hosts entries. The parser only reads files, so
+// the entries are written to a short-lived temporary file.
+func newKnownHostsDB(knownHosts string) (*knownhosts.HostKeyDB, error) {
+	file, err := os.CreateTemp("", "known_hosts-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create known_hosts file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, wrErr := file.WriteString(knownHosts); wrErr != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to write known_hosts file: %w", wrErr)
+	}
+	if clErr := file.Close(); clErr != nil {
+		return nil, fmt.Errorf("failed to write known_hosts file: %w", clErr)
+	}
+
+	db, err := gitssh.NewKnownHostsDb(file.Name())
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse known_hosts: %w", ErrInvalidAuth, err)
+	}
+
+	return db, nil
+}
@@ -0,0 +1,263 @@
+package git
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"go.uber.org/zap"
+)
+
+const remoteName = "origin"
+
+// Client fetches stack repositories into a local cache of bare clones.
+type Client struct {
+	config Config
+
+	mux   sync.Mutex
+	locks map[string]*sync.Mutex
+
+	logger *zap.Logger
+}
+
+// NewClient creates a new git client.
+func NewClient(config Config, logger *zap.Logger) *Client {
+	return &Client{
+		config: config,
+
+		mux:   sync.Mutex{},
+		locks: make(map[string]*sync.Mutex),
+
+		logger: logger,
+	}
+}
+
+// Resolve fetches the repository and resolves ref (branch, tag or commit SHA)
+// to a commit.
+func (c *Client) Resolve(ctx context.Context, src Source, ref string) (Commit, error) {
+	c.logger.Debug("Resolving reference", zap.String("url", src.URL), zap.String("ref", ref))
+
+	if err := validateRef(ref); err != nil {
+		return Commit{}, err
+	}
+
+	unlock := c.lock(src.URL)
+	defer unlock()
+
+	repo, err := c.fetch(ctx, src)
+	if err != nil {
+		return Commit{}, err
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return Commit{}, fmt.Errorf("%w: %q: %w", ErrRefNotFound, ref, err)
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return Commit{}, fmt.Errorf("failed to read commit %s: %w", hash, err)
+	}
+
+	c.logger.Debug("Reference resolved",
+		zap.String("url", src.URL),
+		zap.String("ref", ref),
+		zap.String("sha", commit.Hash.String()),
+	)
+
+	return newCommit(ref, commit), nil
+}
+
+// Head returns the SHA of the head commit of branch without fetching the
+// repository.
+func (c *Client) Head(ctx context.Context, src Source, branch string) (string, error) {
+	auth, err := src.Auth.method(src.URL)
+	if err != nil {
+		return "", err
+	}
+
+	remote := gogit.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: remoteName,
+		URLs: []string{src.URL},
+	})
+
+	refs, err := remote.ListContext(ctx, &gogit.ListOptions{Auth: auth})
+	if err != nil {
+		c.logger.Error("Failed to list remote references", zap.String("url", src.URL), zap.Error(err))
+		return "", fmt.Errorf("failed to list remote references: %w", err)
+	}
+
+	name := plumbing.NewBranchReferenceName(branch)
+	for _, ref := range refs {
+		if ref.Name() == name {
+			return ref.Hash().String(), nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: branch %q", ErrRefNotFound, branch)
+}
+
+// ReadFile returns the contents of the file at path in the given commit.
+//
+// The commit is looked up in the local cache first; the repository is
+// fetched only if the commit is not available locally.
+func (c *Client) ReadFile(ctx context.Context, src Source, sha, path string) ([]byte, error) {
+	unlock := c.lock(src.URL)
+	defer unlock()
+
+	hash := plumbing.NewHash(sha)
+
+	repo, err := gogit.PlainOpen(c.repoDir(src.URL))
+	if err == nil {
+		_, err = repo.CommitObject(hash)
+	}
+	if err != nil {
+		if repo, err = c.fetch(ctx, src); err != nil {
+			return nil, err
+		}
+	}
+
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrRefNotFound, sha, err)
+	}
+
+	file, err := commit.File(strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "/"))
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, fmt.Errorf("%w: %s@%s", ErrFileNotFound, path, sha)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s@%s: %w", path, sha, err)
+	}
+
+	contents, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s@%s: %w", path, sha, err)
+	}
+
+	return []byte(contents), nil
+}
+
+// fetch opens or initializes the cached bare clone of src and mirrors all
+// branches and tags of the remote into it.
+func (c *Client) fetch(ctx context.Context, src Source) (*gogit.Repository, error) {
+	dir := c.repoDir(src.URL)
+
+	repo, err := gogit.PlainOpen(dir)
+	if errors.Is(err, gogit.ErrRepositoryNotExists) {
+		if mkErr := os.MkdirAll(dir, 0o750); mkErr != nil {
+			return nil, fmt.Errorf("failed to create repository cache: %w", mkErr)
+		}
+		repo, err = gogit.PlainInit(dir, true)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository cache: %w", err)
+	}
+
+	if remoteErr := ensureRemote(repo, src.URL); remoteErr != nil {
+		return nil, remoteErr
+	}
+
+	auth, err := src.Auth.method(src.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Debug("Fetching repository", zap.String("url", src.URL))
+
+	err = repo.FetchContext(ctx, &gogit.FetchOptions{
+		RemoteName: remoteName,
+		RefSpecs: []config.RefSpec{
+			"+refs/heads/*:refs/heads/*",
+			"+refs/tags/*:refs/tags/*",
+		},
+		Auth:  auth,
+		Tags:  gogit.NoTags,
+		Force: true,
+		Prune: true,
+	})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		c.logger.Error("Failed to fetch repository", zap.String("url", src.URL), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch repository: %w", err)
+	}
+
+	return repo, nil
+}
+
+// lock serializes access to the cached clone of url.
+func (c *Client) lock(url string) func() {
+	c.mux.Lock()
+	l, ok := c.locks[url]
+	if !ok {
+		l = &sync.Mutex{}
+		c.locks[url] = l
+	}
+	c.mux.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+func (c *Client) repoDir(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.config.CacheDir, hex.EncodeToString(sum[:]))
+}
+
+// validateRef checks that ref is a well-formed branch, tag, full reference
+// name or commit SHA.
+func validateRef(ref string) error {
+	name := plumbing.ReferenceName(ref)
+	if !strings.HasPrefix(ref, "refs/") {
+		name = plumbing.NewBranchReferenceName(ref)
+	}
+
+	if err := name.Validate(); err != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidRef, ref)
+	}
+
+	return nil
+}
+
+func ensureRemote(repo *gogit.Repository, url string) error {
+	remote, err := repo.Remote(remoteName)
+	if err == nil {
+		if urls := remote.Config().URLs; len(urls) == 1 && urls[0] == url {
+			return nil
+		}
+		if delErr := repo.DeleteRemote(remoteName); delErr != nil {
+			return fmt.Errorf("failed to reset remote: %w", delErr)
+		}
+	} else if !errors.Is(err, gogit.ErrRemoteNotFound) {
+		return fmt.Errorf("failed to get remote: %w", err)
+	}
+
+	if _, crErr := repo.CreateRemote(&config.RemoteConfig{
+		Name: remoteName,
+		URLs: []string{url},
+	}); crErr != nil {
+		return fmt.Errorf("failed to create remote: %w", crErr)
+	}
+
+	return nil
+}
+
+func newCommit(ref string, commit *object.Commit) Commit {
+	return Commit{
+		SHA:     commit.Hash.String(),
+		Ref:     ref,
+		Message: strings.TrimSpace(commit.Message),
+		Author:  commit.Author.Name,
+		When:    commit.Author.When,
+	}
+}
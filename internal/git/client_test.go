@@ -0,0 +1,182 @@
+package git_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/git"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.uber.org/zap"
+)
+
+// remote is a bare repository with a "main" branch of two commits and a
+// "v1" tag on the first one.
+type remote struct {
+	url string
+
+	first  string
+	second string
+}
+
+func newRemote(t *testing.T) remote {
+	t.Helper()
+
+	url := filepath.Join(t.TempDir(), "remote.git")
+	if _, err := gogit.PlainInit(url, true); err != nil {
+		t.Fatalf("failed to init bare repository: %v", err)
+	}
+
+	workDir := t.TempDir()
+	work, err := gogit.PlainInitWithOptions(workDir, &gogit.PlainInitOptions{
+		InitOptions: gogit.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("main")},
+		Bare:        false,
+	})
+	if err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+
+	first := commit(t, work, workDir, "docker-compose.yml", "services: {}\n", "initial")
+	if _, tagErr := work.CreateTag("v1", first, nil); tagErr != nil {
+		t.Fatalf("failed to tag: %v", tagErr)
+	}
+	second := commit(t, work, workDir, "docker-compose.yml", "services:\n  web: {}\n", "add web")
+
+	if _, remoteErr := work.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{url}}); remoteErr != nil {
+		t.Fatalf("failed to create remote: %v", remoteErr)
+	}
+	if pushErr := work.Push(&gogit.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{"refs/heads/*:refs/heads/*", "refs/tags/*:refs/tags/*"},
+	}); pushErr != nil {
+		t.Fatalf("failed to push: %v", pushErr)
+	}
+
+	return remote{url: url, first: first.String(), second: second.String()}
+}
+
+func commit(t *testing.T, repo *gogit.Repository, dir, name, content, message string) plumbing.Hash {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	if _, addErr := wt.Add(name); addErr != nil {
+		t.Fatalf("failed to add file: %v", addErr)
+	}
+
+	hash, err := wt.Commit(message, &gogit.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	return hash
+}
+
+func newClient(t *testing.T) *git.Client {
+	t.Helper()
+
+	return git.NewClient(git.Config{CacheDir: t.TempDir()}, zap.NewNop())
+}
+
+func TestClientResolve(t *testing.T) {
+	t.Parallel()
+
+	r := newRemote(t)
+	src := git.Source{URL: r.url, Auth: git.Auth{}}
+
+	tests := []struct {
+		name    string
+		ref     string
+		wantSHA string
+		wantErr error
+	}{
+		{name: "branch", ref: "main", wantSHA: r.second},
+		{name: "full branch reference", ref: "refs/heads/main", wantSHA: r.second},
+		{name: "tag", ref: "v1", wantSHA: r.first},
+		{name: "commit", ref: r.first, wantSHA: r.first},
+		{name: "unknown branch", ref: "develop", wantErr: git.ErrRefNotFound},
+		{name: "invalid", ref: "main..dev", wantErr: git.ErrInvalidRef},
+	}
+
+	client := newClient(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := client.Resolve(t.Context(), src, tt.ref)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+
+			if got.SHA != tt.wantSHA {
+				t.Errorf("Resolve() SHA = %s, want %s", got.SHA, tt.wantSHA)
+			}
+			if got.Ref != tt.ref {
+				t.Errorf("Resolve() Ref = %s, want %s", got.Ref, tt.ref)
+			}
+		})
+	}
+}
+
+func TestClientReadFile(t *testing.T) {
+	t.Parallel()
+
+	r := newRemote(t)
+	src := git.Source{URL: r.url, Auth: git.Auth{}}
+
+	tests := []struct {
+		name    string
+		sha     string
+		path    string
+		want    string
+		wantErr error
+	}{
+		{name: "head", sha: r.second, path: "docker-compose.yml", want: "services:\n  web: {}\n"},
+		{name: "earlier commit", sha: r.first, path: "docker-compose.yml", want: "services: {}\n"},
+		{name: "absolute path", sha: r.first, path: "/docker-compose.yml", want: "services: {}\n"},
+		{name: "missing file", sha: r.second, path: "compose.yml", wantErr: git.ErrFileNotFound},
+		{name: "unknown commit", sha: "0123456789abcdef0123456789abcdef01234567", wantErr: git.ErrRefNotFound},
+	}
+
+	// ReadFile fetches the repository itself when the commit is not cached.
+	client := newClient(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := client.ReadFile(t.Context(), src, tt.sha, tt.path)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ReadFile() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("ReadFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
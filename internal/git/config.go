@@ -0,0 +1,8 @@
+package git
+
+// Config holds the configuration for the git client.
+type Config struct {
+	// CacheDir is the directory where bare clones of stack repositories
+	// are kept between fetches.
+	CacheDir string
+}
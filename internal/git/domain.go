@@ -0,0 +1,31 @@
+package git
+
+import "time"
+
+// Auth holds credentials used to access a remote repository.
+//
+// Username and Password are used for HTTP(S) remotes, SSHKey is used for SSH
+// remotes.
+type Auth struct {
+	Username string
+	Password string
+
+	SSHKey           string // PEM encoded private key
+	SSHKeyPassphrase string // Passphrase of an encrypted SSHKey
+	KnownHosts       string // known_hosts entries used to verify the server; system defaults if empty
+}
+
+// Source describes a remote repository.
+type Source struct {
+	URL  string
+	Auth Auth
+}
+
+// Commit describes a resolved commit.
+type Commit struct {
+	SHA     string    // Full commit SHA
+	Ref     string    // Reference the commit was resolved from
+	Message string    // Commit message
+	Author  string    // Commit author
+	When    time.Time // Commit author time
+}
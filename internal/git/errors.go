@@ -0,0 +1,10 @@
+package git
+
+import "errors"
+
+var (
+	ErrInvalidRef   = errors.New("invalid git reference")
+	ErrRefNotFound  = errors.New("git reference not found")
+	ErrFileNotFound = errors.New("file not found in repository")
+	ErrInvalidAuth  = errors.New("invalid git authentication")
+)
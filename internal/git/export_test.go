@@ -0,0 +1,8 @@
+package git
+
+import "github.com/go-git/go-git/v5/plumbing/transport"
+
+// Method exposes method to the tests.
+func (a Auth) Method(url string) (transport.AuthMethod, error) {
+	return a.method(url)
+}
@@ -0,0 +1,14 @@
+package poller
+
+import "time"
+
+// Config holds the configuration for the git poller.
+type Config struct {
+	// Interval between polls of the stack repositories. Polling is disabled
+	// if zero.
+	Interval time.Duration
+
+	// Jitter is the maximum random delay added to each interval to spread the
+	// load on the git servers.
+	Jitter time.Duration
+}
@@ -0,0 +1,8 @@
+package poller
+
+import "context"
+
+// PollAll exposes pollAll to the tests.
+func (p *Poller) PollAll(ctx context.Context) {
+	p.pollAll(ctx)
+}
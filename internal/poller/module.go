@@ -0,0 +1,27 @@
+package poller
+
+import (
+	"context"
+
+	"github.com/go-core-fx/logger"
+	"go.uber.org/fx"
+)
+
+func Module() fx.Option {
+	return fx.Module(
+		"poller",
+		logger.WithNamedLogger("poller"),
+		fx.Provide(New),
+		fx.Invoke(func(lc fx.Lifecycle, p *Poller) {
+			lc.Append(fx.Hook{
+				OnStart: func(_ context.Context) error {
+					p.Start()
+					return nil
+				},
+				OnStop: func(ctx context.Context) error {
+					return p.Stop(ctx)
+				},
+			})
+		}),
+	)
+}
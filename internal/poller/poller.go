@@ -0,0 +1,190 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/git"
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	"go.uber.org/zap"
+)
+
+// Poller periodically checks the branches of active stacks and triggers a
+// deployment when the head commit differs from the last successful
+// deployment. A rollback to an earlier commit is therefore superseded by the
+// head on the next poll unless the branch is reset as well.
+type Poller struct {
+	config Config
+
+	stacksSvc      *stacks.Service
+	deploymentsSvc *deployments.Service
+	git            *git.Client
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	logger *zap.Logger
+}
+
+func New(
+	config Config,
+	stacksSvc *stacks.Service,
+	deploymentsSvc *deployments.Service,
+	git *git.Client,
+	logger *zap.Logger,
+) *Poller {
+	return &Poller{
+		config: config,
+
+		stacksSvc:      stacksSvc,
+		deploymentsSvc: deploymentsSvc,
+		git:            git,
+
+		cancel: nil,
+		done:   nil,
+
+		logger: logger,
+	}
+}
+
+// Start starts polling in the background.
+func (p *Poller) Start() {
+	if p.config.Interval <= 0 {
+		p.logger.Info("poller disabled")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go p.run(ctx)
+
+	p.logger.Info("poller started", zap.Duration("interval", p.config.Interval))
+}
+
+// Stop stops polling and waits for the current poll to finish.
+func (p *Poller) Stop(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+
+	p.cancel()
+
+	select {
+	case <-p.done:
+		p.logger.Info("poller stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to stop poller: %w", ctx.Err())
+	}
+}
+
+func (p *Poller) run(ctx context.Context) {
+	defer close(p.done)
+
+	timer := time.NewTimer(p.delay())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			p.pollAll(ctx)
+			timer.Reset(p.delay())
+		}
+	}
+}
+
+func (p *Poller) delay() time.Duration {
+	if p.config.Jitter <= 0 {
+		return p.config.Interval
+	}
+
+	return p.config.Interval + rand.N(p.config.Jitter) //nolint:gosec // jitter does not need a secure source
+}
+
+func (p *Poller) pollAll(ctx context.Context) {
+	items, err := p.stacksSvc.List(ctx)
+	if err != nil {
+		p.logger.Error("failed to list stacks", zap.Error(err))
+		return
+	}
+
+	for _, stack := range items {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if stack.Status == stacks.StatusInactive {
+			continue
+		}
+
+		if pollErr := p.poll(ctx, &stack); pollErr != nil {
+			p.logger.Error("failed to poll stack", zap.String("stack_id", stack.ID.String()), zap.Error(pollErr))
+		}
+	}
+}
+
+func (p *Poller) poll(ctx context.Context, stack *stacks.Stack) error {
+	logger := p.logger.With(zap.String("stack_id", stack.ID.String()))
+
+	head, err := p.git.Head(ctx, stack.GitSource(), stack.GitBranch)
+	if err != nil {
+		return fmt.Errorf("failed to get branch head: %w", err)
+	}
+
+	now := time.Now()
+	if updErr := p.stacksSvc.Update(ctx, stack.ID, func(s *stacks.Stack) error {
+		s.LastSync = &now
+		return nil
+	}); updErr != nil {
+		return fmt.Errorf("failed to update last sync: %w", updErr)
+	}
+
+	deployed, err := p.deploymentsSvc.GetLatestByStack(
+		ctx,
+		stack.ID,
+		func(d *deployments.Deployment) bool { return d.Status == deployments.StatusSuccess },
+	)
+	if err != nil && !errors.Is(err, deployments.ErrNotFound) {
+		return fmt.Errorf("failed to get latest successful deployment: %w", err)
+	}
+	if deployed != nil && deployed.Version == head {
+		logger.Debug("head commit already deployed", zap.String("version", head))
+		return nil
+	}
+
+	// A head whose latest attempt is still in progress, failed or was
+	// cancelled is not retried on every poll. The next push or a manual
+	// deployment picks it up.
+	latest, err := p.deploymentsSvc.GetLatestByStack(ctx, stack.ID, nil)
+	if err != nil && !errors.Is(err, deployments.ErrNotFound) {
+		return fmt.Errorf("failed to get latest deployment: %w", err)
+	}
+	if latest != nil && latest.Version == head {
+		logger.Debug(
+			"head commit already attempted",
+			zap.String("version", head),
+			zap.String("status", string(latest.Status)),
+		)
+		return nil
+	}
+
+	logger.Info("new commit detected", zap.String("version", head))
+
+	if _, trErr := p.deploymentsSvc.Trigger(ctx, deployments.DeploymentRequest{
+		StackID:   stack.ID,
+		Ref:       "",
+		Variables: nil,
+	}); trErr != nil {
+		return fmt.Errorf("failed to trigger deployment: %w", trErr)
+	}
+
+	return nil
+}
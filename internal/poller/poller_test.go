@@ -0,0 +1,293 @@
+package poller_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/git"
+	"github.com/apiarycd/apiarycd/internal/poller"
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	"github.com/dgraph-io/badger/v4"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// newRemote returns the URL of a bare repository with a "main" branch of one
+// commit and the SHA of that commit.
+func newRemote(t *testing.T) (string, string) {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "remote.git")
+	if _, err := gogit.PlainInit(dir, true); err != nil {
+		t.Fatalf("failed to init bare repository: %v", err)
+	}
+
+	workDir := t.TempDir()
+	work, err := gogit.PlainInitWithOptions(workDir, &gogit.PlainInitOptions{
+		InitOptions: gogit.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("main")},
+		Bare:        false,
+	})
+	if err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+	writeErr := os.WriteFile(filepath.Join(workDir, "docker-compose.yml"), []byte("services: {}\n"), 0o600)
+	if writeErr != nil {
+		t.Fatalf("failed to write file: %v", writeErr)
+	}
+
+	wt, err := work.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	if _, addErr := wt.Add("docker-compose.yml"); addErr != nil {
+		t.Fatalf("failed to add file: %v", addErr)
+	}
+	head, err := wt.Commit("initial", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	if _, remoteErr := work.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{dir}}); remoteErr != nil {
+		t.Fatalf("failed to create remote: %v", remoteErr)
+	}
+	if pushErr := work.Push(&gogit.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{"refs/heads/*:refs/heads/*"},
+	}); pushErr != nil {
+		t.Fatalf("failed to push: %v", pushErr)
+	}
+
+	return "file://" + dir, head.String()
+}
+
+// env is a poller of stacks stored in an in-memory database. Deployments are
+// queued but never executed.
+type env struct {
+	poller *poller.Poller
+
+	stacks      *stacks.Service
+	deployments *deployments.Repository
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	logger := zap.NewNop()
+	gitClient := git.NewClient(git.Config{CacheDir: t.TempDir()}, logger)
+	stacksSvc := stacks.NewService(stacks.NewRepository(db), logger)
+	repo := deployments.NewRepository(db)
+	deploymentsSvc := deployments.NewService(
+		deployments.Config{},
+		repo,
+		deployments.NewLogRepository(db),
+		deployments.NewManifestRepository(db),
+		stacksSvc,
+		gitClient,
+		nil,
+		logger,
+	)
+
+	return &env{
+		poller:      poller.New(poller.Config{}, stacksSvc, deploymentsSvc, gitClient, logger),
+		stacks:      stacksSvc,
+		deployments: repo,
+	}
+}
+
+func (e *env) createStack(t *testing.T, name, url string) *stacks.Stack {
+	t.Helper()
+
+	stack, err := e.stacks.Create(t.Context(), stacks.StackDraft{
+		Name:        name,
+		GitURL:      url,
+		GitBranch:   "main",
+		ComposePath: "docker-compose.yml",
+	})
+	if err != nil {
+		t.Fatalf("failed to create stack: %v", err)
+	}
+
+	return stack
+}
+
+func (e *env) getStack(t *testing.T, id uuid.UUID) *stacks.Stack {
+	t.Helper()
+
+	stack, err := e.stacks.Get(t.Context(), id)
+	if err != nil {
+		t.Fatalf("failed to get stack: %v", err)
+	}
+
+	return stack
+}
+
+func (e *env) history(t *testing.T, stackID uuid.UUID) []deployments.Deployment {
+	t.Helper()
+
+	history, err := e.deployments.ListByStack(t.Context(), stackID)
+	if err != nil {
+		t.Fatalf("failed to list deployments: %v", err)
+	}
+
+	return history
+}
+
+func TestPollerTrigger(t *testing.T) {
+	t.Parallel()
+
+	const other = "0123456789abcdef0123456789abcdef01234567"
+
+	type attempt struct {
+		head   bool // Deployment of the head commit, of another one otherwise
+		status deployments.Status
+	}
+
+	tests := []struct {
+		name    string
+		history []attempt
+		want    bool
+	}{
+		{name: "never deployed", history: nil, want: true},
+		{
+			name:    "head deployed",
+			history: []attempt{{head: true, status: deployments.StatusSuccess}},
+			want:    false,
+		},
+		{
+			name:    "new commit",
+			history: []attempt{{head: false, status: deployments.StatusSuccess}},
+			want:    true,
+		},
+		{
+			name: "branch reset to a commit deployed before",
+			history: []attempt{
+				{head: true, status: deployments.StatusSuccess},
+				{head: false, status: deployments.StatusSuccess},
+			},
+			want: true,
+		},
+		{
+			name: "head failed",
+			history: []attempt{
+				{head: false, status: deployments.StatusSuccess},
+				{head: true, status: deployments.StatusFailed},
+			},
+			want: false,
+		},
+		{
+			name: "head in progress",
+			history: []attempt{
+				{head: false, status: deployments.StatusSuccess},
+				{head: true, status: deployments.StatusRunning},
+			},
+			want: false,
+		},
+		{
+			name: "head failed before another commit failed",
+			history: []attempt{
+				{head: true, status: deployments.StatusFailed},
+				{head: false, status: deployments.StatusFailed},
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			url, head := newRemote(t)
+			e := newEnv(t)
+			stack := e.createStack(t, "app", url)
+
+			for _, a := range tt.history {
+				version := other
+				if a.head {
+					version = head
+				}
+				if _, err := e.deployments.Create(t.Context(), &deployments.DeploymentDraft{
+					StackID: stack.ID,
+					Version: version,
+					GitRef:  "main",
+					Status:  a.status,
+				}); err != nil {
+					t.Fatalf("failed to create deployment: %v", err)
+				}
+			}
+
+			e.poller.PollAll(t.Context())
+
+			history := e.history(t, stack.ID)
+			triggered := len(history) > len(tt.history)
+			if triggered != tt.want {
+				t.Fatalf("deployment triggered = %t, want %t", triggered, tt.want)
+			}
+			if triggered {
+				latest := history[len(history)-1]
+				if latest.Version != head || latest.Status != deployments.StatusPending {
+					t.Errorf("triggered %s deployment of %s, want %s deployment of %s",
+						latest.Status, latest.Version, deployments.StatusPending, head)
+				}
+			}
+		})
+	}
+}
+
+func TestPollerLastSync(t *testing.T) {
+	t.Parallel()
+
+	url, _ := newRemote(t)
+	e := newEnv(t)
+	active := e.createStack(t, "active", url)
+	inactive := e.createStack(t, "inactive", url)
+	if err := e.stacks.Update(t.Context(), inactive.ID, func(s *stacks.Stack) error {
+		s.Status = stacks.StatusInactive
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to update stack: %v", err)
+	}
+
+	before := time.Now()
+	e.poller.PollAll(t.Context())
+
+	got := e.getStack(t, active.ID)
+	if got.LastSync == nil || got.LastSync.Before(before) {
+		t.Errorf("LastSync = %v, want after %v", got.LastSync, before)
+	}
+	if len(e.history(t, active.ID)) != 1 {
+		t.Error("no deployment of the active stack triggered")
+	}
+
+	// Inactive stacks are neither checked nor deployed.
+	if got = e.getStack(t, inactive.ID); got.LastSync != nil {
+		t.Errorf("LastSync of an inactive stack = %v, want nil", got.LastSync)
+	}
+	if history := e.history(t, inactive.ID); len(history) != 0 {
+		t.Errorf("%d deployments of an inactive stack triggered, want 0", len(history))
+	}
+
+	// A second poll only updates LastSync.
+	previous := *e.getStack(t, active.ID).LastSync
+	e.poller.PollAll(t.Context())
+	if got = e.getStack(t, active.ID); !got.LastSync.After(previous) {
+		t.Errorf("LastSync = %v, want after %v", got.LastSync, previous)
+	}
+	if history := e.history(t, active.ID); len(history) != 1 {
+		t.Errorf("%d deployments after a second poll, want 1", len(history))
+	}
+}
@@ -0,0 +1,57 @@
+package reconciler
+
+import "time"
+
+type Kind string
+
+const (
+	KindService Kind = "service"
+	KindNetwork Kind = "network"
+	KindConfig  Kind = "config"
+	KindSecret  Kind = "secret"
+)
+
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionRemove Action = "remove"
+)
+
+// Change is a single mutation applied to the cluster.
+type Change struct {
+	Kind   Kind
+	Action Action
+	Name   string
+	ID     string
+}
+
+// Result describes the outcome of applying a stack.
+type Result struct {
+	StartedAt time.Time
+	Changes   []Change
+	Services  map[string]string // Service name -> ID of every desired service
+}
+
+// FieldChange is a field of a service spec that differs between the cluster
+// and the desired state.
+type FieldChange struct {
+	Path    string // Dot separated path of the field in the service spec
+	Current any    // Value in the cluster, nil if unset
+	Desired any    // Desired value, nil if unset
+}
+
+// ServiceChange is a mutation Apply would make to a service.
+type ServiceChange struct {
+	Action Action
+	Name   string
+	ID     string        // Empty for services to create
+	Fields []FieldChange // Changed fields of services to update
+}
+
+// Plan describes the changes Apply would make to the services of a stack.
+type Plan struct {
+	Namespace string
+	Services  []ServiceChange
+}
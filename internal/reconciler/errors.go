@@ -0,0 +1,14 @@
+package reconciler
+
+import "errors"
+
+var (
+	ErrExternalNotFound = errors.New("external resource not found")
+	ErrRolloutTimeout   = errors.New("rollout timed out")
+	ErrUpdatePaused     = errors.New("update paused")
+	ErrRolledBack       = errors.New("update rolled back")
+	ErrRollbackPaused   = errors.New("rollback paused")
+	ErrTasksFailed      = errors.New("tasks failed")
+	ErrRemovalTimeout   = errors.New("removal timed out")
+	ErrNotScalable      = errors.New("service cannot be scaled")
+)
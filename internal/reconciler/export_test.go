@@ -0,0 +1,20 @@
+package reconciler
+
+import (
+	"time"
+
+	swarmtypes "github.com/moby/moby/api/types/swarm"
+)
+
+// DiffSpecs exposes diffSpecs to the tests.
+func DiffSpecs(current, desired swarmtypes.ServiceSpec) ([]FieldChange, error) {
+	return diffSpecs(current, desired)
+}
+
+// UpdateProgress exposes updateProgress to the tests along with the progress
+// message it sets.
+func UpdateProgress(service swarmtypes.Service, since time.Time) (bool, bool, string, error) {
+	var p progress
+	updating, rollsBack, err := updateProgress(service, since, &p)
+	return updating, rollsBack, p.message, err
+}
@@ -0,0 +1,14 @@
+package reconciler
+
+import (
+	"github.com/go-core-fx/logger"
+	"go.uber.org/fx"
+)
+
+func Module() fx.Option {
+	return fx.Module(
+		"reconciler",
+		logger.WithNamedLogger("reconciler"),
+		fx.Provide(New),
+	)
+}
@@ -0,0 +1,201 @@
+package reconciler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/apiarycd/apiarycd/internal/compose"
+	swarmtypes "github.com/moby/moby/api/types/swarm"
+	"github.com/moby/moby/client"
+	"go.uber.org/zap"
+)
+
+// Plan compares the services of stack with the ones running in the cluster
+// and returns the changes Apply would make, without mutating anything.
+func (r *Reconciler) Plan(ctx context.Context, stack *compose.Stack) (*Plan, error) {
+	logger := r.logger.With(zap.String("namespace", stack.Namespace))
+	logger.Debug("planning stack", zap.Int("services", len(stack.Services)))
+
+	// Configs and secrets that do not exist yet resolve to an empty ID, which
+	// marks the services referencing them as changed, like Apply would.
+	configIDs, err := r.configIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	secretIDs, err := r.secretIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := r.swarm.ListServices(ctx, namespaceFilter(stack.Namespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	existing := make(map[string]swarmtypes.Service, len(live))
+	for _, s := range live {
+		existing[s.Spec.Name] = s
+	}
+
+	plan := &Plan{
+		Namespace: stack.Namespace,
+		Services:  []ServiceChange{},
+	}
+
+	for _, desired := range stack.Services {
+		spec, specErr := resolveSpec(desired, configIDs, secretIDs)
+		if specErr != nil {
+			return nil, specErr
+		}
+
+		current, ok := existing[spec.Name]
+		delete(existing, spec.Name)
+
+		switch {
+		case !ok:
+			plan.add(ActionCreate, spec.Name, "", nil)
+		case current.Spec.Labels[LabelSpecHash] != spec.Labels[LabelSpecHash]:
+			fields, diffErr := diffSpecs(current.Spec, spec)
+			if diffErr != nil {
+				return nil, fmt.Errorf("failed to compare service %q: %w", spec.Name, diffErr)
+			}
+
+			plan.add(ActionUpdate, spec.Name, current.ID, fields)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(existing)) {
+		plan.add(ActionRemove, name, existing[name].ID, nil)
+	}
+
+	logger.Debug("stack planned", zap.Int("changes", len(plan.Services)))
+	return plan, nil
+}
+
+func (r *Reconciler) configIDs(ctx context.Context) (map[string]string, error) {
+	configs, err := r.swarm.ListConfigs(ctx, make(client.Filters))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configs: %w", err)
+	}
+
+	ids := make(map[string]string, len(configs))
+	for _, c := range configs {
+		ids[c.Spec.Name] = c.ID
+	}
+
+	return ids, nil
+}
+
+func (r *Reconciler) secretIDs(ctx context.Context) (map[string]string, error) {
+	secrets, err := r.swarm.ListSecrets(ctx, make(client.Filters))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	ids := make(map[string]string, len(secrets))
+	for _, s := range secrets {
+		ids[s.Spec.Name] = s.ID
+	}
+
+	return ids, nil
+}
+
+func (p *Plan) add(action Action, name, id string, fields []FieldChange) {
+	p.Services = append(p.Services, ServiceChange{
+		Action: action,
+		Name:   name,
+		ID:     id,
+		Fields: fields,
+	})
+}
+
+// diffSpecs returns the fields of desired that differ from current.
+//
+// Swarm fills in defaults for fields the desired spec leaves unset, so fields
+// that are only set in current are ignored, except for labels. Lists are
+// compared as a whole.
+func diffSpecs(current, desired swarmtypes.ServiceSpec) ([]FieldChange, error) {
+	currentFields, err := flatten(current)
+	if err != nil {
+		return nil, err
+	}
+	desiredFields, err := flatten(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	hashPath := "Labels." + LabelSpecHash
+
+	var changes []FieldChange
+	for _, path := range slices.Sorted(maps.Keys(desiredFields)) {
+		if path == hashPath {
+			continue
+		}
+
+		if value, ok := currentFields[path]; !ok || !reflect.DeepEqual(value, desiredFields[path]) {
+			changes = append(changes, FieldChange{
+				Path:    path,
+				Current: value,
+				Desired: desiredFields[path],
+			})
+		}
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(currentFields)) {
+		if _, ok := desiredFields[path]; ok || path == hashPath || !isLabel(path) {
+			continue
+		}
+
+		changes = append(changes, FieldChange{
+			Path:    path,
+			Current: currentFields[path],
+			Desired: nil,
+		})
+	}
+
+	return changes, nil
+}
+
+// flatten returns the leaves of the JSON encoding of v by path. Empty
+// objects are omitted.
+func flatten(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal spec: %w", err)
+	}
+
+	var tree any
+	if unmErr := json.Unmarshal(data, &tree); unmErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal spec: %w", unmErr)
+	}
+
+	fields := make(map[string]any)
+	flattenInto(fields, "", tree)
+
+	return fields, nil
+}
+
+func flattenInto(fields map[string]any, path string, v any) {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		fields[path] = v
+		return
+	}
+
+	for key, child := range obj {
+		if path != "" {
+			key = path + "." + key
+		}
+		flattenInto(fields, key, child)
+	}
+}
+
+// isLabel reports whether path is an entry of a labels map.
+func isLabel(path string) bool {
+	return strings.HasPrefix(path, "Labels.") || strings.Contains(path, ".Labels.")
+}
@@ -0,0 +1,96 @@
+package reconciler_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/reconciler"
+	"github.com/moby/moby/api/types/swarm"
+)
+
+func newSpec(image string, replicas uint64, labels map[string]string) swarm.ServiceSpec {
+	return swarm.ServiceSpec{
+		Annotations: swarm.Annotations{Name: "app_web", Labels: labels},
+		TaskTemplate: swarm.TaskSpec{
+			ContainerSpec: &swarm.ContainerSpec{Image: image},
+		},
+		Mode: swarm.ServiceMode{
+			Replicated: &swarm.ReplicatedService{Replicas: &replicas},
+		},
+	}
+}
+
+func TestDiffSpecs(t *testing.T) {
+	t.Parallel()
+
+	labels := map[string]string{"team": "web", reconciler.LabelSpecHash: "a"}
+
+	tests := []struct {
+		name    string
+		current swarm.ServiceSpec
+		desired swarm.ServiceSpec
+		want    []reconciler.FieldChange
+	}{
+		{
+			name:    "identical",
+			current: newSpec("nginx:1", 1, labels),
+			desired: newSpec("nginx:1", 1, labels),
+			want:    nil,
+		},
+		{
+			name:    "image and replicas",
+			current: newSpec("nginx:1", 1, labels),
+			desired: newSpec("nginx:2", 3, labels),
+			want: []reconciler.FieldChange{
+				{Path: "Mode.Replicated.Replicas", Current: float64(1), Desired: float64(3)},
+				{Path: "TaskTemplate.ContainerSpec.Image", Current: "nginx:1", Desired: "nginx:2"},
+			},
+		},
+		{
+			name:    "spec hash is not a change",
+			current: newSpec("nginx:1", 1, labels),
+			desired: newSpec("nginx:1", 1, map[string]string{"team": "web", reconciler.LabelSpecHash: "b"}),
+			want:    nil,
+		},
+		{
+			name: "defaults filled in by Swarm are ignored",
+			current: func() swarm.ServiceSpec {
+				spec := newSpec("nginx:1", 1, labels)
+				spec.TaskTemplate.ContainerSpec.StopSignal = "SIGTERM"
+				return spec
+			}(),
+			desired: newSpec("nginx:1", 1, labels),
+			want:    nil,
+		},
+		{
+			name:    "added label",
+			current: newSpec("nginx:1", 1, labels),
+			desired: newSpec("nginx:1", 1, map[string]string{"team": "web", "tier": "front", reconciler.LabelSpecHash: "a"}),
+			want: []reconciler.FieldChange{
+				{Path: "Labels.tier", Current: nil, Desired: "front"},
+			},
+		},
+		{
+			name:    "removed label",
+			current: newSpec("nginx:1", 1, labels),
+			desired: newSpec("nginx:1", 1, map[string]string{reconciler.LabelSpecHash: "a"}),
+			want: []reconciler.FieldChange{
+				{Path: "Labels.team", Current: "web", Desired: nil},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := reconciler.DiffSpecs(tt.current, tt.desired)
+			if err != nil {
+				t.Fatalf("DiffSpecs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffSpecs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
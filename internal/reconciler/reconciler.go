@@ -0,0 +1,397 @@
+package reconciler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/compose"
+	"github.com/apiarycd/apiarycd/internal/swarm"
+	swarmtypes "github.com/moby/moby/api/types/swarm"
+	"github.com/moby/moby/client"
+	"go.uber.org/zap"
+)
+
+// LabelSpecHash holds a digest of the desired service spec. It is used to
+// detect changes without comparing against the defaults filled in by Swarm.
+const LabelSpecHash = "com.apiarycd.spec-hash"
+
+// Reconciler converges the Swarm resources of a stack to the desired state,
+// like `docker stack deploy --prune` does.
+type Reconciler struct {
+	swarm *swarm.Swarm
+
+	logger *zap.Logger
+}
+
+func New(swarm *swarm.Swarm, logger *zap.Logger) *Reconciler {
+	return &Reconciler{
+		swarm: swarm,
+
+		logger: logger,
+	}
+}
+
+// Apply creates missing resources of the stack, updates changed services and
+// removes services, networks, configs and secrets of the namespace that are no
+// longer desired.
+func (r *Reconciler) Apply(ctx context.Context, stack *compose.Stack) (*Result, error) {
+	logger := r.logger.With(zap.String("namespace", stack.Namespace))
+	logger.Info("applying stack", zap.Int("services", len(stack.Services)))
+
+	result := &Result{
+		StartedAt: time.Now(),
+		Changes:   []Change{},
+		Services:  make(map[string]string, len(stack.Services)),
+	}
+
+	obsoleteNetworks, err := r.applyNetworks(ctx, stack, result)
+	if err != nil {
+		return result, err
+	}
+
+	configIDs, obsoleteConfigs, err := r.applyConfigs(ctx, stack, result)
+	if err != nil {
+		return result, err
+	}
+
+	secretIDs, obsoleteSecrets, err := r.applySecrets(ctx, stack, result)
+	if err != nil {
+		return result, err
+	}
+
+	if svcErr := r.applyServices(ctx, stack, configIDs, secretIDs, result); svcErr != nil {
+		return result, svcErr
+	}
+
+	// Resources that are still in use by tasks being shut down cannot be
+	// removed yet; they are retried on the next deployment.
+	for name, id := range obsoleteConfigs {
+		r.prune(ctx, result, KindConfig, name, id, r.swarm.RemoveConfig)
+	}
+	for name, id := range obsoleteSecrets {
+		r.prune(ctx, result, KindSecret, name, id, r.swarm.RemoveSecret)
+	}
+	for name, id := range obsoleteNetworks {
+		r.prune(ctx, result, KindNetwork, name, id, r.swarm.RemoveNetwork)
+	}
+
+	logger.Info("stack applied", zap.Int("changes", len(result.Changes)))
+	return result, nil
+}
+
+// Rollback rolls the services updated by Apply back to their previous spec.
+// Created services and other resources are left in place.
+func (r *Reconciler) Rollback(ctx context.Context, result *Result) error {
+	var errs []error
+	for _, c := range result.Changes {
+		if c.Kind != KindService || c.Action != ActionUpdate {
+			continue
+		}
+
+		if err := r.swarm.RollbackService(ctx, c.ID); err != nil {
+			errs = append(errs, fmt.Errorf("service %q: %w", c.Name, err))
+			continue
+		}
+
+		r.logger.Info("service rolled back", zap.String("service", c.Name))
+	}
+
+	return errors.Join(errs...)
+}
+
+// applyNetworks creates missing networks and returns the obsolete ones.
+func (r *Reconciler) applyNetworks(ctx context.Context, stack *compose.Stack, result *Result) (map[string]string, error) {
+	live, err := r.swarm.ListNetworks(ctx, namespaceFilter(stack.Namespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list networks: %w", err)
+	}
+
+	obsolete := make(map[string]string, len(live))
+	for _, n := range live {
+		obsolete[n.Name] = n.ID
+	}
+
+	for _, n := range stack.Networks {
+		if _, ok := obsolete[n.Name]; ok {
+			delete(obsolete, n.Name)
+			continue
+		}
+
+		id, crErr := r.swarm.CreateNetwork(ctx, n.Name, client.NetworkCreateOptions{
+			Driver:     n.Driver,
+			Scope:      "swarm",
+			EnableIPv4: nil,
+			EnableIPv6: nil,
+			IPAM:       nil,
+			Internal:   n.Internal,
+			Attachable: n.Attachable,
+			Ingress:    false,
+			ConfigOnly: false,
+			ConfigFrom: "",
+			Options:    n.DriverOpts,
+			Labels:     n.Labels,
+		})
+		if crErr != nil {
+			return nil, fmt.Errorf("failed to create network %q: %w", n.Name, crErr)
+		}
+
+		result.add(KindNetwork, ActionCreate, n.Name, id)
+	}
+
+	return obsolete, nil
+}
+
+// applyConfigs creates missing configs and returns the IDs of all configs
+// referenced by the stack along with the obsolete ones.
+func (r *Reconciler) applyConfigs(
+	ctx context.Context,
+	stack *compose.Stack,
+	result *Result,
+) (map[string]string, map[string]string, error) {
+	live, err := r.swarm.ListConfigs(ctx, namespaceFilter(stack.Namespace))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list configs: %w", err)
+	}
+
+	obsolete := make(map[string]string, len(live))
+	for _, c := range live {
+		obsolete[c.Spec.Name] = c.ID
+	}
+
+	ids := make(map[string]string, len(stack.Configs))
+	for _, c := range stack.Configs {
+		if id, ok := obsolete[c.Name]; ok {
+			ids[c.Name] = id
+			delete(obsolete, c.Name)
+			continue
+		}
+
+		id, crErr := r.swarm.CreateConfig(ctx, c)
+		if crErr != nil {
+			return nil, nil, fmt.Errorf("failed to create config %q: %w", c.Name, crErr)
+		}
+
+		ids[c.Name] = id
+		result.add(KindConfig, ActionCreate, c.Name, id)
+	}
+
+	// External configs
+	for _, svc := range stack.Services {
+		for _, ref := range svc.TaskTemplate.ContainerSpec.Configs {
+			if _, ok := ids[ref.ConfigName]; ok {
+				continue
+			}
+
+			items, lsErr := r.swarm.ListConfigs(ctx, make(client.Filters).Add("name", ref.ConfigName))
+			if lsErr != nil {
+				return nil, nil, fmt.Errorf("failed to list configs: %w", lsErr)
+			}
+
+			for _, item := range items {
+				if item.Spec.Name == ref.ConfigName {
+					ids[ref.ConfigName] = item.ID
+				}
+			}
+			if _, ok := ids[ref.ConfigName]; !ok {
+				return nil, nil, fmt.Errorf("%w: config %q", ErrExternalNotFound, ref.ConfigName)
+			}
+		}
+	}
+
+	return ids, obsolete, nil
+}
+
+// applySecrets creates missing secrets and returns the IDs of all secrets
+// referenced by the stack along with the obsolete ones.
+func (r *Reconciler) applySecrets(
+	ctx context.Context,
+	stack *compose.Stack,
+	result *Result,
+) (map[string]string, map[string]string, error) {
+	live, err := r.swarm.ListSecrets(ctx, namespaceFilter(stack.Namespace))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	obsolete := make(map[string]string, len(live))
+	for _, s := range live {
+		obsolete[s.Spec.Name] = s.ID
+	}
+
+	ids := make(map[string]string, len(stack.Secrets))
+	for _, s := range stack.Secrets {
+		if id, ok := obsolete[s.Name]; ok {
+			ids[s.Name] = id
+			delete(obsolete, s.Name)
+			continue
+		}
+
+		id, crErr := r.swarm.CreateSecret(ctx, s)
+		if crErr != nil {
+			return nil, nil, fmt.Errorf("failed to create secret %q: %w", s.Name, crErr)
+		}
+
+		ids[s.Name] = id
+		result.add(KindSecret, ActionCreate, s.Name, id)
+	}
+
+	// External secrets
+	for _, svc := range stack.Services {
+		for _, ref := range svc.TaskTemplate.ContainerSpec.Secrets {
+			if _, ok := ids[ref.SecretName]; ok {
+				continue
+			}
+
+			items, lsErr := r.swarm.ListSecrets(ctx, make(client.Filters).Add("name", ref.SecretName))
+			if lsErr != nil {
+				return nil, nil, fmt.Errorf("failed to list secrets: %w", lsErr)
+			}
+
+			for _, item := range items {
+				if item.Spec.Name == ref.SecretName {
+					ids[ref.SecretName] = item.ID
+				}
+			}
+			if _, ok := ids[ref.SecretName]; !ok {
+				return nil, nil, fmt.Errorf("%w: secret %q", ErrExternalNotFound, ref.SecretName)
+			}
+		}
+	}
+
+	return ids, obsolete, nil
+}
+
+// applyServices creates, updates and removes services.
+func (r *Reconciler) applyServices(
+	ctx context.Context,
+	stack *compose.Stack,
+	configIDs, secretIDs map[string]string,
+	result *Result,
+) error {
+	live, err := r.swarm.ListServices(ctx, namespaceFilter(stack.Namespace))
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+
+	existing := make(map[string]swarmtypes.Service, len(live))
+	for _, s := range live {
+		existing[s.Spec.Name] = s
+	}
+
+	for _, desired := range stack.Services {
+		spec, specErr := resolveSpec(desired, configIDs, secretIDs)
+		if specErr != nil {
+			return specErr
+		}
+
+		current, ok := existing[spec.Name]
+		delete(existing, spec.Name)
+
+		switch {
+		case !ok:
+			id, crErr := r.swarm.CreateService(ctx, spec)
+			if crErr != nil {
+				return fmt.Errorf("failed to create service %q: %w", spec.Name, crErr)
+			}
+
+			result.Services[spec.Name] = id
+			result.add(KindService, ActionCreate, spec.Name, id)
+		case current.Spec.Labels[LabelSpecHash] != spec.Labels[LabelSpecHash]:
+			if updErr := r.swarm.UpdateService(ctx, current.ID, current.Version, spec); updErr != nil {
+				return fmt.Errorf("failed to update service %q: %w", spec.Name, updErr)
+			}
+
+			result.Services[spec.Name] = current.ID
+			result.add(KindService, ActionUpdate, spec.Name, current.ID)
+		default:
+			result.Services[spec.Name] = current.ID
+		}
+	}
+
+	for name, s := range existing {
+		if rmErr := r.swarm.RemoveService(ctx, s.ID); rmErr != nil {
+			return fmt.Errorf("failed to remove service %q: %w", name, rmErr)
+		}
+
+		result.add(KindService, ActionRemove, name, s.ID)
+	}
+
+	return nil
+}
+
+func (r *Reconciler) prune(
+	ctx context.Context,
+	result *Result,
+	kind Kind,
+	name, id string,
+	remove func(context.Context, string) error,
+) {
+	if err := remove(ctx, id); err != nil {
+		r.logger.Warn("failed to remove obsolete resource",
+			zap.String("kind", string(kind)),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		return
+	}
+
+	result.add(kind, ActionRemove, name, id)
+}
+
+// resolveSpec returns a copy of spec with config and secret IDs filled in and
+// the spec hash label set.
+func resolveSpec(spec swarmtypes.ServiceSpec, configIDs, secretIDs map[string]string) (swarmtypes.ServiceSpec, error) {
+	containerSpec := *spec.TaskTemplate.ContainerSpec
+
+	containerSpec.Configs = make([]*swarmtypes.ConfigReference, len(spec.TaskTemplate.ContainerSpec.Configs))
+	for i, ref := range spec.TaskTemplate.ContainerSpec.Configs {
+		resolved := *ref
+		resolved.ConfigID = configIDs[ref.ConfigName]
+		containerSpec.Configs[i] = &resolved
+	}
+
+	containerSpec.Secrets = make([]*swarmtypes.SecretReference, len(spec.TaskTemplate.ContainerSpec.Secrets))
+	for i, ref := range spec.TaskTemplate.ContainerSpec.Secrets {
+		resolved := *ref
+		resolved.SecretID = secretIDs[ref.SecretName]
+		containerSpec.Secrets[i] = &resolved
+	}
+
+	spec.TaskTemplate.ContainerSpec = &containerSpec
+
+	labels := make(map[string]string, len(spec.Labels)+1)
+	for k, v := range spec.Labels {
+		if k != LabelSpecHash {
+			labels[k] = v
+		}
+	}
+	spec.Labels = labels
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return spec, fmt.Errorf("failed to hash service spec: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	spec.Labels[LabelSpecHash] = hex.EncodeToString(sum[:])
+
+	return spec, nil
+}
+
+func (r *Result) add(kind Kind, action Action, name, id string) {
+	r.Changes = append(r.Changes, Change{
+		Kind:   kind,
+		Action: action,
+		Name:   name,
+		ID:     id,
+	})
+}
+
+func namespaceFilter(namespace string) client.Filters {
+	return make(client.Filters).Add("label", compose.LabelNamespace+"="+namespace)
+}
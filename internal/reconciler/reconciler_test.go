@@ -0,0 +1,277 @@
+package reconciler_test
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/compose"
+	"github.com/apiarycd/apiarycd/internal/reconciler"
+	"github.com/apiarycd/apiarycd/internal/swarm/swarmtest"
+	"github.com/moby/moby/api/types/swarm"
+	"go.uber.org/zap"
+)
+
+const stackFile = `
+services:
+  web:
+    image: ${IMAGE}
+    networks: [front]
+    configs: [site]
+    secrets: [token, shared]
+  worker:
+    image: busybox
+networks:
+  front: {}
+configs:
+  site:
+    name: app_site
+    content: hello
+secrets:
+  token:
+    name: app_token
+    file: ./token
+  shared:
+    external: true
+    name: shared_token
+`
+
+func loadStack(t *testing.T, image string) *compose.Stack {
+	t.Helper()
+
+	stack, err := compose.Load([]byte(stackFile), compose.Options{
+		Namespace: "app",
+		Variables: map[string]string{"IMAGE": image},
+		ReadFile:  func(string) ([]byte, error) { return []byte("s3cr3t"), nil },
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	return stack
+}
+
+// newEngine returns an engine holding the resources the stack uses without
+// owning them.
+func newEngine() *swarmtest.Engine {
+	eng := swarmtest.NewEngine()
+	eng.AddSecret(swarm.SecretSpec{Annotations: swarm.Annotations{Name: "shared_token"}})
+	return eng
+}
+
+func newReconciler(t *testing.T, eng *swarmtest.Engine) *reconciler.Reconciler {
+	t.Helper()
+
+	return reconciler.New(eng.Swarm(t), zap.NewNop())
+}
+
+// changes formats the changes of a result as "<action> <kind> <name>".
+func changes(result *reconciler.Result) []string {
+	list := make([]string, 0, len(result.Changes))
+	for _, c := range result.Changes {
+		list = append(list, fmt.Sprintf("%s %s %s", c.Action, c.Kind, c.Name))
+	}
+	return list
+}
+
+func namespaced(namespace string) map[string]string {
+	return map[string]string{compose.LabelNamespace: namespace}
+}
+
+func TestReconcilerApply(t *testing.T) {
+	t.Parallel()
+
+	eng := newEngine()
+	r := newReconciler(t, eng)
+
+	created, err := r.Apply(t.Context(), loadStack(t, "nginx:1"))
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	want := []string{
+		"create network app_default",
+		"create network app_front",
+		"create config app_site",
+		"create secret app_token",
+		"create service app_web",
+		"create service app_worker",
+	}
+	if got := changes(created); !slices.Equal(got, want) {
+		t.Errorf("Apply() changes = %v, want %v", got, want)
+	}
+	services := eng.Services()
+	wantServices := map[string]string{"app_web": services["app_web"].ID, "app_worker": services["app_worker"].ID}
+	if !maps.Equal(created.Services, wantServices) {
+		t.Errorf("Apply() services = %v, want %v", created.Services, wantServices)
+	}
+	if got := services["app_web"].Spec.Labels[reconciler.LabelSpecHash]; got == "" {
+		t.Errorf("service labels = %v, want %s set", services["app_web"].Spec.Labels, reconciler.LabelSpecHash)
+	}
+
+	unchanged, err := r.Apply(t.Context(), loadStack(t, "nginx:1"))
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got := changes(unchanged); len(got) != 0 {
+		t.Errorf("Apply() of the same stack changes = %v, want none", got)
+	}
+	if !maps.Equal(unchanged.Services, wantServices) {
+		t.Errorf("Apply() of the same stack services = %v, want %v", unchanged.Services, wantServices)
+	}
+
+	updated, err := r.Apply(t.Context(), loadStack(t, "nginx:2"))
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got, want := changes(updated), []string{"update service app_web"}; !slices.Equal(got, want) {
+		t.Errorf("Apply() of a new image changes = %v, want %v", got, want)
+	}
+	web := eng.Services()["app_web"]
+	if web.ID != wantServices["app_web"] || web.Spec.TaskTemplate.ContainerSpec.Image != "nginx:2" {
+		t.Errorf("service after update = %s %s, want %s nginx:2",
+			web.ID, web.Spec.TaskTemplate.ContainerSpec.Image, wantServices["app_web"])
+	}
+	if web.Spec.Labels[reconciler.LabelSpecHash] == services["app_web"].Spec.Labels[reconciler.LabelSpecHash] {
+		t.Errorf("spec hash after update = %s, want a new one", web.Spec.Labels[reconciler.LabelSpecHash])
+	}
+}
+
+func TestReconcilerApplyPrune(t *testing.T) {
+	t.Parallel()
+
+	eng := newEngine()
+	r := newReconciler(t, eng)
+	if _, err := r.Apply(t.Context(), loadStack(t, "nginx:1")); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	// Obsolete resources of the namespace.
+	eng.AddService(swarm.ServiceSpec{
+		Annotations:  swarm.Annotations{Name: "app_old", Labels: namespaced("app")},
+		TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "nginx:0"}},
+	})
+	eng.AddNetwork("app_old", namespaced("app"))
+	eng.AddConfig(swarm.ConfigSpec{Annotations: swarm.Annotations{Name: "app_site_old", Labels: namespaced("app")}})
+	eng.AddSecret(swarm.SecretSpec{Annotations: swarm.Annotations{Name: "app_token_old", Labels: namespaced("app")}})
+	// A config of the namespace still in use elsewhere cannot be removed.
+	busy := eng.AddConfig(swarm.ConfigSpec{Annotations: swarm.Annotations{Name: "app_busy", Labels: namespaced("app")}})
+	// Resources that are not part of the namespace.
+	eng.AddService(swarm.ServiceSpec{
+		Annotations: swarm.Annotations{Name: "other_web", Labels: namespaced("other")},
+		TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{
+			Image:   "nginx:1",
+			Configs: []*swarm.ConfigReference{{ConfigID: busy, ConfigName: "app_busy"}},
+		}},
+	})
+	eng.AddNetwork("ingress", nil)
+	eng.AddConfig(swarm.ConfigSpec{Annotations: swarm.Annotations{Name: "shared_site"}})
+
+	result, err := r.Apply(t.Context(), loadStack(t, "nginx:1"))
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	want := []string{
+		"remove service app_old",
+		"remove config app_site_old",
+		"remove secret app_token_old",
+		"remove network app_old",
+	}
+	if got := changes(result); !slices.Equal(got, want) {
+		t.Errorf("Apply() changes = %v, want %v", got, want)
+	}
+
+	wantServices := []string{"app_web", "app_worker", "other_web"}
+	if got := slices.Sorted(maps.Keys(eng.Services())); !slices.Equal(got, wantServices) {
+		t.Errorf("services = %v, want %v", got, wantServices)
+	}
+	if got, want := eng.Networks(), []string{"app_default", "app_front", "ingress"}; !slices.Equal(got, want) {
+		t.Errorf("networks = %v, want %v", got, want)
+	}
+	if got, want := eng.Configs(), []string{"app_busy", "app_site", "shared_site"}; !slices.Equal(got, want) {
+		t.Errorf("configs = %v, want %v", got, want)
+	}
+	if got, want := eng.Secrets(), []string{"app_token", "shared_token"}; !slices.Equal(got, want) {
+		t.Errorf("secrets = %v, want %v", got, want)
+	}
+}
+
+func TestReconcilerApplyFailure(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		failing  string
+		want     []string
+		services []string
+	}{
+		{
+			name:     "network",
+			failing:  "app_front",
+			want:     []string{"create network app_default"},
+			services: nil,
+		},
+		{
+			name:    "secret",
+			failing: "app_token",
+			want: []string{
+				"create network app_default",
+				"create network app_front",
+				"create config app_site",
+			},
+			services: nil,
+		},
+		{
+			name:    "service",
+			failing: "app_worker",
+			want: []string{
+				"create network app_default",
+				"create network app_front",
+				"create config app_site",
+				"create secret app_token",
+				"create service app_web",
+			},
+			services: []string{"app_web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			eng := newEngine()
+			eng.FailCreate(tt.failing)
+
+			result, err := newReconciler(t, eng).Apply(t.Context(), loadStack(t, "nginx:1"))
+			if err == nil || !strings.Contains(err.Error(), tt.failing) {
+				t.Fatalf("Apply() error = %v, want failure of %q", err, tt.failing)
+			}
+			if result == nil {
+				t.Fatal("Apply() result = nil, want the changes made before the failure")
+			}
+			if got := changes(result); !slices.Equal(got, tt.want) {
+				t.Errorf("Apply() changes = %v, want %v", got, tt.want)
+			}
+			if got := slices.Sorted(maps.Keys(result.Services)); !slices.Equal(got, tt.services) {
+				t.Errorf("Apply() services = %v, want %v", got, tt.services)
+			}
+		})
+	}
+}
+
+func TestReconcilerApplyMissingExternal(t *testing.T) {
+	t.Parallel()
+
+	eng := swarmtest.NewEngine()
+
+	_, err := newReconciler(t, eng).Apply(t.Context(), loadStack(t, "nginx:1"))
+	if !errors.Is(err, reconciler.ErrExternalNotFound) {
+		t.Fatalf("Apply() error = %v, want %v", err, reconciler.ErrExternalNotFound)
+	}
+	if services := eng.Services(); len(services) != 0 {
+		t.Errorf("services = %v, want none", slices.Collect(maps.Keys(services)))
+	}
+}
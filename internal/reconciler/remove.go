@@ -0,0 +1,162 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	swarmtypes "github.com/moby/moby/api/types/swarm"
+	"go.uber.org/zap"
+)
+
+// Remove removes every service, config, secret and network of the namespace,
+// like `docker stack rm` does. Once the services are removed it waits for
+// their tasks to shut down, so the networks, configs and secrets they used
+// are released. Resources still in use are retried until ctx expires.
+func (r *Reconciler) Remove(ctx context.Context, namespace string) (*Result, error) {
+	logger := r.logger.With(zap.String("namespace", namespace))
+	logger.Info("removing stack")
+
+	result := &Result{
+		StartedAt: time.Now(),
+		Changes:   []Change{},
+		Services:  map[string]string{},
+	}
+
+	services, err := r.swarm.ListServices(ctx, namespaceFilter(namespace))
+	if err != nil {
+		return result, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	for _, s := range services {
+		if rmErr := r.swarm.RemoveService(ctx, s.ID); rmErr != nil {
+			return result, fmt.Errorf("failed to remove service %q: %w", s.Spec.Name, rmErr)
+		}
+
+		result.add(KindService, ActionRemove, s.Spec.Name, s.ID)
+	}
+
+	if waitErr := r.waitTasks(ctx, namespace); waitErr != nil {
+		return result, waitErr
+	}
+
+	configs, err := r.swarm.ListConfigs(ctx, namespaceFilter(namespace))
+	if err != nil {
+		return result, fmt.Errorf("failed to list configs: %w", err)
+	}
+
+	secrets, err := r.swarm.ListSecrets(ctx, namespaceFilter(namespace))
+	if err != nil {
+		return result, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	networks, err := r.swarm.ListNetworks(ctx, namespaceFilter(namespace))
+	if err != nil {
+		return result, fmt.Errorf("failed to list networks: %w", err)
+	}
+
+	resources := make([]resource, 0, len(configs)+len(secrets)+len(networks))
+	for _, c := range configs {
+		resources = append(resources, resource{KindConfig, c.Spec.Name, c.ID, r.swarm.RemoveConfig})
+	}
+	for _, s := range secrets {
+		resources = append(resources, resource{KindSecret, s.Spec.Name, s.ID, r.swarm.RemoveSecret})
+	}
+	for _, n := range networks {
+		resources = append(resources, resource{KindNetwork, n.Name, n.ID, r.swarm.RemoveNetwork})
+	}
+
+	if rmErr := r.removeResources(ctx, resources, result); rmErr != nil {
+		return result, rmErr
+	}
+
+	logger.Info("stack removed", zap.Int("changes", len(result.Changes)))
+	return result, nil
+}
+
+// resource is a network, config or secret to remove.
+type resource struct {
+	kind   Kind
+	name   string
+	id     string
+	remove func(context.Context, string) error
+}
+
+// waitTasks blocks until every task of the namespace has reached a final
+// state.
+func (r *Reconciler) waitTasks(ctx context.Context, namespace string) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		tasks, err := r.swarm.ListTasks(ctx, namespaceFilter(namespace))
+		if err != nil {
+			return fmt.Errorf("failed to list tasks: %w", err)
+		}
+
+		remaining := 0
+		for _, task := range tasks {
+			if !isFinal(task.Status.State) {
+				remaining++
+			}
+		}
+		if remaining == 0 {
+			return nil
+		}
+
+		r.logger.Debug("waiting for tasks to shut down", zap.String("namespace", namespace), zap.Int("tasks", remaining))
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %d tasks still running", ErrRemovalTimeout, remaining)
+		case <-ticker.C:
+		}
+	}
+}
+
+// removeResources removes resources, retrying the ones that fail until ctx
+// expires. Swarm releases networks asynchronously after the tasks attached to
+// them have stopped.
+func (r *Reconciler) removeResources(ctx context.Context, resources []resource, result *Result) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		var errs []error
+		resources = slices.DeleteFunc(resources, func(res resource) bool {
+			if err := res.remove(ctx, res.id); err != nil {
+				errs = append(errs, fmt.Errorf("%s %q: %w", res.kind, res.name, err))
+				return false
+			}
+
+			result.add(res.kind, ActionRemove, res.name, res.id)
+			return true
+		})
+		if len(resources) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrRemovalTimeout, errors.Join(errs...))
+		case <-ticker.C:
+		}
+	}
+}
+
+// isFinal reports whether a task has stopped for good.
+func isFinal(state swarmtypes.TaskState) bool {
+	switch state { //nolint:exhaustive // other states are in progress
+	case swarmtypes.TaskStateComplete,
+		swarmtypes.TaskStateShutdown,
+		swarmtypes.TaskStateFailed,
+		swarmtypes.TaskStateRejected,
+		swarmtypes.TaskStateOrphaned,
+		swarmtypes.TaskStateRemove:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,99 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// ScaleDown scales the replicated services of the namespace to zero and
+// returns the replica counts they had by service name. Services already
+// scaled to zero are left out, so scaling down twice keeps the counts of the
+// first call. Global services and jobs cannot be scaled and keep running. On
+// failure the services scaled so far are returned along with the error.
+func (r *Reconciler) ScaleDown(ctx context.Context, namespace string) (map[string]uint64, error) {
+	services, err := r.swarm.ListServices(ctx, namespaceFilter(namespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	scaled := make(map[string]uint64, len(services))
+	for _, s := range services {
+		mode := s.Spec.Mode.Replicated
+		if mode == nil || mode.Replicas == nil || *mode.Replicas == 0 {
+			continue
+		}
+
+		previous := *mode.Replicas
+		if scErr := r.scale(ctx, s.ID, 0); scErr != nil {
+			return scaled, fmt.Errorf("service %q: %w", s.Spec.Name, scErr)
+		}
+
+		scaled[s.Spec.Name] = previous
+		r.logger.Info("service scaled down", zap.String("service", s.Spec.Name), zap.Uint64("replicas", previous))
+	}
+
+	return scaled, nil
+}
+
+// Scale sets the replica counts of services of the namespace by name. It
+// returns the names of the services that have been scaled or no longer exist,
+// even if scaling others fails.
+func (r *Reconciler) Scale(ctx context.Context, namespace string, replicas map[string]uint64) ([]string, error) {
+	services, err := r.swarm.ListServices(ctx, namespaceFilter(namespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	ids := make(map[string]string, len(services))
+	for _, s := range services {
+		ids[s.Spec.Name] = s.ID
+	}
+
+	done := make([]string, 0, len(replicas))
+
+	var errs []error
+	for name, count := range replicas {
+		id, ok := ids[name]
+		if !ok {
+			r.logger.Warn("service to scale not found", zap.String("service", name))
+			done = append(done, name)
+			continue
+		}
+
+		if scErr := r.scale(ctx, id, count); scErr != nil {
+			errs = append(errs, fmt.Errorf("service %q: %w", name, scErr))
+			continue
+		}
+
+		done = append(done, name)
+		r.logger.Info("service scaled", zap.String("service", name), zap.Uint64("replicas", count))
+	}
+
+	return done, errors.Join(errs...)
+}
+
+// scale sets the replica count of a replicated service.
+func (r *Reconciler) scale(ctx context.Context, id string, replicas uint64) error {
+	service, err := r.swarm.InspectService(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to inspect service: %w", err)
+	}
+
+	spec := service.Spec
+	if spec.Mode.Replicated == nil {
+		return fmt.Errorf("%w: service is not replicated", ErrNotScalable)
+	}
+
+	mode := *spec.Mode.Replicated
+	mode.Replicas = &replicas
+	spec.Mode.Replicated = &mode
+
+	if updErr := r.swarm.UpdateService(ctx, id, service.Version, spec); updErr != nil {
+		return fmt.Errorf("failed to scale service: %w", updErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	swarmtypes "github.com/moby/moby/api/types/swarm"
+	"github.com/moby/moby/client"
+	"go.uber.org/zap"
+)
+
+const (
+	pollInterval    = 2 * time.Second
+	maxTaskFailures = 3
+
+	rollingBack = "rolling back: "
+)
+
+// progress is the rollout state of a single service.
+type progress struct {
+	converged bool
+	running   uint64
+	desired   uint64
+	message   string
+}
+
+func (p progress) String() string {
+	s := fmt.Sprintf("%d/%d tasks running", p.running, p.desired)
+	if p.message != "" {
+		s += " (" + p.message + ")"
+	}
+
+	return s
+}
+
+// ProgressFunc is called with the rollout status of a service whenever it
+// changes.
+type ProgressFunc func(service, status string)
+
+// Wait blocks until every service of result has converged: its update has
+// completed and the desired number of up-to-date tasks is running. It fails
+// when an update is paused or rolled back, when tasks keep failing or being
+// rejected, or when ctx expires before the services converge. Services with
+// the rollback failure action are left to Swarm to roll back on task failures,
+// which fails with ErrRolledBack. report may be nil.
+func (r *Reconciler) Wait(ctx context.Context, result *Result, report ProgressFunc) error {
+	pending := maps.Clone(result.Services)
+	states := make(map[string]progress, len(pending))
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, name := range slices.Sorted(maps.Keys(pending)) {
+			p, err := r.progress(ctx, pending[name], result.StartedAt)
+			if err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				return fmt.Errorf("service %q: %w", name, err)
+			}
+
+			if p.converged {
+				r.logger.Info("service converged", zap.String("service", name), zap.Uint64("replicas", p.running))
+				if report != nil {
+					report(name, "converged, "+p.String())
+				}
+				delete(pending, name)
+				delete(states, name)
+				continue
+			}
+
+			if previous, ok := states[name]; report != nil && (!ok || previous.String() != p.String()) {
+				report(name, p.String())
+			}
+			states[name] = p
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("%w: %s", ErrRolloutTimeout, describe(states))
+			}
+			return ctx.Err() //nolint:wrapcheck // cancellation is reported as is
+		case <-ticker.C:
+		}
+	}
+}
+
+// progress inspects the update status and the up-to-date tasks of a service.
+// Only task failures reported after since are taken into account.
+func (r *Reconciler) progress(ctx context.Context, id string, since time.Time) (progress, error) {
+	var p progress
+
+	service, err := r.swarm.InspectService(ctx, id)
+	if err != nil {
+		return p, fmt.Errorf("failed to inspect service: %w", err)
+	}
+
+	updating, swarmHandlesFailures, err := updateProgress(service, since, &p)
+	if err != nil {
+		return p, err
+	}
+
+	// Tasks of previous versions of the spec are filtered out.
+	tasks, err := r.swarm.ListTasks(ctx, make(client.Filters).
+		Add("service", id).
+		Add("_up-to-date", "true"),
+	)
+	if err != nil {
+		return p, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	failures := 0
+	lastFailure := ""
+	for _, task := range tasks {
+		switch task.Status.State { //nolint:exhaustive // other states are in progress or final
+		case swarmtypes.TaskStateFailed, swarmtypes.TaskStateRejected:
+			if task.Status.Timestamp.After(since) {
+				failures++
+				lastFailure = taskMessage(task)
+			}
+			continue
+		case swarmtypes.TaskStateRunning:
+			if task.DesiredState == swarmtypes.TaskStateRunning {
+				p.running++
+			}
+		default:
+			if task.DesiredState == swarmtypes.TaskStateRunning && !strings.HasPrefix(p.message, rollingBack) {
+				p.message = taskMessage(task)
+			}
+		}
+
+		if service.Spec.Mode.Global != nil && task.DesiredState == swarmtypes.TaskStateRunning {
+			p.desired++
+		}
+	}
+
+	if failures >= maxTaskFailures && !swarmHandlesFailures {
+		return p, fmt.Errorf("%w: %d tasks failed, last: %s", ErrTasksFailed, failures, lastFailure)
+	}
+
+	mode := service.Spec.Mode
+	switch {
+	case mode.Replicated != nil:
+		p.desired = 1
+		if mode.Replicated.Replicas != nil {
+			p.desired = *mode.Replicated.Replicas
+		}
+	case mode.ReplicatedJob != nil, mode.GlobalJob != nil:
+		// Jobs run to completion and have no steady state to wait for.
+		p.desired = p.running
+	}
+
+	if failures > 0 && p.message == "" {
+		p.message = lastFailure
+	}
+
+	// Global services have no tasks until the orchestrator schedules them.
+	scheduled := mode.Global == nil || p.desired > 0
+	p.converged = !updating && scheduled && p.running == p.desired
+	return p, nil
+}
+
+// updateProgress inspects the update status of a service. It reports whether
+// an update is in progress and whether Swarm rolls it back on task failures by
+// itself. Statuses of updates started before since are left over from previous
+// deployments and ignored.
+func updateProgress(service swarmtypes.Service, since time.Time, p *progress) (bool, bool, error) {
+	status := service.UpdateStatus
+	if status == nil || (status.StartedAt != nil && status.StartedAt.Before(since)) {
+		return false, false, nil
+	}
+
+	rollsBack := service.Spec.UpdateConfig != nil &&
+		service.Spec.UpdateConfig.FailureAction == swarmtypes.UpdateFailureActionRollback
+
+	switch status.State {
+	case swarmtypes.UpdateStatePaused:
+		return false, false, fmt.Errorf("%w: %s", ErrUpdatePaused, status.Message)
+	case swarmtypes.UpdateStateRollbackCompleted:
+		return false, false, fmt.Errorf("%w: %s", ErrRolledBack, status.Message)
+	case swarmtypes.UpdateStateRollbackPaused:
+		return false, false, fmt.Errorf("%w: %s", ErrRollbackPaused, status.Message)
+	case swarmtypes.UpdateStateRollbackStarted:
+		p.message = rollingBack + status.Message
+		return true, true, nil
+	case swarmtypes.UpdateStateUpdating:
+		return true, rollsBack, nil
+	case swarmtypes.UpdateStateCompleted:
+	}
+
+	return false, false, nil
+}
+
+// taskMessage returns the most descriptive status message of a task.
+func taskMessage(task swarmtypes.Task) string {
+	message := string(task.Status.State)
+	if task.Status.Err != "" {
+		return message + ": " + task.Status.Err
+	}
+	if task.Status.Message != "" {
+		return message + ": " + task.Status.Message
+	}
+
+	return message
+}
+
+func describe(states map[string]progress) string {
+	parts := make([]string, 0, len(states))
+	for _, name := range slices.Sorted(maps.Keys(states)) {
+		parts = append(parts, fmt.Sprintf("service %q: %s", name, states[name]))
+	}
+
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,102 @@
+package reconciler_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/reconciler"
+	"github.com/moby/moby/api/types/swarm"
+)
+
+func TestUpdateProgress(t *testing.T) {
+	t.Parallel()
+
+	since := time.Now()
+	later := since.Add(time.Second)
+	earlier := since.Add(-time.Second)
+
+	newService := func(state swarm.UpdateState, startedAt *time.Time, failureAction string) swarm.Service {
+		var service swarm.Service
+		if failureAction != "" {
+			service.Spec.UpdateConfig = &swarm.UpdateConfig{FailureAction: swarm.FailureAction(failureAction)}
+		}
+		if state != "" {
+			service.UpdateStatus = &swarm.UpdateStatus{State: state, StartedAt: startedAt, Message: "update"}
+		}
+		return service
+	}
+
+	tests := []struct {
+		name          string
+		service       swarm.Service
+		wantUpdating  bool
+		wantRollsBack bool
+		wantMessage   string
+		wantErr       error
+	}{
+		{
+			name:    "never updated",
+			service: newService("", nil, ""),
+		},
+		{
+			name:    "earlier rollback",
+			service: newService(swarm.UpdateStateRollbackCompleted, &earlier, ""),
+		},
+		{
+			name:         "updating",
+			service:      newService(swarm.UpdateStateUpdating, &later, ""),
+			wantUpdating: true,
+		},
+		{
+			name:          "updating with rollback on failure",
+			service:       newService(swarm.UpdateStateUpdating, &later, string(swarm.UpdateFailureActionRollback)),
+			wantUpdating:  true,
+			wantRollsBack: true,
+		},
+		{
+			name:    "completed",
+			service: newService(swarm.UpdateStateCompleted, &later, ""),
+		},
+		{
+			name:          "rolling back",
+			service:       newService(swarm.UpdateStateRollbackStarted, &later, ""),
+			wantUpdating:  true,
+			wantRollsBack: true,
+			wantMessage:   "rolling back: update",
+		},
+		{
+			name:    "rolled back",
+			service: newService(swarm.UpdateStateRollbackCompleted, &later, ""),
+			wantErr: reconciler.ErrRolledBack,
+		},
+		{
+			name:    "paused",
+			service: newService(swarm.UpdateStatePaused, &later, ""),
+			wantErr: reconciler.ErrUpdatePaused,
+		},
+		{
+			name:    "rollback paused",
+			service: newService(swarm.UpdateStateRollbackPaused, &later, ""),
+			wantErr: reconciler.ErrRollbackPaused,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			updating, rollsBack, message, err := reconciler.UpdateProgress(tt.service, since)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateProgress() error = %v, want %v", err, tt.wantErr)
+			}
+			if updating != tt.wantUpdating || rollsBack != tt.wantRollsBack {
+				t.Errorf("UpdateProgress() = updating %t, rolls back %t, want %t, %t",
+					updating, rollsBack, tt.wantUpdating, tt.wantRollsBack)
+			}
+			if message != tt.wantMessage {
+				t.Errorf("UpdateProgress() message = %q, want %q", message, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -24,9 +24,289 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/deployments": {
+            "get": {
+                "description": "List deployments of all stacks, newest first unless requested otherwise. Pass the next cursor of a page to get the following one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "deployments"
+                ],
+                "summary": "List deployments",
+                "parameters": [
+                    {
+                        "maxLength": 200,
+                        "type": "string",
+                        "description": "Next cursor of the previous page.",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 200,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Maximum number of deployments per page.",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order by creation time. Defaults to newest first.",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only deployments created at or after this time (RFC 3339).",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only deployments of this stack.",
+                        "name": "stackID",
+                        "in": "query"
+                    },
+                    {
+                        "maxLength": 200,
+                        "type": "string",
+                        "description": "Comma separated statuses to include.",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only deployments created before this time (RFC 3339).",
+                        "name": "until",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.DeploymentsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/deployments/{id}": {
+            "get": {
+                "description": "Get details of a deployment",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "deployments"
+                ],
+                "summary": "Get a deployment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deployment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.DeploymentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/deployments/{id}/cancel": {
+            "post": {
+                "description": "Cancel a pending or running deployment. A running deployment stops mutating the cluster; service updates it has already started are rolled back on request and otherwise left to finish.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "deployments"
+                ],
+                "summary": "Cancel a deployment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deployment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Cancel request",
+                        "name": "cancel",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/deployments.POSTCancelRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.DeploymentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/deployments/{id}/logs": {
+            "get": {
+                "description": "Get a page of the log of a deployment. With follow, or when requested with Accept: text/event-stream, the log is streamed as Server-Sent Events until the deployment finishes:\neach line is a \"log\" event whose ID is its sequence number, and a final \"end\" event carries the deployment. Streams resume after Last-Event-ID.",
+                "produces": [
+                    "application/json",
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "deployments"
+                ],
+                "summary": "Get deployment logs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deployment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Return lines after this sequence number.",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Stream new lines as Server-Sent Events until the deployment finishes.",
+                        "name": "follow",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Maximum number of lines per page.",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/deployments.LogsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/deployments/{id}/manifest": {
+            "get": {
+                "description": "Download the rendered manifest snapshot applied by a deployment: the Swarm service specs, networks, configs and secrets with variables interpolated. Secret data is omitted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "deployments"
+                ],
+                "summary": "Download a deployment manifest",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deployment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/deployments.ManifestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/stacks": {
             "get": {
-                "description": "Retrieve a list of all configured stacks",
+                "description": "Retrieve the configured stacks, optionally filtered by status and label selectors",
                 "consumes": [
                     "application/json"
                 ],
@@ -37,6 +317,26 @@ const docTemplate = `{
                     "stacks"
                 ],
                 "summary": "List all stacks",
+                "parameters": [
+                    {
+                        "maxItems": 20,
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Label selectors, all of which must match: key=value, key!=value,\nkey in (a,b), key notin (a,b), key or !key. Repeat the parameter or\nseparate selectors with commas.",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "maxLength": 200,
+                        "type": "string",
+                        "description": "Comma separated statuses to include.",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -46,6 +346,12 @@ const docTemplate = `{
                                 "$ref": "#/definitions/stacks.StackResponse"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
                     }
                 }
             },
@@ -138,7 +444,7 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Delete an existing stack by ID",
+                "description": "Delete an existing stack by ID along with its deployment history. With prune, the Swarm services, networks, configs and secrets of the stack are removed first and the request waits for their removal.",
                 "consumes": [
                     "application/json"
                 ],
@@ -156,6 +462,12 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Remove the Swarm services, networks, configs and secrets of the stack.",
+                        "name": "prune",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -173,6 +485,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/fiberfx.ErrorResponse"
                         }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
                     }
                 }
             },
@@ -226,7 +550,7 @@ const docTemplate = `{
         },
         "/stacks/{id}/deploy": {
             "post": {
-                "description": "Trigger a deployment of a stack",
+                "description": "Queue a deployment of a stack to be executed in the background",
                 "consumes": [
                     "application/json"
                 ],
@@ -256,10 +580,10 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/stacks.DeploymentResponse"
+                            "$ref": "#/definitions/dto.DeploymentResponse"
                         }
                     },
                     "400": {
@@ -273,16 +597,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/fiberfx.ErrorResponse"
                         }
+                    },
+                    "409": {
+                        "description": "Another deployment is in progress and the stack rejects concurrent deployments",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
                     }
                 }
             }
         },
         "/stacks/{id}/history": {
             "get": {
-                "description": "List all deployments for a stack",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "List deployments of a stack, newest first unless requested otherwise. Pass the next cursor of a page to get the following one.",
                 "produces": [
                     "application/json"
                 ],
@@ -298,22 +625,63 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/stacks.DeploymentResponse"
-                            }
-                        }
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                    {
+                        "maxLength": 200,
+                        "type": "string",
+                        "description": "Next cursor of the previous page.",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 200,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Maximum number of deployments per page.",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order by creation time. Defaults to newest first.",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only deployments created at or after this time (RFC 3339).",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "maxLength": 200,
+                        "type": "string",
+                        "description": "Comma separated statuses to include.",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only deployments created before this time (RFC 3339).",
+                        "name": "until",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.DeploymentsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
                         }
                     },
                     "404": {
@@ -325,9 +693,68 @@ const docTemplate = `{
                 }
             }
         },
-        "/stacks/{id}/rollback": {
+        "/stacks/{id}/plan": {
+            "post": {
+                "description": "Render the compose file of a stack at a ref and compare it with the services running in the cluster. Returns the services a deployment would create, update (with the changed fields) and remove, without changing anything or recording a deployment.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stacks",
+                    "deployments"
+                ],
+                "summary": "Plan a deployment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stack ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Plan request",
+                        "name": "plan",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/stacks.POSTPlanRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/stacks.PlanResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "The compose file cannot be rendered",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stacks/{id}/rename": {
             "post": {
-                "description": "Rollback a stack to a previous version",
+                "description": "Change the name of a stack. Renaming a deployed stack to a name with a different namespace requires migrate: the resources of the previous namespace are removed and the last successful deployment is redeployed to the new one.",
                 "consumes": [
                     "application/json"
                 ],
@@ -337,7 +764,169 @@ const docTemplate = `{
                 "tags": [
                     "stacks"
                 ],
+                "summary": "Rename a stack",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stack ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rename request",
+                        "name": "rename",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/stacks.POSTRenameRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/stacks.RenameResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stacks/{id}/resume": {
+            "post": {
+                "description": "Restore the replica counts of the services scaled down by suspend and resume syncing the stack.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stacks"
+                ],
+                "summary": "Resume a stack",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stack ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/stacks.StackResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stacks/{id}/rollback": {
+            "post": {
+                "description": "Queue a deployment of the commit and variables of an earlier successful deployment. Without a target, the stack is rolled back to the successful deployment preceding the last applied one, even if that one failed; repeated rollbacks walk further back.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stacks",
+                    "deployments"
+                ],
                 "summary": "Rollback a stack",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stack ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rollback request",
+                        "name": "rollback",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/stacks.POSTRollbackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/dto.DeploymentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stacks/{id}/suspend": {
+            "post": {
+                "description": "Stop syncing the stack and scale its replicated services to zero. The replica counts are remembered and restored on resume. Deployments of a suspended stack are refused.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stacks"
+                ],
+                "summary": "Suspend a stack",
                 "parameters": [
                     {
                         "type": "string",
@@ -351,7 +940,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/stacks.DeploymentResponse"
+                            "$ref": "#/definitions/stacks.StackResponse"
                         }
                     },
                     "400": {
@@ -365,12 +954,221 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/fiberfx.ErrorResponse"
                         }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/git": {
+            "post": {
+                "description": "Receive a push event from GitHub, GitLab, Gitea or Forgejo and deploy the pushed commit to every stack tracking the pushed repository and branch.\nThe request must be signed with the webhook secret of the stack (X-Hub-Signature-256, X-Gitea-Signature, X-Forgejo-Signature) or carry it as a token (X-Gitlab-Token).\nStacks whose secret does not match are left out of the results.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Git push webhook",
+                "responses": {
+                    "200": {
+                        "description": "No deployment triggered",
+                        "schema": {
+                            "$ref": "#/definitions/webhooks.PushResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "At least one deployment triggered",
+                        "schema": {
+                            "$ref": "#/definitions/webhooks.PushResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Signature does not match any stack",
+                        "schema": {
+                            "$ref": "#/definitions/fiberfx.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "deployments.CancelAction": {
+            "type": "string",
+            "enum": [
+                "none",
+                "rollback"
+            ],
+            "x-enum-comments": {
+                "CancelActionNone": "Let started service updates finish",
+                "CancelActionRollback": "Roll back started service updates"
+            },
+            "x-enum-varnames": [
+                "CancelActionNone",
+                "CancelActionRollback"
+            ]
+        },
+        "deployments.LogEntryResponse": {
+            "type": "object",
+            "properties": {
+                "level": {
+                    "$ref": "#/definitions/deployments.LogLevel"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "seq": {
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "deployments.LogLevel": {
+            "type": "string",
+            "enum": [
+                "info",
+                "warn",
+                "error"
+            ],
+            "x-enum-varnames": [
+                "LogLevelInfo",
+                "LogLevelWarn",
+                "LogLevelError"
+            ]
+        },
+        "deployments.LogsResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/deployments.LogEntryResponse"
+                    }
+                },
+                "next": {
+                    "description": "Sequence number to pass as after to get the next page.",
+                    "type": "integer"
+                }
+            }
+        },
+        "deployments.ManifestResponse": {
+            "type": "object",
+            "properties": {
+                "configs": {
+                    "description": "Config specs in the format of the Docker Engine API.",
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "digest": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                },
+                "networks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/deployments.NetworkResponse"
+                    }
+                },
+                "secrets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/deployments.SecretResponse"
+                    }
+                },
+                "services": {
+                    "description": "Service specs in the format of the Docker Engine API.",
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                }
+            }
+        },
+        "deployments.NetworkResponse": {
+            "type": "object",
+            "properties": {
+                "attachable": {
+                    "type": "boolean"
+                },
+                "driver": {
+                    "type": "string"
+                },
+                "driver_opts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "internal": {
+                    "type": "boolean"
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "deployments.POSTCancelRequest": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "What to do with service updates already started: let them finish (none) or roll them back.",
+                    "enum": [
+                        "none",
+                        "rollback"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/deployments.CancelAction"
+                        }
+                    ]
+                },
+                "cancelled_by": {
+                    "description": "Who cancels the deployment. Defaults to the client IP.",
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "deployments.SecretResponse": {
+            "type": "object",
+            "properties": {
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
                     }
+                },
+                "name": {
+                    "type": "string"
                 }
             }
-        }
-    },
-    "definitions": {
+        },
         "deployments.Status": {
             "type": "string",
             "enum": [
@@ -398,33 +1196,21 @@ const docTemplate = `{
                 "StatusRolledBack"
             ]
         },
-        "fiberfx.ErrorResponse": {
+        "dto.DeploymentResponse": {
             "type": "object",
             "properties": {
-                "code": {
-                    "type": "integer"
+                "automatic": {
+                    "description": "Rolled back automatically after a failure",
+                    "type": "boolean"
                 },
-                "details": {},
-                "message": {
+                "cancelled_at": {
+                    "description": "When the deployment was cancelled",
                     "type": "string"
-                }
-            }
-        },
-        "github_com_apiarycd_apiarycd_internal_server_handlers_stacks.GitAuth": {
-            "type": "object",
-            "properties": {
-                "password": {
-                    "type": "string",
-                    "format": "password"
                 },
-                "username": {
+                "cancelled_by": {
+                    "description": "Cancellation",
                     "type": "string"
-                }
-            }
-        },
-        "stacks.DeploymentResponse": {
-            "type": "object",
-            "properties": {
+                },
                 "completed_at": {
                     "description": "When deployment completed/failed",
                     "type": "string"
@@ -444,21 +1230,26 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "logs": {
-                    "description": "Logs and Metrics",
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "manifest": {
+                    "description": "Digest of the rendered manifest snapshot",
+                    "type": "string"
                 },
                 "message": {
                     "description": "Git commit message",
                     "type": "string"
                 },
+                "origin": {
+                    "description": "Deployment redeployed by a rollback",
+                    "type": "string"
+                },
                 "previous_deployments": {
                     "description": "Rollback Information",
                     "type": "string"
                 },
+                "rollback_of": {
+                    "description": "Failed deployment reverted by an automatic rollback",
+                    "type": "string"
+                },
                 "stack_id": {
                     "description": "References",
                     "type": "string"
@@ -491,14 +1282,112 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.DeploymentsResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.DeploymentResponse"
+                    }
+                },
+                "next": {
+                    "description": "Cursor of the next page, empty on the last page.",
+                    "type": "string"
+                }
+            }
+        },
+        "fiberfx.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "details": {},
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_apiarycd_apiarycd_internal_server_handlers_stacks.GitAuth": {
+            "type": "object",
+            "properties": {
+                "known_hosts": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "format": "password"
+                },
+                "ssh_key": {
+                    "description": "SSH authentication",
+                    "type": "string",
+                    "format": "password"
+                },
+                "ssh_key_passphrase": {
+                    "type": "string",
+                    "format": "password"
+                },
+                "username": {
+                    "description": "HTTPS authentication",
+                    "type": "string"
+                }
+            }
+        },
+        "reconciler.Action": {
+            "type": "string",
+            "enum": [
+                "create",
+                "update",
+                "remove"
+            ],
+            "x-enum-varnames": [
+                "ActionCreate",
+                "ActionUpdate",
+                "ActionRemove"
+            ]
+        },
+        "stacks.FieldChangeResponse": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "description": "Value in the cluster"
+                },
+                "desired": {
+                    "description": "Desired value, unset if removed"
+                },
+                "path": {
+                    "description": "Dot separated path of the field in the Swarm service spec",
+                    "type": "string"
+                }
+            }
+        },
         "stacks.PATCHRequest": {
             "type": "object",
             "properties": {
+                "auto_rollback": {
+                    "description": "Redeploy the last successful deployment automatically when a deployment fails.",
+                    "type": "boolean"
+                },
                 "compose_path": {
                     "type": "string",
                     "maxLength": 255,
                     "minLength": 1
                 },
+                "deploy_policy": {
+                    "description": "What to do with a deployment requested while another one is in progress.",
+                    "type": "string",
+                    "enum": [
+                        "queue",
+                        "reject"
+                    ]
+                },
+                "deploy_timeout": {
+                    "description": "Rollout timeout in seconds. 0 uses the server default.",
+                    "type": "integer",
+                    "maximum": 86400,
+                    "minimum": 0
+                },
                 "description": {
                     "type": "string",
                     "maxLength": 500
@@ -525,12 +1414,39 @@ const docTemplate = `{
                     "additionalProperties": {
                         "type": "string"
                     }
+                },
+                "webhook_secret": {
+                    "description": "Shared secret for push webhooks. Write-only.",
+                    "type": "string",
+                    "format": "password",
+                    "maxLength": 255
                 }
             }
         },
         "stacks.POSTDeployRequest": {
             "type": "object",
             "properties": {
+                "ref": {
+                    "description": "Branch, tag or commit SHA to deploy. Defaults to the stack branch.",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "stacks.POSTPlanRequest": {
+            "type": "object",
+            "properties": {
+                "ref": {
+                    "description": "Branch, tag or commit SHA to plan. Defaults to the stack branch.",
+                    "type": "string",
+                    "maxLength": 255
+                },
                 "variables": {
                     "type": "object",
                     "additionalProperties": {
@@ -539,6 +1455,23 @@ const docTemplate = `{
                 }
             }
         },
+        "stacks.POSTRenameRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "migrate": {
+                    "description": "Move the Swarm resources of the stack to the namespace of the new name\nby redeploying it.",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                }
+            }
+        },
         "stacks.POSTRequest": {
             "type": "object",
             "required": [
@@ -548,11 +1481,29 @@ const docTemplate = `{
                 "name"
             ],
             "properties": {
+                "auto_rollback": {
+                    "description": "Redeploy the last successful deployment automatically when a deployment fails.",
+                    "type": "boolean"
+                },
                 "compose_path": {
                     "type": "string",
                     "maxLength": 255,
                     "minLength": 1
                 },
+                "deploy_policy": {
+                    "description": "What to do with a deployment requested while another one is in progress. Defaults to queue.",
+                    "type": "string",
+                    "enum": [
+                        "queue",
+                        "reject"
+                    ]
+                },
+                "deploy_timeout": {
+                    "description": "Rollout timeout in seconds. 0 uses the server default.",
+                    "type": "integer",
+                    "maximum": 86400,
+                    "minimum": 0
+                },
                 "description": {
                     "type": "string",
                     "maxLength": 500
@@ -584,6 +1535,94 @@ const docTemplate = `{
                     "additionalProperties": {
                         "type": "string"
                     }
+                },
+                "webhook_secret": {
+                    "description": "Shared secret for push webhooks. Write-only.",
+                    "type": "string",
+                    "format": "password",
+                    "maxLength": 255
+                }
+            }
+        },
+        "stacks.POSTRollbackRequest": {
+            "type": "object",
+            "properties": {
+                "target": {
+                    "description": "Deployment to roll back to. Defaults to the successful deployment preceding the last applied one.",
+                    "type": "string"
+                }
+            }
+        },
+        "stacks.PlanResponse": {
+            "type": "object",
+            "properties": {
+                "git_ref": {
+                    "description": "Branch, tag, or commit",
+                    "type": "string"
+                },
+                "message": {
+                    "description": "Git commit message",
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                },
+                "services": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/stacks.ServiceChangeResponse"
+                    }
+                },
+                "version": {
+                    "description": "Git commit SHA",
+                    "type": "string"
+                }
+            }
+        },
+        "stacks.RenameResponse": {
+            "type": "object",
+            "properties": {
+                "deployment": {
+                    "description": "Redeployment migrating the namespace, if any.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.DeploymentResponse"
+                        }
+                    ]
+                },
+                "stack": {
+                    "$ref": "#/definitions/stacks.StackResponse"
+                }
+            }
+        },
+        "stacks.ServiceChangeResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "enum": [
+                        "create",
+                        "update",
+                        "remove"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/reconciler.Action"
+                        }
+                    ]
+                },
+                "fields": {
+                    "description": "Changed fields of services to update",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/stacks.FieldChangeResponse"
+                    }
+                },
+                "id": {
+                    "description": "Swarm service ID of existing services",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
                 }
             }
         },
@@ -596,6 +1635,10 @@ const docTemplate = `{
                 "name"
             ],
             "properties": {
+                "auto_rollback": {
+                    "description": "Redeploy the last successful deployment automatically when a deployment fails.",
+                    "type": "boolean"
+                },
                 "compose_path": {
                     "type": "string",
                     "maxLength": 255,
@@ -604,6 +1647,20 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
+                "deploy_policy": {
+                    "description": "What to do with a deployment requested while another one is in progress. Defaults to queue.",
+                    "type": "string",
+                    "enum": [
+                        "queue",
+                        "reject"
+                    ]
+                },
+                "deploy_timeout": {
+                    "description": "Rollout timeout in seconds. 0 uses the server default.",
+                    "type": "integer",
+                    "maximum": 86400,
+                    "minimum": 0
+                },
                 "description": {
                     "type": "string",
                     "maxLength": 500
@@ -636,9 +1693,20 @@ const docTemplate = `{
                     "maxLength": 100,
                     "minLength": 1
                 },
+                "previous_namespace": {
+                    "description": "Namespace left by a rename, removed by the next deployment.",
+                    "type": "string"
+                },
                 "status": {
                     "type": "string"
                 },
+                "suspended_replicas": {
+                    "description": "Replica counts restored on resume by service name.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
                 "updated_at": {
                     "type": "string"
                 },
@@ -649,6 +1717,90 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "webhooks.Provider": {
+            "type": "string",
+            "enum": [
+                "github",
+                "gitlab",
+                "gitea",
+                "forgejo"
+            ],
+            "x-enum-varnames": [
+                "ProviderGitHub",
+                "ProviderGitLab",
+                "ProviderGitea",
+                "ProviderForgejo"
+            ]
+        },
+        "webhooks.PushResponse": {
+            "type": "object",
+            "properties": {
+                "event": {
+                    "description": "Provider specific event name",
+                    "type": "string"
+                },
+                "provider": {
+                    "description": "github, gitlab, gitea, forgejo",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/webhooks.Provider"
+                        }
+                    ]
+                },
+                "results": {
+                    "description": "Per-stack results",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webhooks.StackResult"
+                    }
+                }
+            }
+        },
+        "webhooks.ResultStatus": {
+            "type": "string",
+            "enum": [
+                "triggered",
+                "skipped",
+                "failed"
+            ],
+            "x-enum-comments": {
+                "ResultFailed": "Deployment could not be triggered",
+                "ResultSkipped": "Stack is inactive",
+                "ResultTriggered": "Deployment was triggered"
+            },
+            "x-enum-varnames": [
+                "ResultTriggered",
+                "ResultSkipped",
+                "ResultFailed"
+            ]
+        },
+        "webhooks.StackResult": {
+            "type": "object",
+            "properties": {
+                "deployment_id": {
+                    "description": "Triggered deployment",
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Error message if failed",
+                    "type": "string"
+                },
+                "stack_id": {
+                    "type": "string"
+                },
+                "stack_name": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "triggered, skipped, failed",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/webhooks.ResultStatus"
+                        }
+                    ]
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,133 @@
+package deployments
+
+import (
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/compose"
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/server/handlers/dto"
+	"github.com/google/uuid"
+	"github.com/moby/moby/api/types/swarm"
+	"github.com/samber/lo"
+)
+
+// POSTCancelRequest represents the request payload for cancelling a deployment.
+type POSTCancelRequest struct {
+	// Who cancels the deployment. Defaults to the client IP.
+	CancelledBy string `json:"cancelled_by,omitempty" validate:"max=100"`
+	// What to do with service updates already started: let them finish (none) or roll them back.
+	Action deployments.CancelAction `json:"action,omitempty" validate:"omitempty,oneof=none rollback" enums:"none,rollback"`
+}
+
+// GETLogsRequest represents the query parameters for reading deployment logs.
+type GETLogsRequest struct {
+	// Return lines after this sequence number.
+	After uint64 `query:"after"`
+	// Maximum number of lines per page.
+	Limit int `query:"limit" validate:"omitempty,min=1,max=1000"`
+	// Stream new lines as Server-Sent Events until the deployment finishes.
+	Follow bool `query:"follow"`
+}
+
+// GETListRequest represents the query parameters for listing deployments.
+type GETListRequest struct {
+	dto.DeploymentsQuery
+
+	// Only deployments of this stack.
+	StackID string `query:"stack_id" validate:"omitempty,uuid"`
+}
+
+func (r *GETListRequest) toDomain() (deployments.Filter, deployments.PageRequest) {
+	var stackID *uuid.UUID
+	if id, err := uuid.Parse(r.StackID); err == nil {
+		stackID = &id
+	}
+
+	return r.ToDomain(stackID)
+}
+
+type LogEntryResponse struct {
+	Seq     uint64               `json:"seq"`
+	Time    time.Time            `json:"time"`
+	Level   deployments.LogLevel `json:"level"`
+	Message string               `json:"message"`
+}
+
+type LogsResponse struct {
+	Items []LogEntryResponse `json:"items"`
+	// Sequence number to pass as after to get the next page.
+	Next uint64 `json:"next"`
+}
+
+// ManifestResponse is the snapshot of the Swarm resources applied by a
+// deployment. Secret data is not included.
+type ManifestResponse struct {
+	Digest    string `json:"digest"`
+	Namespace string `json:"namespace"`
+	// Service specs in the format of the Docker Engine API.
+	Services []swarm.ServiceSpec `json:"services" swaggertype:"array,object"`
+	Networks []NetworkResponse   `json:"networks"`
+	// Config specs in the format of the Docker Engine API.
+	Configs []swarm.ConfigSpec `json:"configs" swaggertype:"array,object"`
+	Secrets []SecretResponse   `json:"secrets"`
+}
+
+type NetworkResponse struct {
+	Name       string            `json:"name"`
+	Driver     string            `json:"driver"`
+	DriverOpts map[string]string `json:"driver_opts,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Attachable bool              `json:"attachable"`
+	Internal   bool              `json:"internal"`
+}
+
+type SecretResponse struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func newManifestResponse(domain *deployments.Manifest) ManifestResponse {
+	return ManifestResponse{
+		Digest:    domain.Digest,
+		Namespace: domain.Stack.Namespace,
+		Services:  domain.Stack.Services,
+		Networks: lo.Map(domain.Stack.Networks, func(n compose.Network, _ int) NetworkResponse {
+			return NetworkResponse{
+				Name:       n.Name,
+				Driver:     n.Driver,
+				DriverOpts: n.DriverOpts,
+				Labels:     n.Labels,
+				Attachable: n.Attachable,
+				Internal:   n.Internal,
+			}
+		}),
+		Configs: domain.Stack.Configs,
+		Secrets: lo.Map(domain.Stack.Secrets, func(s swarm.SecretSpec, _ int) SecretResponse {
+			return SecretResponse{
+				Name:   s.Name,
+				Labels: s.Labels,
+			}
+		}),
+	}
+}
+
+func newLogEntryResponse(domain deployments.LogEntry) LogEntryResponse {
+	return LogEntryResponse{
+		Seq:     domain.Seq,
+		Time:    domain.Time,
+		Level:   domain.Level,
+		Message: domain.Message,
+	}
+}
+
+func newLogsResponse(entries []deployments.LogEntry, after uint64) LogsResponse {
+	next := after
+	if len(entries) > 0 {
+		next = entries[len(entries)-1].Seq
+	}
+
+	return LogsResponse{
+		Items: lo.Map(entries, func(e deployments.LogEntry, _ int) LogEntryResponse { return newLogEntryResponse(e) }),
+		Next:  next,
+	}
+}
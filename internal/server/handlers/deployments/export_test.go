@@ -0,0 +1,13 @@
+package deployments
+
+import (
+	"bufio"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// StreamLogs exposes streamLogs to the tests.
+func (h *Handler) StreamLogs(ctx context.Context, w *bufio.Writer, id uuid.UUID, after uint64, limit int) {
+	h.streamLogs(ctx, w, id, after, limit)
+}
@@ -0,0 +1,282 @@
+package deployments
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/server/handlers/dto"
+	"github.com/go-core-fx/fiberfx/handler"
+	"github.com/go-core-fx/fiberfx/validation"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultLogsLimit = 100
+	eventStreamMIME  = "text/event-stream"
+)
+
+type Handler struct {
+	deploymentsSvc *deployments.Service
+
+	validator *validator.Validate
+	logger    *zap.Logger
+}
+
+func NewHandler(
+	deploymentsSvc *deployments.Service,
+	validator *validator.Validate,
+	logger *zap.Logger,
+) handler.Handler {
+	return &Handler{
+		deploymentsSvc: deploymentsSvc,
+
+		validator: validator,
+		logger:    logger,
+	}
+}
+
+// Register implements handler.Handler.
+func (h *Handler) Register(r fiber.Router) {
+	r = r.Group("/deployments")
+
+	r.Use(h.errorsHandler)
+	// GET    /api/v1/deployments              # List deployments
+	r.Get("/", h.list)
+	// GET    /api/v1/deployments/{id}         # Get deployment details
+	r.Get("/:id", h.get)
+	// POST   /api/v1/deployments/{id}/cancel  # Cancel deployment
+	r.Post("/:id/cancel", validation.DecorateWithBodyEx(h.validator, h.cancel))
+	// GET    /api/v1/deployments/{id}/logs    # Deployment logs
+	r.Get("/:id/logs", h.logs)
+	// GET    /api/v1/deployments/{id}/manifest # Deployment manifest snapshot
+	r.Get("/:id/manifest", h.manifest)
+}
+
+//	@Summary		List deployments
+//	@Description	List deployments of all stacks, newest first unless requested otherwise. Pass the next cursor of a page to get the following one.
+//	@Tags			deployments
+//	@Produce		json
+//	@Param			query	query		GETListRequest	false	"Filter and pagination"
+//	@Success		200		{object}	dto.DeploymentsResponse
+//	@Failure		400		{object}	fiberfx.ErrorResponse
+//	@Router			/deployments [get]
+//
+// List deployments.
+func (h *Handler) list(c *fiber.Ctx) error {
+	req := new(GETListRequest)
+	if qErr := c.QueryParser(req); qErr != nil {
+		return fiber.NewError(fiber.StatusBadRequest, qErr.Error())
+	}
+	if vErr := h.validator.Struct(req); vErr != nil {
+		return validation.NewErrors(vErr)
+	}
+
+	filter, page := req.toDomain()
+	deps, err := h.deploymentsSvc.List(c.Context(), filter, page)
+	if err != nil {
+		return fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	return c.JSON(dto.NewDeploymentsResponse(deps))
+}
+
+//	@Summary		Get a deployment
+//	@Description	Get details of a deployment
+//	@Tags			deployments
+//	@Produce		json
+//	@Param			id	path		string	true	"Deployment ID"
+//	@Success		200	{object}	dto.DeploymentResponse
+//	@Failure		400	{object}	fiberfx.ErrorResponse
+//	@Failure		404	{object}	fiberfx.ErrorResponse
+//	@Router			/deployments/{id} [get]
+//
+// Get a deployment.
+func (h *Handler) get(c *fiber.Ctx) error {
+	id, err := getDeploymentID(c)
+	if err != nil {
+		return err
+	}
+
+	d, err := h.deploymentsSvc.Get(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+
+	return c.JSON(dto.NewDeploymentResponse(d))
+}
+
+//	@Summary		Cancel a deployment
+//	@Description	Cancel a pending or running deployment. A running deployment stops mutating the cluster; service updates it has already started are rolled back on request and otherwise left to finish.
+//	@Tags			deployments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Deployment ID"
+//	@Param			cancel	body		POSTCancelRequest	false	"Cancel request"
+//	@Success		200		{object}	dto.DeploymentResponse
+//	@Failure		400		{object}	fiberfx.ErrorResponse
+//	@Failure		404		{object}	fiberfx.ErrorResponse
+//	@Failure		409		{object}	fiberfx.ErrorResponse
+//	@Router			/deployments/{id}/cancel [post]
+//
+// Cancel a deployment.
+func (h *Handler) cancel(c *fiber.Ctx, req *POSTCancelRequest) error {
+	id, err := getDeploymentID(c)
+	if err != nil {
+		return err
+	}
+
+	cancelledBy := req.CancelledBy
+	if cancelledBy == "" {
+		cancelledBy = c.IP()
+	}
+
+	action := req.Action
+	if action == "" {
+		action = deployments.CancelActionNone
+	}
+
+	d, err := h.deploymentsSvc.Cancel(c.Context(), id, deployments.CancelRequest{
+		CancelledBy: cancelledBy,
+		Action:      action,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cancel deployment: %w", err)
+	}
+
+	return c.JSON(dto.NewDeploymentResponse(d))
+}
+
+//	@Summary		Get deployment logs
+//	@Description	Get a page of the log of a deployment. With follow, or when requested with Accept: text/event-stream, the log is streamed as Server-Sent Events until the deployment finishes:
+//	@Description	each line is a "log" event whose ID is its sequence number, and a final "end" event carries the deployment. Streams resume after Last-Event-ID.
+//	@Tags			deployments
+//	@Produce		json,text/event-stream
+//	@Param			id		path		string			true	"Deployment ID"
+//	@Param			query	query		GETLogsRequest	false	"Pagination"
+//	@Success		200		{object}	LogsResponse
+//	@Failure		400		{object}	fiberfx.ErrorResponse
+//	@Failure		404		{object}	fiberfx.ErrorResponse
+//	@Router			/deployments/{id}/logs [get]
+//
+// Get deployment logs.
+func (h *Handler) logs(c *fiber.Ctx) error {
+	id, err := getDeploymentID(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(GETLogsRequest)
+	if qErr := c.QueryParser(req); qErr != nil {
+		return fiber.NewError(fiber.StatusBadRequest, qErr.Error())
+	}
+	if vErr := h.validator.Struct(req); vErr != nil {
+		return validation.NewErrors(vErr)
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultLogsLimit
+	}
+
+	if req.Follow || c.Get(fiber.HeaderAccept) == eventStreamMIME {
+		return h.followLogs(c, id, req.After, limit)
+	}
+
+	entries, err := h.deploymentsSvc.ListLogs(c.Context(), id, req.After, limit)
+	if err != nil {
+		return fmt.Errorf("failed to list deployment logs: %w", err)
+	}
+
+	return c.JSON(newLogsResponse(entries, req.After))
+}
+
+func (h *Handler) followLogs(c *fiber.Ctx, id uuid.UUID, after uint64, limit int) error {
+	// Fail with a regular response before switching to a stream.
+	if _, err := h.deploymentsSvc.Get(c.Context(), id); err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+
+	if lastEventID := c.Get("Last-Event-ID"); lastEventID != "" {
+		seq, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid Last-Event-ID")
+		}
+		after = seq
+	}
+
+	c.Set(fiber.HeaderContentType, eventStreamMIME)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	// The request context is only valid until the handler returns, but its
+	// Done channel is closed when the server shuts down.
+	shutdown := c.Context().Done()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-shutdown:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		h.streamLogs(ctx, w, id, after, limit)
+	})
+
+	return nil
+}
+
+//	@Summary		Download a deployment manifest
+//	@Description	Download the rendered manifest snapshot applied by a deployment: the Swarm service specs, networks, configs and secrets with variables interpolated. Secret data is omitted.
+//	@Tags			deployments
+//	@Produce		json
+//	@Param			id	path		string	true	"Deployment ID"
+//	@Success		200	{object}	ManifestResponse
+//	@Failure		400	{object}	fiberfx.ErrorResponse
+//	@Failure		404	{object}	fiberfx.ErrorResponse
+//	@Router			/deployments/{id}/manifest [get]
+//
+// Download a deployment manifest.
+func (h *Handler) manifest(c *fiber.Ctx) error {
+	id, err := getDeploymentID(c)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := h.deploymentsSvc.GetManifest(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment manifest: %w", err)
+	}
+
+	c.Attachment(strings.TrimPrefix(manifest.Digest, "sha256:") + ".json")
+	return c.JSON(newManifestResponse(manifest))
+}
+
+func (h *Handler) errorsHandler(c *fiber.Ctx) error {
+	err := c.Next()
+	if err == nil {
+		return nil
+	}
+
+	switch {
+	case errors.Is(err, deployments.ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, deployments.ErrNotAllowed):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, deployments.ErrConflict):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+
+	return err //nolint:wrapcheck //already wrapped
+}
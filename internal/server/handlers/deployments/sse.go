@@ -0,0 +1,121 @@
+package deployments
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/server/handlers/dto"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const keepAliveInterval = 15 * time.Second
+
+// streamLogs writes the log of a deployment as Server-Sent Events until the
+// deployment is finished, ctx is cancelled or the client disconnects.
+func (h *Handler) streamLogs(ctx context.Context, w *bufio.Writer, id uuid.UUID, after uint64, limit int) {
+	logger := h.logger.With(zap.String("deployment_id", id.String()))
+
+	// A failed write means the client has gone away.
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	flush := func() bool {
+		if err := w.Flush(); err != nil {
+			cancel(err)
+			return false
+		}
+		return true
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	unwatch := func() {}
+	defer func() { unwatch() }()
+
+	finished := false
+	for ctx.Err() == nil {
+		// Subscribe before reading so that no line is missed in between. No
+		// line follows the final status, so there is nothing to wait for once
+		// the deployment is finished.
+		var wake <-chan struct{}
+		if !finished {
+			unwatch()
+			wake, unwatch = h.deploymentsSvc.WatchLogs(id)
+		}
+
+		entries, err := h.deploymentsSvc.ListLogs(ctx, id, after, limit)
+		if err != nil {
+			logger.Error("failed to list deployment logs", zap.Error(err))
+			return
+		}
+
+		for _, e := range entries {
+			if wrErr := writeEvent(w, fmt.Sprint(e.Seq), "log", newLogEntryResponse(e)); wrErr != nil {
+				cancel(wrErr)
+				return
+			}
+			after = e.Seq
+		}
+		if !flush() {
+			return
+		}
+
+		if len(entries) == limit {
+			continue
+		}
+
+		d, err := h.deploymentsSvc.Get(ctx, id)
+		if err != nil {
+			logger.Error("failed to get deployment", zap.Error(err))
+			return
+		}
+
+		if finished {
+			if wrErr := writeEvent(w, "", "end", dto.NewDeploymentResponse(d)); wrErr == nil {
+				flush()
+			}
+			return
+		}
+
+		if d.IsFinished() {
+			// Read once more for the lines recorded along with the final status.
+			finished = true
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-wake:
+		case <-keepAlive.C:
+			if _, wrErr := w.WriteString(": keep-alive\n\n"); wrErr != nil {
+				cancel(wrErr)
+				return
+			}
+			flush()
+		}
+	}
+}
+
+// writeEvent writes a Server-Sent Event with a JSON payload.
+func writeEvent(w *bufio.Writer, id, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if id != "" {
+		if _, wrErr := fmt.Fprintf(w, "id: %s\n", id); wrErr != nil {
+			return fmt.Errorf("failed to write event: %w", wrErr)
+		}
+	}
+
+	if _, wrErr := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload); wrErr != nil {
+		return fmt.Errorf("failed to write event: %w", wrErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package deployments_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	handlers "github.com/apiarycd/apiarycd/internal/server/handlers/deployments"
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// env is a deployments handler over an in-memory database with a deployment
+// of the given status and log lines.
+type env struct {
+	handler *handlers.Handler
+	id      uuid.UUID
+}
+
+func newEnv(t *testing.T, status deployments.Status, lines int) *env {
+	t.Helper()
+
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	logger := zap.NewNop()
+	stacksSvc := stacks.NewService(stacks.NewRepository(db), logger)
+	repo := deployments.NewRepository(db)
+	logs := deployments.NewLogRepository(db)
+	svc := deployments.NewService(
+		deployments.Config{},
+		repo,
+		logs,
+		deployments.NewManifestRepository(db),
+		stacksSvc,
+		nil,
+		nil,
+		logger,
+	)
+
+	stack, err := stacksSvc.Create(t.Context(), stacks.StackDraft{
+		Name:        "app",
+		GitURL:      "https://example.com/app.git",
+		GitBranch:   "main",
+		ComposePath: "docker-compose.yml",
+	})
+	if err != nil {
+		t.Fatalf("failed to create stack: %v", err)
+	}
+
+	d, err := repo.Create(t.Context(), &deployments.DeploymentDraft{
+		StackID: stack.ID,
+		Version: "0123456789abcdef0123456789abcdef01234567",
+		GitRef:  "main",
+		Status:  status,
+	})
+	if err != nil {
+		t.Fatalf("failed to create deployment: %v", err)
+	}
+	for range lines {
+		if _, appErr := logs.Append(t.Context(), d.ID, deployments.LogLevelInfo, "line"); appErr != nil {
+			t.Fatalf("failed to append log: %v", appErr)
+		}
+	}
+
+	h, ok := handlers.NewHandler(svc, validator.New(), logger).(*handlers.Handler)
+	if !ok {
+		t.Fatal("NewHandler() does not return a *Handler")
+	}
+
+	return &env{handler: h, id: d.ID}
+}
+
+// stream runs StreamLogs in the background and returns a channel closed when
+// it returns.
+func (e *env) stream(ctx context.Context, w *bufio.Writer) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.handler.StreamLogs(ctx, w, e.id, 0, 2)
+	}()
+	return done
+}
+
+func wait(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("StreamLogs() did not return")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset by peer")
+}
+
+func TestHandlerStreamLogsFinished(t *testing.T) {
+	t.Parallel()
+
+	e := newEnv(t, deployments.StatusSuccess, 3)
+
+	var buf bytes.Buffer
+	wait(t, e.stream(t.Context(), bufio.NewWriter(&buf)))
+
+	out := buf.String()
+	for _, want := range []string{"id: 1\nevent: log\n", "id: 2\nevent: log\n", "id: 3\nevent: log\n", "event: end\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("stream does not contain %q:\n%s", want, out)
+		}
+	}
+	if !strings.HasSuffix(out, "\n\n") || strings.Index(out, "event: end") < strings.Index(out, "id: 3") {
+		t.Errorf("stream does not end with the end event:\n%s", out)
+	}
+}
+
+func TestHandlerStreamLogsCancelled(t *testing.T) {
+	t.Parallel()
+
+	e := newEnv(t, deployments.StatusRunning, 1)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var buf bytes.Buffer
+	done := e.stream(ctx, bufio.NewWriter(&buf))
+
+	// The stream of a running deployment waits for more lines until the
+	// server shuts down.
+	select {
+	case <-done:
+		t.Fatal("StreamLogs() returned while the deployment is running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	wait(t, done)
+
+	if out := buf.String(); !strings.Contains(out, "id: 1\nevent: log\n") || strings.Contains(out, "event: end") {
+		t.Errorf("stream = %q, want the log line without the end event", out)
+	}
+}
+
+func TestHandlerStreamLogsClientGone(t *testing.T) {
+	t.Parallel()
+
+	e := newEnv(t, deployments.StatusRunning, 1)
+
+	wait(t, e.stream(t.Context(), bufio.NewWriter(failingWriter{})))
+}
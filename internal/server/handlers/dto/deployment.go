@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/google/uuid"
+)
+
+// DeploymentResponse is a deployment as returned by the stacks and
+// deployments handlers.
+type DeploymentResponse struct {
+	ID uuid.UUID `json:"id"`
+
+	// References
+	StackID uuid.UUID `json:"stack_id"`
+
+	// Deployment Details
+	Version string `json:"version"` // Git commit SHA or tag
+	GitRef  string `json:"git_ref"` // Branch, tag, or commit
+	Message string `json:"message"` // Git commit message
+
+	// Deployment Configuration
+	Variables map[string]string `json:"variables"`          // Deployment-specific variables
+	Manifest  string            `json:"manifest,omitempty"` // Digest of the rendered manifest snapshot
+
+	// Status
+	Status      deployments.Status `json:"status"`       // pending, running, success, failed, cancelled
+	StartedAt   *time.Time         `json:"started_at"`   // When deployment started
+	CompletedAt *time.Time         `json:"completed_at"` // When deployment completed/failed
+	Error       string             `json:"error"`        // Error message if failed
+
+	// Cancellation
+	CancelledBy string     `json:"cancelled_by,omitempty"` // Who cancelled the deployment
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"` // When the deployment was cancelled
+
+	// Rollback Information
+	PreviousDeployment *uuid.UUID `json:"previous_deployments"`  // Previous deployment ID for rollback
+	Origin             *uuid.UUID `json:"origin,omitempty"`      // Deployment redeployed by a rollback
+	RollbackOf         *uuid.UUID `json:"rollback_of,omitempty"` // Failed deployment reverted by an automatic rollback
+	Automatic          bool       `json:"automatic"`             // Rolled back automatically after a failure
+
+	// Timestamps
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewDeploymentResponse(domain *deployments.Deployment) DeploymentResponse {
+	return DeploymentResponse{
+		ID:                 domain.ID,
+		StackID:            domain.StackID,
+		Version:            domain.Version,
+		GitRef:             domain.GitRef,
+		Message:            domain.Message,
+		Variables:          domain.Variables,
+		Manifest:           domain.Manifest,
+		Status:             domain.Status,
+		StartedAt:          domain.StartedAt,
+		CompletedAt:        domain.CompletedAt,
+		Error:              domain.Error,
+		CancelledBy:        domain.CancelledBy,
+		CancelledAt:        domain.CancelledAt,
+		PreviousDeployment: domain.PreviousDeployment,
+		Origin:             domain.Origin,
+		RollbackOf:         domain.RollbackOf,
+		Automatic:          domain.RollbackOf != nil,
+		CreatedAt:          domain.CreatedAt,
+		UpdatedAt:          domain.UpdatedAt,
+	}
+}
@@ -0,0 +1,73 @@
+package dto
+
+import (
+	"strings"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+)
+
+// DeploymentsQuery represents the query parameters for listing deployments.
+type DeploymentsQuery struct {
+	// Comma separated statuses to include.
+	Status string `query:"status" validate:"omitempty,max=200"`
+	// Only deployments created at or after this time (RFC 3339).
+	Since string `query:"since" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	// Only deployments created before this time (RFC 3339).
+	Until string `query:"until" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	// Sort order by creation time. Defaults to newest first.
+	Order string `query:"order" validate:"omitempty,oneof=asc desc" enums:"asc,desc"`
+	// Next cursor of the previous page.
+	Cursor string `query:"cursor" validate:"omitempty,max=200"`
+	// Maximum number of deployments per page.
+	Limit int `query:"limit" validate:"omitempty,min=1,max=200"`
+}
+
+// ToDomain returns the filter and page requested, restricted to the
+// deployments of a stack if stackID is set.
+func (q *DeploymentsQuery) ToDomain(stackID *uuid.UUID) (deployments.Filter, deployments.PageRequest) {
+	filter := deployments.Filter{
+		StackID:  stackID,
+		Statuses: nil,
+		Since:    parseTime(q.Since),
+		Until:    parseTime(q.Until),
+	}
+	for status := range strings.SplitSeq(q.Status, ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			filter.Statuses = append(filter.Statuses, deployments.Status(status))
+		}
+	}
+
+	return filter, deployments.PageRequest{
+		Order:  deployments.SortOrder(q.Order),
+		Cursor: q.Cursor,
+		Limit:  q.Limit,
+	}
+}
+
+type DeploymentsResponse struct {
+	Items []DeploymentResponse `json:"items"`
+	// Cursor of the next page, empty on the last page.
+	Next string `json:"next,omitempty"`
+}
+
+func NewDeploymentsResponse(page *deployments.Page) DeploymentsResponse {
+	return DeploymentsResponse{
+		Items: lo.Map(page.Items, func(d deployments.Deployment, _ int) DeploymentResponse {
+			return NewDeploymentResponse(&d)
+		}),
+		Next: page.Next,
+	}
+}
+
+// parseTime parses a validated RFC 3339 time, nil if empty.
+func parseTime(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil
+	}
+
+	return &t
+}
@@ -0,0 +1,73 @@
+package dto_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/server/handlers/dto"
+	"github.com/google/uuid"
+)
+
+func TestDeploymentsQueryToDomain(t *testing.T) {
+	t.Parallel()
+
+	stackID := uuid.New()
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    dto.DeploymentsQuery
+		stackID  *uuid.UUID
+		statuses []deployments.Status
+		since    *time.Time
+		page     deployments.PageRequest
+	}{
+		{
+			name:  "empty",
+			query: dto.DeploymentsQuery{},
+			page:  deployments.PageRequest{},
+		},
+		{
+			name:     "statuses are trimmed and empty ones skipped",
+			query:    dto.DeploymentsQuery{Status: " failed,,success "},
+			statuses: []deployments.Status{deployments.StatusFailed, deployments.StatusSuccess},
+		},
+		{
+			name:    "stack and time range",
+			query:   dto.DeploymentsQuery{Since: "2026-01-02T03:04:05Z"},
+			stackID: &stackID,
+			since:   &since,
+		},
+		{
+			name:  "page",
+			query: dto.DeploymentsQuery{Order: "asc", Cursor: "next", Limit: 10},
+			page:  deployments.PageRequest{Order: deployments.SortOrderAsc, Cursor: "next", Limit: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			filter, page := tt.query.ToDomain(tt.stackID)
+
+			if filter.StackID != tt.stackID {
+				t.Errorf("StackID = %v, want %v", filter.StackID, tt.stackID)
+			}
+			if !slices.Equal(filter.Statuses, tt.statuses) {
+				t.Errorf("Statuses = %v, want %v", filter.Statuses, tt.statuses)
+			}
+			if (filter.Since == nil) != (tt.since == nil) || (tt.since != nil && !filter.Since.Equal(*tt.since)) {
+				t.Errorf("Since = %v, want %v", filter.Since, tt.since)
+			}
+			if filter.Until != nil {
+				t.Errorf("Until = %v, want nil", filter.Until)
+			}
+			if page != tt.page {
+				t.Errorf("page = %+v, want %+v", page, tt.page)
+			}
+		})
+	}
+}
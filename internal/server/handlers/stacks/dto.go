@@ -1,25 +1,45 @@
 package stacks
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/apiarycd/apiarycd/internal/server/handlers/dto"
+	"github.com/apiarycd/apiarycd/internal/stacks"
 	"github.com/google/uuid"
 )
 
+// GitAuth holds repository credentials. It is write-only and never returned in responses.
 type GitAuth struct {
+	// HTTPS authentication
 	Username string `json:"username"`
 	Password string `json:"password" format:"password"`
+
+	// SSH authentication
+	SSHKey           string `json:"ssh_key,omitempty"            format:"password"`
+	SSHKeyPassphrase string `json:"ssh_key_passphrase,omitempty" format:"password"`
+	KnownHosts       string `json:"known_hosts,omitempty"`
 }
 
+// Stack is the definition of a stack. Its validation matches
+// stacks.StackDraft.Validate, which declarative definitions go through.
 type Stack struct {
 	Name        string `json:"name"        validate:"required,min=1,max=100"`
 	Description string `json:"description" validate:"max=500"`
-	GitURL      string `json:"git_url"     validate:"required,url"`
+	GitURL      string `json:"git_url"     validate:"required,url|startswith=git@"`
 	GitBranch   string `json:"git_branch"  validate:"required,min=1,max=100"`
 
 	ComposePath string            `json:"compose_path"        validate:"required,min=1,max=255"`
 	Variables   map[string]string `json:"variables,omitempty"`
 	Labels      map[string]string `json:"labels,omitempty"`
+
+	// Rollout timeout in seconds. 0 uses the server default.
+	DeployTimeout int `json:"deploy_timeout,omitempty" validate:"min=0,max=86400"`
+	// What to do with a deployment requested while another one is in progress. Defaults to queue.
+	DeployPolicy string `json:"deploy_policy,omitempty" validate:"omitempty,oneof=queue reject" enums:"queue,reject"`
+	// Redeploy the last successful deployment automatically when a deployment fails.
+	AutoRollback bool `json:"auto_rollback,omitempty"`
 }
 
 // POSTRequest represents the request payload for creating a stack.
@@ -27,17 +47,72 @@ type POSTRequest struct {
 	Stack
 
 	GitAuth GitAuth `json:"git_auth,omitempty"`
+	// Shared secret for push webhooks. Write-only.
+	WebhookSecret string `json:"webhook_secret,omitempty" validate:"max=255" format:"password"`
 }
 
 // PATCHRequest represents the request payload for updating a stack.
 type PATCHRequest struct {
-	Description *string            `json:"description,omitempty"  validate:"omitempty,max=500"`
-	GitURL      *string            `json:"git_url,omitempty"      validate:"omitempty,url"`
-	GitBranch   *string            `json:"git_branch,omitempty"   validate:"omitempty,min=1,max=100"`
-	GitAuth     *GitAuth           `json:"git_auth"`
-	ComposePath *string            `json:"compose_path,omitempty" validate:"omitempty,min=1,max=255"`
-	Variables   *map[string]string `json:"variables,omitempty"`
-	Labels      *map[string]string `json:"labels,omitempty"`
+	Description *string  `json:"description,omitempty"  validate:"omitempty,max=500"`
+	GitURL      *string  `json:"git_url,omitempty"      validate:"omitempty,url|startswith=git@"`
+	GitBranch   *string  `json:"git_branch,omitempty"   validate:"omitempty,min=1,max=100"`
+	GitAuth     *GitAuth `json:"git_auth"`
+	// Shared secret for push webhooks. Write-only.
+	WebhookSecret *string            `json:"webhook_secret,omitempty" validate:"omitempty,max=255" format:"password"`
+	ComposePath   *string            `json:"compose_path,omitempty" validate:"omitempty,min=1,max=255"`
+	Variables     *map[string]string `json:"variables,omitempty"`
+	Labels        *map[string]string `json:"labels,omitempty"`
+	// Rollout timeout in seconds. 0 uses the server default.
+	DeployTimeout *int `json:"deploy_timeout,omitempty" validate:"omitempty,min=0,max=86400"`
+	// What to do with a deployment requested while another one is in progress.
+	DeployPolicy *string `json:"deploy_policy,omitempty" validate:"omitempty,oneof=queue reject" enums:"queue,reject"`
+	// Redeploy the last successful deployment automatically when a deployment fails.
+	AutoRollback *bool `json:"auto_rollback,omitempty"`
+}
+
+// GETListRequest represents the query parameters for listing stacks.
+type GETListRequest struct {
+	// Comma separated statuses to include.
+	Status string `query:"status" validate:"omitempty,max=200"`
+	// Label selectors, all of which must match: key=value, key!=value,
+	// key in (a,b), key notin (a,b), key or !key. Repeat the parameter or
+	// separate selectors with commas.
+	Label []string `query:"label" validate:"max=20,dive,max=500"`
+}
+
+func (r *GETListRequest) toDomain() (stacks.Filter, error) {
+	filter := stacks.Filter{
+		Statuses: nil,
+		Labels:   nil,
+	}
+	for status := range strings.SplitSeq(r.Status, ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			filter.Statuses = append(filter.Statuses, stacks.Status(status))
+		}
+	}
+	for _, label := range r.Label {
+		selectors, err := stacks.ParseLabelSelectors(label)
+		if err != nil {
+			return filter, fmt.Errorf("failed to parse label selector: %w", err)
+		}
+		filter.Labels = append(filter.Labels, selectors...)
+	}
+
+	return filter, nil
+}
+
+// DELETERequest represents the query parameters for deleting a stack.
+type DELETERequest struct {
+	// Remove the Swarm services, networks, configs and secrets of the stack.
+	Prune bool `query:"prune"`
+}
+
+// POSTRenameRequest represents the request payload for renaming a stack.
+type POSTRenameRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+	// Move the Swarm resources of the stack to the namespace of the new name
+	// by redeploying it.
+	Migrate bool `json:"migrate,omitempty"`
 }
 
 // StackResponse represents the response payload for a stack.
@@ -50,4 +125,16 @@ type StackResponse struct {
 	LastDeploy *time.Time `json:"last_deploy,omitempty"`
 	CreatedAt  time.Time  `json:"created_at"`
 	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Replica counts restored on resume by service name.
+	SuspendedReplicas map[string]uint64 `json:"suspended_replicas,omitempty"`
+	// Namespace left by a rename, removed by the next deployment.
+	PreviousNamespace string `json:"previous_namespace,omitempty"`
+}
+
+// RenameResponse represents the response payload for renaming a stack.
+type RenameResponse struct {
+	Stack StackResponse `json:"stack"`
+	// Redeployment migrating the namespace, if any.
+	Deployment *dto.DeploymentResponse `json:"deployment,omitempty"`
 }
@@ -1,63 +1,73 @@
 package stacks
 
 import (
-	"time"
-
 	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/reconciler"
 	"github.com/google/uuid"
+	"github.com/samber/lo"
 )
 
 // POSTDeployRequest represents the request payload for deploying a stack.
 type POSTDeployRequest struct {
+	// Branch, tag or commit SHA to deploy. Defaults to the stack branch.
+	Ref       string            `json:"ref,omitempty"       validate:"omitempty,max=255,printascii"`
 	Variables map[string]string `json:"variables,omitempty"`
 }
 
-type DeploymentResponse struct {
-	ID uuid.UUID `json:"id"`
-
-	// References
-	StackID uuid.UUID `json:"stack_id"`
-
-	// Deployment Details
-	Version string `json:"version"` // Git commit SHA or tag
-	GitRef  string `json:"git_ref"` // Branch, tag, or commit
-	Message string `json:"message"` // Git commit message
+// POSTPlanRequest represents the request payload for planning a deployment.
+type POSTPlanRequest struct {
+	// Branch, tag or commit SHA to plan. Defaults to the stack branch.
+	Ref       string            `json:"ref,omitempty"       validate:"omitempty,max=255,printascii"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
 
-	// Deployment Configuration
-	Variables map[string]string `json:"variables"` // Deployment-specific variables
+// POSTRollbackRequest represents the request payload for rolling back a stack.
+type POSTRollbackRequest struct {
+	// Deployment to roll back to. Defaults to the successful deployment preceding the last applied one.
+	Target *uuid.UUID `json:"target,omitempty"`
+}
 
-	// Status
-	Status      deployments.Status `json:"status"`       // pending, running, success, failed, cancelled
-	StartedAt   *time.Time         `json:"started_at"`   // When deployment started
-	CompletedAt *time.Time         `json:"completed_at"` // When deployment completed/failed
-	Error       string             `json:"error"`        // Error message if failed
+type FieldChangeResponse struct {
+	Path    string `json:"path"`              // Dot separated path of the field in the Swarm service spec
+	Current any    `json:"current,omitempty"` // Value in the cluster
+	Desired any    `json:"desired,omitempty"` // Desired value, unset if removed
+}
 
-	// Logs and Metrics
-	Logs []string `json:"logs"` // Deployment logs
+type ServiceChangeResponse struct {
+	Action reconciler.Action     `json:"action"           enums:"create,update,remove"`
+	Name   string                `json:"name"`
+	ID     string                `json:"id,omitempty"`     // Swarm service ID of existing services
+	Fields []FieldChangeResponse `json:"fields,omitempty"` // Changed fields of services to update
+}
 
-	// Rollback Information
-	PreviousDeployment *uuid.UUID `json:"previous_deployments"` // Previous deployment ID for rollback
+type PlanResponse struct {
+	Version string `json:"version"` // Git commit SHA
+	GitRef  string `json:"git_ref"` // Branch, tag, or commit
+	Message string `json:"message"` // Git commit message
 
-	// Timestamps
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Namespace string                  `json:"namespace"`
+	Services  []ServiceChangeResponse `json:"services"`
 }
 
-func newDeploymentResponse(domain *deployments.Deployment) DeploymentResponse {
-	return DeploymentResponse{
-		ID:                 domain.ID,
-		StackID:            domain.StackID,
-		Version:            domain.Version,
-		GitRef:             domain.GitRef,
-		Message:            domain.Message,
-		Variables:          domain.Variables,
-		Status:             domain.Status,
-		StartedAt:          domain.StartedAt,
-		CompletedAt:        domain.CompletedAt,
-		Error:              domain.Error,
-		Logs:               domain.Logs,
-		PreviousDeployment: domain.PreviousDeployment,
-		CreatedAt:          domain.CreatedAt,
-		UpdatedAt:          domain.UpdatedAt,
+func newPlanResponse(domain *deployments.Plan) PlanResponse {
+	return PlanResponse{
+		Version:   domain.Version,
+		GitRef:    domain.GitRef,
+		Message:   domain.Message,
+		Namespace: domain.Namespace,
+		Services: lo.Map(domain.Services, func(s reconciler.ServiceChange, _ int) ServiceChangeResponse {
+			return ServiceChangeResponse{
+				Action: s.Action,
+				Name:   s.Name,
+				ID:     s.ID,
+				Fields: lo.Map(s.Fields, func(f reconciler.FieldChange, _ int) FieldChangeResponse {
+					return FieldChangeResponse{
+						Path:    f.Path,
+						Current: f.Current,
+						Desired: f.Desired,
+					}
+				}),
+			}
+		}),
 	}
 }
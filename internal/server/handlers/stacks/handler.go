@@ -3,14 +3,18 @@ package stacks
 import (
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/apiarycd/apiarycd/internal/compose"
 	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/git"
+	"github.com/apiarycd/apiarycd/internal/reconciler"
+	"github.com/apiarycd/apiarycd/internal/server/handlers/dto"
 	"github.com/apiarycd/apiarycd/internal/stacks"
 	"github.com/go-core-fx/fiberfx/handler"
 	"github.com/go-core-fx/fiberfx/validation"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
-	"github.com/samber/lo"
 	"go.uber.org/zap"
 )
 
@@ -55,10 +59,18 @@ func (h *Handler) Register(r fiber.Router) {
 
 	// POST   /api/v1/stacks/{id}/deploy    # Deploy stack
 	r.Post("/:id/deploy", validation.DecorateWithBodyEx(h.validator, h.deploy))
+	// POST   /api/v1/stacks/{id}/plan      # Plan deployment
+	r.Post("/:id/plan", validation.DecorateWithBodyEx(h.validator, h.plan))
 	// GET    /api/v1/stacks/{id}/history   # Deployment history
 	r.Get("/:id/history", h.history)
 	// POST   /api/v1/stacks/{id}/rollback  # Rollback to previous version
-	r.Post("/:id/rollback", h.rollback)
+	r.Post("/:id/rollback", validation.DecorateWithBodyEx(h.validator, h.rollback))
+	// POST   /api/v1/stacks/{id}/suspend   # Scale down and stop syncing
+	r.Post("/:id/suspend", h.suspend)
+	// POST   /api/v1/stacks/{id}/resume    # Scale back up and resume syncing
+	r.Post("/:id/resume", h.resume)
+	// POST   /api/v1/stacks/{id}/rename    # Rename stack
+	r.Post("/:id/rename", validation.DecorateWithBodyEx(h.validator, h.rename))
 }
 
 //	@Summary		Create a new stack
@@ -80,12 +92,22 @@ func (h *Handler) post(c *fiber.Ctx, req *POSTRequest) error {
 		GitURL:      req.GitURL,
 		GitBranch:   req.GitBranch,
 		GitAuth: stacks.GitAuth{
-			Username: req.GitAuth.Username,
-			Password: req.GitAuth.Password,
+			Username:         req.GitAuth.Username,
+			Password:         req.GitAuth.Password,
+			SSHKey:           req.GitAuth.SSHKey,
+			SSHKeyPassphrase: req.GitAuth.SSHKeyPassphrase,
+			KnownHosts:       req.GitAuth.KnownHosts,
 		},
 		ComposePath: req.ComposePath,
-		Variables:   req.Variables,
-		Labels:      req.Labels,
+
+		WebhookSecret: req.WebhookSecret,
+
+		DeployTimeout: time.Duration(req.DeployTimeout) * time.Second,
+		DeployPolicy:  stacks.DeployPolicy(req.DeployPolicy),
+		AutoRollback:  req.AutoRollback,
+
+		Variables: req.Variables,
+		Labels:    req.Labels,
 	}
 
 	stack, err := h.stacksSvc.Create(c.Context(), draft)
@@ -98,16 +120,31 @@ func (h *Handler) post(c *fiber.Ctx, req *POSTRequest) error {
 }
 
 //	@Summary		List all stacks
-//	@Description	Retrieve a list of all configured stacks
+//	@Description	Retrieve the configured stacks, optionally filtered by status and label selectors
 //	@Tags			stacks
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{array}	StackResponse
+//	@Param			query	query		GETListRequest	false	"Filter"
+//	@Success		200		{array}		StackResponse
+//	@Failure		400		{object}	fiberfx.ErrorResponse
 //	@Router			/stacks [get]
 //
 // List all stacks.
 func (h *Handler) list(c *fiber.Ctx) error {
-	stacks, err := h.stacksSvc.List(c.Context())
+	req := new(GETListRequest)
+	if qErr := c.QueryParser(req); qErr != nil {
+		return fiber.NewError(fiber.StatusBadRequest, qErr.Error())
+	}
+	if vErr := h.validator.Struct(req); vErr != nil {
+		return validation.NewErrors(vErr)
+	}
+
+	filter, err := req.toDomain()
+	if err != nil {
+		return err
+	}
+
+	stacks, err := h.stacksSvc.Find(c.Context(), filter)
 	if err != nil {
 		return fmt.Errorf("failed to list stacks: %w", err)
 	}
@@ -178,20 +215,35 @@ func (h *Handler) patch(c *fiber.Ctx, req *PATCHRequest) error {
 		}
 		if req.GitAuth != nil {
 			stack.GitAuth = stacks.GitAuth{
-				Username: req.GitAuth.Username,
-				Password: req.GitAuth.Password,
+				Username:         req.GitAuth.Username,
+				Password:         req.GitAuth.Password,
+				SSHKey:           req.GitAuth.SSHKey,
+				SSHKeyPassphrase: req.GitAuth.SSHKeyPassphrase,
+				KnownHosts:       req.GitAuth.KnownHosts,
 			}
 		}
 		if req.ComposePath != nil {
 			stack.ComposePath = *req.ComposePath
 		}
+		if req.WebhookSecret != nil {
+			stack.WebhookSecret = *req.WebhookSecret
+		}
 		if req.Variables != nil {
 			stack.Variables = *req.Variables
 		}
 		if req.Labels != nil {
 			stack.Labels = *req.Labels
 		}
-		return nil
+		if req.DeployTimeout != nil {
+			stack.DeployTimeout = time.Duration(*req.DeployTimeout) * time.Second
+		}
+		if req.DeployPolicy != nil {
+			stack.DeployPolicy = stacks.DeployPolicy(*req.DeployPolicy)
+		}
+		if req.AutoRollback != nil {
+			stack.AutoRollback = *req.AutoRollback
+		}
+		return stack.Validate()
 	}
 
 	err = h.stacksSvc.Update(c.Context(), id, updater)
@@ -203,14 +255,17 @@ func (h *Handler) patch(c *fiber.Ctx, req *PATCHRequest) error {
 }
 
 //	@Summary		Delete a stack
-//	@Description	Delete an existing stack by ID
+//	@Description	Delete an existing stack by ID along with its deployment history. With prune, the Swarm services, networks, configs and secrets of the stack are removed first and the request waits for their removal.
 //	@Tags			stacks
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path	string	true	"Stack ID"
+//	@Param			id		path	string				true	"Stack ID"
+//	@Param			query	query	DELETERequest	false	"Options"
 //	@Success		204
 //	@Failure		400	{object}	fiberfx.ErrorResponse
 //	@Failure		404	{object}	fiberfx.ErrorResponse
+//	@Failure		409	{object}	fiberfx.ErrorResponse
+//	@Failure		504	{object}	fiberfx.ErrorResponse
 //	@Router			/stacks/{id} [delete]
 //
 // Delete a stack.
@@ -220,7 +275,12 @@ func (h *Handler) delete(c *fiber.Ctx) error {
 		return err
 	}
 
-	err = h.stacksSvc.Delete(c.Context(), id)
+	req := new(DELETERequest)
+	if qErr := c.QueryParser(req); qErr != nil {
+		return fiber.NewError(fiber.StatusBadRequest, qErr.Error())
+	}
+
+	err = h.deploymentsSvc.DeleteStack(c.Context(), id, req.Prune)
 	if err != nil {
 		return fmt.Errorf("failed to delete stack: %w", err)
 	}
@@ -231,15 +291,16 @@ func (h *Handler) delete(c *fiber.Ctx) error {
 // Deployments API.
 
 //	@Summary		Deploy a stack
-//	@Description	Trigger a deployment of a stack
+//	@Description	Queue a deployment of a stack to be executed in the background
 //	@Tags			stacks, deployments
 //	@Accept			json
 //	@Produce		json
 //	@Param			id		path		string				true	"Stack ID"
 //	@Param			deploy	body		POSTDeployRequest	false	"Deployment request"
-//	@Success		200		{object}	DeploymentResponse
+//	@Success		202		{object}	dto.DeploymentResponse
 //	@Failure		400		{object}	fiberfx.ErrorResponse
 //	@Failure		404		{object}	fiberfx.ErrorResponse
+//	@Failure		409		{object}	fiberfx.ErrorResponse	"Another deployment is in progress and the stack rejects concurrent deployments"
 //	@Router			/stacks/{id}/deploy [post]
 //
 // Deploy a stack.
@@ -253,6 +314,7 @@ func (h *Handler) deploy(c *fiber.Ctx, req *POSTDeployRequest) error {
 		c.Context(),
 		deployments.DeploymentRequest{
 			StackID:   id,
+			Ref:       req.Ref,
 			Variables: req.Variables,
 		},
 	)
@@ -260,18 +322,18 @@ func (h *Handler) deploy(c *fiber.Ctx, req *POSTDeployRequest) error {
 		return fmt.Errorf("failed to trigger deployment: %w", err)
 	}
 
-	return c.JSON(newDeploymentResponse(d))
+	return c.Status(fiber.StatusAccepted).JSON(dto.NewDeploymentResponse(d))
 }
 
 //	@Summary		List deployments for a stack
-//	@Description	List all deployments for a stack
+//	@Description	List deployments of a stack, newest first unless requested otherwise. Pass the next cursor of a page to get the following one.
 //	@Tags			stacks, deployments
-//	@Accept			json
 //	@Produce		json
-//	@Param			id	path		string	true	"Stack ID"
-//	@Success		200	{object}	[]DeploymentResponse
-//	@Failure		400	{object}	fiberfx.ErrorResponse
-//	@Failure		404	{object}	fiberfx.ErrorResponse
+//	@Param			id		path		string					true	"Stack ID"
+//	@Param			query	query		dto.DeploymentsQuery	false	"Filter and pagination"
+//	@Success		200		{object}	dto.DeploymentsResponse
+//	@Failure		400		{object}	fiberfx.ErrorResponse
+//	@Failure		404		{object}	fiberfx.ErrorResponse
 //	@Router			/stacks/{id}/history [get]
 //
 // List deployments for a stack.
@@ -281,45 +343,177 @@ func (h *Handler) history(c *fiber.Ctx) error {
 		return err
 	}
 
-	deps, err := h.deploymentsSvc.ListByStack(c.Context(), id)
+	req := new(dto.DeploymentsQuery)
+	if qErr := c.QueryParser(req); qErr != nil {
+		return fiber.NewError(fiber.StatusBadRequest, qErr.Error())
+	}
+	if vErr := h.validator.Struct(req); vErr != nil {
+		return validation.NewErrors(vErr)
+	}
+
+	if _, getErr := h.stacksSvc.Get(c.Context(), id); getErr != nil {
+		return fmt.Errorf("failed to get stack: %w", getErr)
+	}
+
+	filter, page := req.ToDomain(&id)
+	deps, err := h.deploymentsSvc.List(c.Context(), filter, page)
 	if err != nil {
 		return fmt.Errorf("failed to list deployments: %w", err)
 	}
 
-	return c.JSON(
-		lo.Map(
-			deps,
-			func(d deployments.Deployment, _ int) DeploymentResponse {
-				return newDeploymentResponse(&d)
-			},
-		),
+	return c.JSON(dto.NewDeploymentsResponse(deps))
+}
+
+//	@Summary		Plan a deployment
+//	@Description	Render the compose file of a stack at a ref and compare it with the services running in the cluster. Returns the services a deployment would create, update (with the changed fields) and remove, without changing anything or recording a deployment.
+//	@Tags			stacks, deployments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string			true	"Stack ID"
+//	@Param			plan	body		POSTPlanRequest	false	"Plan request"
+//	@Success		200		{object}	PlanResponse
+//	@Failure		400		{object}	fiberfx.ErrorResponse
+//	@Failure		404		{object}	fiberfx.ErrorResponse
+//	@Failure		422		{object}	fiberfx.ErrorResponse	"The compose file cannot be rendered"
+//	@Router			/stacks/{id}/plan [post]
+//
+// Plan a deployment.
+func (h *Handler) plan(c *fiber.Ctx, req *POSTPlanRequest) error {
+	id, err := getStackID(c)
+	if err != nil {
+		return err
+	}
+
+	plan, err := h.deploymentsSvc.Plan(
+		c.Context(),
+		deployments.DeploymentRequest{
+			StackID:   id,
+			Ref:       req.Ref,
+			Variables: req.Variables,
+		},
 	)
+	if err != nil {
+		return fmt.Errorf("failed to plan deployment: %w", err)
+	}
+
+	return c.JSON(newPlanResponse(plan))
 }
 
 //	@Summary		Rollback a stack
-//	@Description	Rollback a stack to a previous version
-//	@Tags			stacks
+//	@Description	Queue a deployment of the commit and variables of an earlier successful deployment. Without a target, the stack is rolled back to the successful deployment preceding the last applied one, even if that one failed; repeated rollbacks walk further back.
+//	@Tags			stacks, deployments
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		string	true	"Stack ID"
-//	@Success		200	{object}	DeploymentResponse
-//	@Failure		400	{object}	fiberfx.ErrorResponse
-//	@Failure		404	{object}	fiberfx.ErrorResponse
+//	@Param			id			path		string				true	"Stack ID"
+//	@Param			rollback	body		POSTRollbackRequest	false	"Rollback request"
+//	@Success		202			{object}	dto.DeploymentResponse
+//	@Failure		400			{object}	fiberfx.ErrorResponse
+//	@Failure		404			{object}	fiberfx.ErrorResponse
+//	@Failure		409			{object}	fiberfx.ErrorResponse
 //	@Router			/stacks/{id}/rollback [post]
 //
 // Rollback a stack.
-func (h *Handler) rollback(c *fiber.Ctx) error {
+func (h *Handler) rollback(c *fiber.Ctx, req *POSTRollbackRequest) error {
 	id, err := getStackID(c)
 	if err != nil {
 		return err
 	}
 
-	_, current, err := h.deploymentsSvc.Rollback(c.Context(), id)
+	d, err := h.deploymentsSvc.Rollback(c.Context(), id, req.Target)
 	if err != nil {
 		return fmt.Errorf("failed to rollback stack: %w", err)
 	}
 
-	return c.JSON(newDeploymentResponse(current))
+	return c.Status(fiber.StatusAccepted).JSON(dto.NewDeploymentResponse(d))
+}
+
+//	@Summary		Suspend a stack
+//	@Description	Stop syncing the stack and scale its replicated services to zero. The replica counts are remembered and restored on resume. Deployments of a suspended stack are refused.
+//	@Tags			stacks
+//	@Produce		json
+//	@Param			id	path		string	true	"Stack ID"
+//	@Success		200	{object}	StackResponse
+//	@Failure		400	{object}	fiberfx.ErrorResponse
+//	@Failure		404	{object}	fiberfx.ErrorResponse
+//	@Failure		409	{object}	fiberfx.ErrorResponse
+//	@Router			/stacks/{id}/suspend [post]
+//
+// Suspend a stack.
+func (h *Handler) suspend(c *fiber.Ctx) error {
+	id, err := getStackID(c)
+	if err != nil {
+		return err
+	}
+
+	stack, err := h.deploymentsSvc.Suspend(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("failed to suspend stack: %w", err)
+	}
+
+	return c.JSON(h.toResponse(stack))
+}
+
+//	@Summary		Resume a stack
+//	@Description	Restore the replica counts of the services scaled down by suspend and resume syncing the stack.
+//	@Tags			stacks
+//	@Produce		json
+//	@Param			id	path		string	true	"Stack ID"
+//	@Success		200	{object}	StackResponse
+//	@Failure		400	{object}	fiberfx.ErrorResponse
+//	@Failure		404	{object}	fiberfx.ErrorResponse
+//	@Failure		409	{object}	fiberfx.ErrorResponse
+//	@Router			/stacks/{id}/resume [post]
+//
+// Resume a stack.
+func (h *Handler) resume(c *fiber.Ctx) error {
+	id, err := getStackID(c)
+	if err != nil {
+		return err
+	}
+
+	stack, err := h.deploymentsSvc.Resume(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("failed to resume stack: %w", err)
+	}
+
+	return c.JSON(h.toResponse(stack))
+}
+
+//	@Summary		Rename a stack
+//	@Description	Change the name of a stack. Renaming a deployed stack to a name with a different namespace requires migrate: the resources of the previous namespace are removed and the last successful deployment is redeployed to the new one.
+//	@Tags			stacks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Stack ID"
+//	@Param			rename	body		POSTRenameRequest	true	"Rename request"
+//	@Success		200		{object}	RenameResponse
+//	@Failure		400		{object}	fiberfx.ErrorResponse
+//	@Failure		404		{object}	fiberfx.ErrorResponse
+//	@Failure		409		{object}	fiberfx.ErrorResponse
+//	@Router			/stacks/{id}/rename [post]
+//
+// Rename a stack.
+func (h *Handler) rename(c *fiber.Ctx, req *POSTRenameRequest) error {
+	id, err := getStackID(c)
+	if err != nil {
+		return err
+	}
+
+	stack, d, err := h.deploymentsSvc.Rename(c.Context(), id, req.Name, req.Migrate)
+	if err != nil {
+		return fmt.Errorf("failed to rename stack: %w", err)
+	}
+
+	response := RenameResponse{
+		Stack:      h.toResponse(stack),
+		Deployment: nil,
+	}
+	if d != nil {
+		deployment := dto.NewDeploymentResponse(d)
+		response.Deployment = &deployment
+	}
+
+	return c.JSON(response)
 }
 
 func (h *Handler) errorsHandler(c *fiber.Ctx) error {
@@ -329,7 +523,8 @@ func (h *Handler) errorsHandler(c *fiber.Ctx) error {
 	}
 
 	switch {
-	case errors.Is(err, stacks.ErrNotAllowed):
+	case errors.Is(err, stacks.ErrNotAllowed),
+		errors.Is(err, stacks.ErrInvalid):
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	case errors.Is(err, stacks.ErrNotFound):
 		return fiber.NewError(fiber.StatusNotFound, err.Error())
@@ -342,6 +537,31 @@ func (h *Handler) errorsHandler(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusNotFound, err.Error())
 	case errors.Is(err, deployments.ErrNotAllowed):
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, deployments.ErrConflict):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+
+	switch {
+	case errors.Is(err, git.ErrInvalidRef):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, git.ErrRefNotFound):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, git.ErrInvalidAuth):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, git.ErrFileNotFound):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	}
+
+	switch {
+	case errors.Is(err, reconciler.ErrRemovalTimeout):
+		return fiber.NewError(fiber.StatusGatewayTimeout, err.Error())
+	}
+
+	switch {
+	case errors.Is(err, compose.ErrInvalid),
+		errors.Is(err, compose.ErrUnsupported),
+		errors.Is(err, compose.ErrMissingVariable):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
 	}
 
 	return err //nolint:wrapcheck //already wrapped
@@ -357,6 +577,10 @@ func (h *Handler) toResponse(stack *stacks.Stack) StackResponse {
 			ComposePath: stack.ComposePath,
 			Variables:   stack.Variables,
 			Labels:      stack.Labels,
+
+			DeployTimeout: int(stack.DeployTimeout / time.Second),
+			DeployPolicy:  string(stack.DeployPolicy),
+			AutoRollback:  stack.AutoRollback,
 		},
 		ID: stack.ID,
 
@@ -365,5 +589,8 @@ func (h *Handler) toResponse(stack *stacks.Stack) StackResponse {
 		LastDeploy: stack.LastDeploy,
 		CreatedAt:  stack.CreatedAt,
 		UpdatedAt:  stack.UpdatedAt,
+
+		SuspendedReplicas: stack.SuspendedReplicas,
+		PreviousNamespace: stack.PreviousNamespace,
 	}
 }
@@ -0,0 +1,32 @@
+package webhooks
+
+import (
+	"github.com/apiarycd/apiarycd/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+// PushResponse represents the response payload for a webhook.
+type PushResponse struct {
+	Provider webhooks.Provider `json:"provider"` // github, gitlab, gitea, forgejo
+	Event    string            `json:"event"`    // Provider specific event name
+	Results  []StackResult     `json:"results"`  // Per-stack results
+}
+
+// StackResult represents the outcome of a webhook for a single stack.
+type StackResult struct {
+	StackID      uuid.UUID             `json:"stack_id"`
+	StackName    string                `json:"stack_name"`
+	Status       webhooks.ResultStatus `json:"status"`                  // triggered, skipped, failed
+	DeploymentID *uuid.UUID            `json:"deployment_id,omitempty"` // Triggered deployment
+	Error        string                `json:"error,omitempty"`         // Error message if failed
+}
+
+func newStackResult(domain webhooks.Result) StackResult {
+	return StackResult{
+		StackID:      domain.StackID,
+		StackName:    domain.StackName,
+		Status:       domain.Status,
+		DeploymentID: domain.DeploymentID,
+		Error:        domain.Error,
+	}
+}
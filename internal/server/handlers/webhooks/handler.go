@@ -0,0 +1,90 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/apiarycd/apiarycd/internal/webhooks"
+	"github.com/go-core-fx/fiberfx/handler"
+	"github.com/gofiber/fiber/v2"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	webhooksSvc *webhooks.Service
+
+	logger *zap.Logger
+}
+
+func NewHandler(webhooksSvc *webhooks.Service, logger *zap.Logger) handler.Handler {
+	return &Handler{
+		webhooksSvc: webhooksSvc,
+
+		logger: logger,
+	}
+}
+
+// Register implements handler.Handler.
+func (h *Handler) Register(r fiber.Router) {
+	r = r.Group("/webhooks")
+
+	r.Use(h.errorsHandler)
+	// POST   /api/v1/webhooks/git          # Git push webhook
+	r.Post("/git", h.push)
+}
+
+//	@Summary		Git push webhook
+//	@Description	Receive a push event from GitHub, GitLab, Gitea or Forgejo and deploy the pushed commit to every stack tracking the pushed repository and branch.
+//	@Description	The request must be signed with the webhook secret of the stack (X-Hub-Signature-256, X-Gitea-Signature, X-Forgejo-Signature) or carry it as a token (X-Gitlab-Token).
+//	@Description	Stacks whose secret does not match are left out of the results.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	PushResponse	"No deployment triggered"
+//	@Success		202	{object}	PushResponse	"At least one deployment triggered"
+//	@Failure		400	{object}	fiberfx.ErrorResponse
+//	@Failure		401	{object}	fiberfx.ErrorResponse	"Signature does not match any stack"
+//	@Router			/webhooks/git [post]
+//
+// Git push webhook.
+func (h *Handler) push(c *fiber.Ctx) error {
+	event, err := webhooks.ParseEvent(func(key string) string { return c.Get(key) }, c.Body())
+	if err != nil {
+		return fmt.Errorf("failed to parse webhook: %w", err)
+	}
+
+	results, err := h.webhooksSvc.HandlePush(c.Context(), event)
+	if err != nil {
+		return fmt.Errorf("failed to handle webhook: %w", err)
+	}
+
+	status := fiber.StatusOK
+	if lo.ContainsBy(results, func(r webhooks.Result) bool { return r.Status == webhooks.ResultTriggered }) {
+		status = fiber.StatusAccepted
+	}
+
+	return c.Status(status).JSON(PushResponse{
+		Provider: event.Provider,
+		Event:    event.Type,
+		Results:  lo.Map(results, func(r webhooks.Result, _ int) StackResult { return newStackResult(r) }),
+	})
+}
+
+func (h *Handler) errorsHandler(c *fiber.Ctx) error {
+	err := c.Next()
+	if err == nil {
+		return nil
+	}
+
+	switch {
+	case errors.Is(err, webhooks.ErrUnsupportedProvider):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, webhooks.ErrInvalidPayload):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, webhooks.ErrUnauthorized):
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	return err //nolint:wrapcheck //already wrapped
+}
@@ -0,0 +1,175 @@
+package webhooks_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	handlers "github.com/apiarycd/apiarycd/internal/server/handlers/webhooks"
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	"github.com/apiarycd/apiarycd/internal/webhooks"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const push = `{
+	"ref": "refs/heads/main",
+	"after": "9fceb02d0ae598e95dc970b74767f19372d61af8",
+	"repository": {"clone_url": "https://github.com/org/repo.git"}
+}`
+
+// newApp returns an app serving the webhook handler for the stacks created
+// by the drafts. The stacks are inactive, so pushes never deploy them.
+func newApp(t *testing.T, drafts ...stacks.StackDraft) (*fiber.App, map[string]*stacks.Stack) {
+	t.Helper()
+
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	logger := zap.NewNop()
+	stacksSvc := stacks.NewService(stacks.NewRepository(db), logger)
+	deploymentsSvc := deployments.NewService(
+		deployments.Config{},
+		deployments.NewRepository(db),
+		deployments.NewLogRepository(db),
+		deployments.NewManifestRepository(db),
+		stacksSvc,
+		nil,
+		nil,
+		logger,
+	)
+
+	created := make(map[string]*stacks.Stack, len(drafts))
+	for _, draft := range drafts {
+		stack, createErr := stacksSvc.Create(t.Context(), draft)
+		if createErr != nil {
+			t.Fatalf("failed to create stack: %v", createErr)
+		}
+		if updErr := stacksSvc.Update(t.Context(), stack.ID, func(s *stacks.Stack) error {
+			s.Status = stacks.StatusInactive
+			return nil
+		}); updErr != nil {
+			t.Fatalf("failed to update stack: %v", updErr)
+		}
+		created[stack.Name] = stack
+	}
+
+	app := fiber.New()
+	handlers.NewHandler(webhooks.NewService(stacksSvc, deploymentsSvc, logger), logger).Register(app)
+
+	return app, created
+}
+
+func draft(name, url, branch, secret string) stacks.StackDraft {
+	return stacks.StackDraft{
+		Name:          name,
+		GitURL:        url,
+		GitBranch:     branch,
+		ComposePath:   "docker-compose.yml",
+		WebhookSecret: secret,
+	}
+}
+
+func signedPush(t *testing.T, secret string) *http.Request {
+	t.Helper()
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(push))
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/webhooks/git", strings.NewReader(push))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Github-Event", "push")
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestHandlerPush(t *testing.T) {
+	t.Parallel()
+
+	const repo = "https://github.com/org/repo.git"
+
+	tests := []struct {
+		name       string
+		stacks     []stacks.StackDraft
+		secret     string
+		wantStatus int
+		want       []string // Names of the stacks in the results
+	}{
+		{
+			name: "unverified stacks are hidden",
+			stacks: []stacks.StackDraft{
+				draft("verified", repo, "main", "s3cret"),
+				draft("unverified", "git@github.com:org/repo.git", "main", "other"),
+				draft("no-secret", repo, "main", ""),
+			},
+			secret:     "s3cret",
+			wantStatus: http.StatusOK,
+			want:       []string{"verified"},
+		},
+		{
+			name: "no stack verified",
+			stacks: []stacks.StackDraft{
+				draft("unverified", repo, "main", "other"),
+				draft("no-secret", repo, "main", ""),
+			},
+			secret:     "s3cret",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "no stack tracks the branch",
+			stacks: []stacks.StackDraft{
+				draft("other-branch", repo, "develop", "other"),
+				draft("other-repo", "https://github.com/org/other.git", "main", "other"),
+			},
+			secret:     "s3cret",
+			wantStatus: http.StatusOK,
+			want:       []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			app, created := newApp(t, tt.stacks...)
+
+			resp, err := app.Test(signedPush(t, tt.secret), -1)
+			if err != nil {
+				t.Fatalf("Test() error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if resp.StatusCode != http.StatusOK {
+				return
+			}
+
+			var body handlers.PushResponse
+			if decErr := json.NewDecoder(resp.Body).Decode(&body); decErr != nil {
+				t.Fatalf("failed to decode response: %v", decErr)
+			}
+
+			if len(body.Results) != len(tt.want) {
+				t.Fatalf("results = %+v, want stacks %v", body.Results, tt.want)
+			}
+			for i, name := range tt.want {
+				got := body.Results[i]
+				if got.StackID != created[name].ID || got.StackName != name || got.Status != webhooks.ResultSkipped {
+					t.Errorf("result %d = %+v, want %s skipped", i, got, name)
+				}
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package server
 
 import (
 	"github.com/apiarycd/apiarycd/internal/server/docs"
+	"github.com/apiarycd/apiarycd/internal/server/handlers/deployments"
 	"github.com/apiarycd/apiarycd/internal/server/handlers/stacks"
+	"github.com/apiarycd/apiarycd/internal/server/handlers/webhooks"
 	"github.com/apiarycd/apiarycd/pkg/openapifx"
 	"github.com/go-core-fx/fiberfx"
 	"github.com/go-core-fx/fiberfx/handler"
@@ -30,6 +32,8 @@ func Module() fx.Option {
 		fx.Provide(
 			fx.Annotate(health.NewHandler, fx.ResultTags(`name:"health-handler"`)), fx.Private,
 			fx.Annotate(stacks.NewHandler, fx.ResultTags(`group:"handlers"`)), fx.Private,
+			fx.Annotate(deployments.NewHandler, fx.ResultTags(`group:"handlers"`)), fx.Private,
+			fx.Annotate(webhooks.NewHandler, fx.ResultTags(`group:"handlers"`)), fx.Private,
 		),
 
 		fx.Invoke(
@@ -1,14 +1,31 @@
 package stacks
 
 import (
+	"strings"
 	"time"
 
+	"github.com/apiarycd/apiarycd/internal/git"
 	"github.com/google/uuid"
 )
 
+// DeployPolicy decides what happens to a deployment requested while another
+// deployment of the same stack is pending or running.
+type DeployPolicy string
+
+const (
+	DeployPolicyQueue  DeployPolicy = "queue"  // Run after the current deployment
+	DeployPolicyReject DeployPolicy = "reject" // Refuse the deployment
+)
+
 type GitAuth struct {
+	// HTTPS authentication
 	Username string
 	Password string
+
+	// SSH authentication
+	SSHKey           string // PEM encoded private key
+	SSHKeyPassphrase string // Private key passphrase
+	KnownHosts       string // known_hosts entries for host key verification
 }
 
 type StackDraft struct {
@@ -22,6 +39,14 @@ type StackDraft struct {
 	GitAuth     GitAuth // Authentication
 	ComposePath string  // Path to docker-compose.yml
 
+	// Webhooks
+	WebhookSecret string // Shared secret for push webhooks
+
+	// Deployment
+	DeployTimeout time.Duration // Rollout timeout, 0 for the default
+	DeployPolicy  DeployPolicy  // Concurrent deployments policy, queue by default
+	AutoRollback  bool          // Redeploy the last successful deployment when a deployment fails
+
 	// Configuration
 	Variables map[string]string // Default variables
 
@@ -36,6 +61,12 @@ type StackUpdate struct {
 	Status     Status     // active, inactive, error
 	LastSync   *time.Time // Last successful sync
 	LastDeploy *time.Time // Last successful deployment
+
+	// Suspension
+	SuspendedReplicas map[string]uint64 // Replica counts of the services scaled down by suspend
+
+	// Rename
+	PreviousNamespace string // Namespace left by a rename, removed by the next deployment
 }
 
 type Stack struct {
@@ -45,3 +76,46 @@ type Stack struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+// GitSource returns the repository of the stack.
+func (s *Stack) GitSource() git.Source {
+	return git.Source{
+		URL: s.GitURL,
+		Auth: git.Auth{
+			Username:         s.GitAuth.Username,
+			Password:         s.GitAuth.Password,
+			SSHKey:           s.GitAuth.SSHKey,
+			SSHKeyPassphrase: s.GitAuth.SSHKeyPassphrase,
+			KnownHosts:       s.GitAuth.KnownHosts,
+		},
+	}
+}
+
+// NamespaceCollision is a namespace shared by stacks created before namespaces
+// were unique.
+type NamespaceCollision struct {
+	Namespace string
+	Owner     string   // Name of the stack the namespace is indexed for
+	Others    []string // Names of the other stacks using the namespace
+}
+
+// Namespace returns the name that prefixes and labels the Swarm resources of
+// the stack. Characters not allowed in Swarm object names are replaced with
+// dashes.
+func (s *Stack) Namespace() string {
+	return Namespace(s.Name)
+}
+
+// Namespace returns the namespace of a stack named name.
+func Namespace(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '-'
+		}
+	}, name)
+}
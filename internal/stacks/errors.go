@@ -6,4 +6,5 @@ var (
 	ErrNotFound   = errors.New("stack not found")
 	ErrConflict   = errors.New("stack already exists")
 	ErrNotAllowed = errors.New("operation not allowed")
+	ErrInvalid    = errors.New("invalid stack")
 )
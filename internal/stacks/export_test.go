@@ -0,0 +1,32 @@
+package stacks
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// CreateUnindexed creates a stack the way Create did before namespaces were
+// indexed: without the namespace index and its uniqueness check.
+func (r *Repository) CreateUnindexed(draft StackDraft) (*Stack, error) {
+	model := newStackModel(draft)
+
+	err := r.db.Update(func(txn *badger.Txn) error {
+		if err := r.storage.Write(txn, model); err != nil {
+			return err //nolint:wrapcheck // wrapped outside of transaction
+		}
+
+		for _, namespace := range model.namespaces() {
+			if err := txn.Delete([]byte(prefixByNamespace + namespace)); err != nil {
+				return err //nolint:wrapcheck // wrapped outside of transaction
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stack: %w", err)
+	}
+
+	return model.toDomain(), nil
+}
@@ -16,10 +16,11 @@ type Status string
 const (
 	prefix = "stack:"
 
-	prefixByID     = prefix + "id:"
-	prefixByName   = prefix + "name:"
-	prefixByStatus = prefix + "status:"
-	prefixByLabel  = prefix + "label:"
+	prefixByID        = prefix + "id:"
+	prefixByName      = prefix + "name:"
+	prefixByNamespace = prefix + "namespace:"
+	prefixByStatus    = prefix + "status:"
+	prefixByLabel     = prefix + "label:"
 )
 
 const (
@@ -28,9 +29,22 @@ const (
 	StatusError    Status = "error"
 )
 
+func (s Status) isValid() bool {
+	switch s {
+	case StatusActive, StatusInactive, StatusError:
+		return true
+	}
+
+	return false
+}
+
 type gitAuth struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+
+	SSHKey           string `json:"ssh_key,omitempty"`
+	SSHKeyPassphrase string `json:"ssh_key_passphrase,omitempty"`
+	KnownHosts       string `json:"known_hosts,omitempty"`
 }
 
 // stackModel represents a GitOps stack configuration.
@@ -47,6 +61,14 @@ type stackModel struct {
 	GitAuth     gitAuth `json:"git_auth"`     // Git authentication
 	ComposePath string  `json:"compose_path"` // Path to docker-compose.yml
 
+	// Webhooks
+	WebhookSecret string `json:"webhook_secret,omitempty"` // Shared secret for push webhooks
+
+	// Deployment
+	DeployTimeout time.Duration `json:"deploy_timeout,omitempty"` // Rollout timeout, 0 for the default
+	DeployPolicy  DeployPolicy  `json:"deploy_policy,omitempty"`  // Concurrent deployments policy, queue by default
+	AutoRollback  bool          `json:"auto_rollback,omitempty"`  // Redeploy the last success when a deployment fails
+
 	// Configuration
 	Variables map[string]string `json:"variables"` // Default variables
 
@@ -55,10 +77,26 @@ type stackModel struct {
 	LastSync   *time.Time `json:"last_sync"`   // Last successful sync
 	LastDeploy *time.Time `json:"last_deploy"` // Last successful deployment
 
+	// Suspension
+	SuspendedReplicas map[string]uint64 `json:"suspended_replicas,omitempty"` // Replicas to restore on resume
+
+	// Rename
+	PreviousNamespace string `json:"previous_namespace,omitempty"` // Namespace to remove on the next deployment
+
 	// Metadata
 	Labels map[string]string `json:"labels"` // Custom labels for filtering
 }
 
+func newGitAuth(auth GitAuth) gitAuth {
+	return gitAuth{
+		Username:         auth.Username,
+		Password:         auth.Password,
+		SSHKey:           auth.SSHKey,
+		SSHKeyPassphrase: auth.SSHKeyPassphrase,
+		KnownHosts:       auth.KnownHosts,
+	}
+}
+
 func newStackModel(stack StackDraft) *stackModel {
 	return &stackModel{
 		BaseEntity: storage.BaseEntity{
@@ -70,16 +108,23 @@ func newStackModel(stack StackDraft) *stackModel {
 		Description: stack.Description,
 		GitURL:      stack.GitURL,
 		GitBranch:   stack.GitBranch,
-		GitAuth: gitAuth{
-			Username: stack.GitAuth.Username,
-			Password: stack.GitAuth.Password,
-		},
+		GitAuth:     newGitAuth(stack.GitAuth),
 		ComposePath: stack.ComposePath,
-		Variables:   stack.Variables,
-		Status:      StatusActive,
-		LastSync:    nil,
-		LastDeploy:  nil,
-		Labels:      stack.Labels,
+
+		WebhookSecret: stack.WebhookSecret,
+
+		DeployTimeout: stack.DeployTimeout,
+		DeployPolicy:  stack.DeployPolicy,
+		AutoRollback:  stack.AutoRollback,
+
+		Variables:  stack.Variables,
+		Status:     StatusActive,
+		LastSync:   nil,
+		LastDeploy: nil,
+		Labels:     stack.Labels,
+
+		SuspendedReplicas: nil,
+		PreviousNamespace: "",
 	}
 }
 
@@ -87,6 +132,17 @@ func (s *stackModel) nameIndex() string {
 	return prefixByName + s.Name
 }
 
+// namespaces returns the namespace of the stack and the previous one awaiting
+// removal, if any.
+func (s *stackModel) namespaces() []string {
+	namespaces := []string{Namespace(s.Name)}
+	if s.PreviousNamespace != "" && s.PreviousNamespace != namespaces[0] {
+		namespaces = append(namespaces, s.PreviousNamespace)
+	}
+
+	return namespaces
+}
+
 // MarshalStorage implements badgerfx.Entity.
 func (s *stackModel) MarshalStorage() ([]byte, error) {
 	data, err := json.Marshal(s)
@@ -99,24 +155,47 @@ func (s *stackModel) MarshalStorage() ([]byte, error) {
 
 // StorageIndexes implements badgerfx.Entity.
 func (s *stackModel) StorageIndexes() []string {
-	const fixedIndexCount = 2
+	const fixedIndexCount = 3
 
 	indexes := make([]string, 0, fixedIndexCount+len(s.Labels))
 
 	// Name index
 	indexes = append(indexes, s.nameIndex())
 
+	// Namespace indexes
+	for _, namespace := range s.namespaces() {
+		indexes = append(indexes, prefixByNamespace+namespace)
+	}
+
 	// Status index
-	indexes = append(indexes, prefixByStatus+string(s.Status)+":"+s.ID.String())
+	indexes = append(indexes, statusIndexPrefix(s.Status)+s.ID.String())
 
 	// Labels index
 	for key, value := range s.Labels {
-		indexes = append(indexes, prefixByLabel+url.QueryEscape(key)+":"+url.QueryEscape(value)+":"+s.ID.String())
+		indexes = append(indexes, labelIndexPrefix(key, value)+s.ID.String())
 	}
 
 	return indexes
 }
 
+// statusIndexPrefix returns the prefix of the status index keys of stacks
+// in status `stack:status:<status>:`.
+func statusIndexPrefix(status Status) string {
+	return prefixByStatus + string(status) + ":"
+}
+
+// labelKeyIndexPrefix returns the prefix of the label index keys of stacks
+// with the label set `stack:label:<key>:`.
+func labelKeyIndexPrefix(key string) string {
+	return prefixByLabel + url.QueryEscape(key) + ":"
+}
+
+// labelIndexPrefix returns the prefix of the label index keys of stacks with
+// the label set to value `stack:label:<key>:<value>:`.
+func labelIndexPrefix(key, value string) string {
+	return labelKeyIndexPrefix(key) + url.QueryEscape(value) + ":"
+}
+
 // StorageKey implements badgerfx.Entity.
 func (s *stackModel) StorageKey(id ...string) string {
 	if len(id) > 0 {
@@ -138,11 +217,12 @@ func (s *stackModel) update(stack StackUpdate) {
 	s.Description = stack.Description
 	s.GitURL = stack.GitURL
 	s.GitBranch = stack.GitBranch
-	s.GitAuth = gitAuth{
-		Username: stack.GitAuth.Username,
-		Password: stack.GitAuth.Password,
-	}
+	s.GitAuth = newGitAuth(stack.GitAuth)
 	s.ComposePath = stack.ComposePath
+	s.WebhookSecret = stack.WebhookSecret
+	s.DeployTimeout = stack.DeployTimeout
+	s.DeployPolicy = stack.DeployPolicy
+	s.AutoRollback = stack.AutoRollback
 	s.Variables = stack.Variables
 	s.Labels = stack.Labels
 
@@ -150,6 +230,9 @@ func (s *stackModel) update(stack StackUpdate) {
 	s.LastSync = stack.LastSync
 	s.LastDeploy = stack.LastDeploy
 
+	s.SuspendedReplicas = stack.SuspendedReplicas
+	s.PreviousNamespace = stack.PreviousNamespace
+
 	s.UpdatedAt = time.Now()
 }
 
@@ -166,17 +249,30 @@ func (s *stackModel) toDomain() *Stack {
 				GitURL:      s.GitURL,
 				GitBranch:   s.GitBranch,
 				GitAuth: GitAuth{
-					Username: s.GitAuth.Username,
-					Password: s.GitAuth.Password,
+					Username:         s.GitAuth.Username,
+					Password:         s.GitAuth.Password,
+					SSHKey:           s.GitAuth.SSHKey,
+					SSHKeyPassphrase: s.GitAuth.SSHKeyPassphrase,
+					KnownHosts:       s.GitAuth.KnownHosts,
 				},
 				ComposePath: s.ComposePath,
-				Variables:   s.Variables,
-				Labels:      s.Labels,
+
+				WebhookSecret: s.WebhookSecret,
+
+				DeployTimeout: s.DeployTimeout,
+				DeployPolicy:  s.DeployPolicy,
+				AutoRollback:  s.AutoRollback,
+
+				Variables: s.Variables,
+				Labels:    s.Labels,
 			},
 
 			Status:     s.Status,
 			LastSync:   s.LastSync,
 			LastDeploy: s.LastDeploy,
+
+			SuspendedReplicas: s.SuspendedReplicas,
+			PreviousNamespace: s.PreviousNamespace,
 		},
 
 		ID:        s.ID,
@@ -11,5 +11,11 @@ func Module() fx.Option {
 		logger.WithNamedLogger("stacks"),
 		fx.Provide(NewRepository, fx.Private),
 		fx.Provide(NewService),
+		fx.Invoke(func(lc fx.Lifecycle, s *Service) {
+			lc.Append(fx.Hook{
+				OnStart: s.Reindex,
+				OnStop:  nil,
+			})
+		}),
 	)
 }
@@ -0,0 +1,139 @@
+package stacks
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// idSet is a set of stack IDs.
+type idSet map[string]struct{}
+
+// Find retrieves the stacks matching filter in creation order. Statuses and
+// label selectors are resolved with the status and label indexes, so only
+// the matching stacks are read.
+func (r *Repository) Find(_ context.Context, filter Filter) ([]Stack, error) {
+	var stacks []Stack
+
+	err := r.db.View(func(txn *badger.Txn) error {
+		ids := selectIDs(txn, filter)
+
+		stacks = make([]Stack, 0, len(ids))
+		for _, id := range ids {
+			model, err := r.storage.Read(txn, id)
+			if err != nil {
+				return fmt.Errorf("failed to read stack %s: %w", id, err)
+			}
+
+			stacks = append(stacks, *model.toDomain())
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stacks: %w", err)
+	}
+
+	return stacks, nil
+}
+
+// selectIDs returns the sorted IDs of the stacks matching filter.
+func selectIDs(txn *badger.Txn, filter Filter) []string {
+	var (
+		include idSet // nil matches every stack
+		exclude = idSet{}
+	)
+
+	if len(filter.Statuses) > 0 {
+		prefixes := make([]string, 0, len(filter.Statuses))
+		for _, status := range filter.Statuses {
+			prefixes = append(prefixes, statusIndexPrefix(status))
+		}
+
+		include = include.intersect(scanIDs(txn, prefixes...))
+	}
+
+	for _, selector := range filter.Labels {
+		switch selector.Operator {
+		case OperatorEquals, OperatorIn:
+			include = include.intersect(scanIDs(txn, labelPrefixes(selector)...))
+		case OperatorExists:
+			include = include.intersect(scanIDs(txn, labelKeyIndexPrefix(selector.Key)))
+		case OperatorNotEquals, OperatorNotIn:
+			exclude.add(scanIDs(txn, labelPrefixes(selector)...))
+		case OperatorDoesNotExist:
+			exclude.add(scanIDs(txn, labelKeyIndexPrefix(selector.Key)))
+		}
+	}
+
+	if include == nil {
+		include = scanIDs(txn, prefixByID)
+	}
+
+	ids := make([]string, 0, len(include))
+	for id := range include {
+		if _, ok := exclude[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+
+	// IDs are UUIDv7, so sorting them restores the creation order.
+	slices.Sort(ids)
+
+	return ids
+}
+
+func labelPrefixes(selector LabelSelector) []string {
+	prefixes := make([]string, 0, len(selector.Values))
+	for _, value := range selector.Values {
+		prefixes = append(prefixes, labelIndexPrefix(selector.Key, value))
+	}
+
+	return prefixes
+}
+
+// scanIDs collects the stack IDs of the keys under any of the prefixes. The
+// ID is the last segment of the key, both for entity and index keys.
+func scanIDs(txn *badger.Txn, prefixes ...string) idSet {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	ids := idSet{}
+	for _, prefix := range prefixes {
+		p := []byte(prefix)
+		for it.Seek(p); it.ValidForPrefix(p); it.Next() {
+			key := string(it.Item().Key())
+			ids[key[strings.LastIndexByte(key, ':')+1:]] = struct{}{}
+		}
+	}
+
+	return ids
+}
+
+// intersect returns the IDs in both sets, treating a nil set as every stack.
+func (s idSet) intersect(other idSet) idSet {
+	if s == nil {
+		return other
+	}
+
+	for id := range s {
+		if _, ok := other[id]; !ok {
+			delete(s, id)
+		}
+	}
+
+	return s
+}
+
+func (s idSet) add(other idSet) {
+	for id := range other {
+		s[id] = struct{}{}
+	}
+}
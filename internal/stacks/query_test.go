@@ -0,0 +1,156 @@
+package stacks_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestRepositoryFind(t *testing.T) {
+	t.Parallel()
+
+	repo := newRepository(t)
+	ids := make(map[string]uuid.UUID)
+	for _, draft := range []stacks.StackDraft{
+		newDraft("api", map[string]string{"env": "prod", "team": "core"}),
+		newDraft("web", map[string]string{"env": "staging", "team": "front"}),
+		newDraft("docs", map[string]string{"env": "prod"}),
+		newDraft("scratch", nil),
+	} {
+		stack, err := repo.Create(t.Context(), draft)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		ids[stack.Name] = stack.ID
+	}
+
+	if err := repo.Update(t.Context(), ids["docs"], func(s *stacks.Stack) error {
+		s.Status = stacks.StatusInactive
+		return nil
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	selector := func(s string) []stacks.LabelSelector {
+		selectors, err := stacks.ParseLabelSelectors(s)
+		if err != nil {
+			t.Fatalf("ParseLabelSelectors(%q) error = %v", s, err)
+		}
+		return selectors
+	}
+
+	tests := []struct {
+		name   string
+		filter stacks.Filter
+		want   []string
+	}{
+		{name: "everything", filter: stacks.Filter{}, want: []string{"api", "web", "docs", "scratch"}},
+		{
+			name:   "status",
+			filter: stacks.Filter{Statuses: []stacks.Status{stacks.StatusInactive}},
+			want:   []string{"docs"},
+		},
+		{
+			name:   "any of the statuses",
+			filter: stacks.Filter{Statuses: []stacks.Status{stacks.StatusInactive, stacks.StatusActive}},
+			want:   []string{"api", "web", "docs", "scratch"},
+		},
+		{name: "equals", filter: stacks.Filter{Labels: selector("env=prod")}, want: []string{"api", "docs"}},
+		{name: "not equals", filter: stacks.Filter{Labels: selector("env!=prod")}, want: []string{"web", "scratch"}},
+		{name: "in", filter: stacks.Filter{Labels: selector("team in (core,front)")}, want: []string{"api", "web"}},
+		{
+			name:   "not in",
+			filter: stacks.Filter{Labels: selector("team notin (core)")},
+			want:   []string{"web", "docs", "scratch"},
+		},
+		{name: "exists", filter: stacks.Filter{Labels: selector("team")}, want: []string{"api", "web"}},
+		{name: "does not exist", filter: stacks.Filter{Labels: selector("!env")}, want: []string{"scratch"}},
+		{name: "all selectors", filter: stacks.Filter{Labels: selector("env=prod,!team")}, want: []string{"docs"}},
+		{
+			name: "status and selectors",
+			filter: stacks.Filter{
+				Statuses: []stacks.Status{stacks.StatusActive},
+				Labels:   selector("env=prod"),
+			},
+			want: []string{"api"},
+		},
+		{name: "no match", filter: stacks.Filter{Labels: selector("env=dev")}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			found, err := repo.Find(t.Context(), tt.filter)
+			if err != nil {
+				t.Fatalf("Find() error = %v", err)
+			}
+
+			got := make([]string, 0, len(found))
+			for _, stack := range found {
+				got = append(got, stack.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Find() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepositoryFindAfterUpdate(t *testing.T) {
+	t.Parallel()
+
+	repo := newRepository(t)
+	stack, err := repo.Create(t.Context(), newDraft("api", map[string]string{"env": "staging"}))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if err = repo.Update(t.Context(), stack.ID, func(s *stacks.Stack) error {
+		s.Labels = map[string]string{"env": "prod"}
+		s.Status = stacks.StatusError
+		return nil
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// The indexes follow the update.
+	env := func(value string) []stacks.LabelSelector {
+		return []stacks.LabelSelector{{Key: "env", Operator: stacks.OperatorEquals, Values: []string{value}}}
+	}
+
+	tests := []struct {
+		filter stacks.Filter
+		want   int
+	}{
+		{filter: stacks.Filter{Labels: env("staging")}, want: 0},
+		{filter: stacks.Filter{Labels: env("prod")}, want: 1},
+		{filter: stacks.Filter{Statuses: []stacks.Status{stacks.StatusActive}}, want: 0},
+		{filter: stacks.Filter{Statuses: []stacks.Status{stacks.StatusError}}, want: 1},
+	}
+
+	for _, tt := range tests {
+		found, findErr := repo.Find(t.Context(), tt.filter)
+		if findErr != nil {
+			t.Fatalf("Find() error = %v", findErr)
+		}
+		if len(found) != tt.want {
+			t.Errorf("Find(%+v) = %d stacks, want %d", tt.filter, len(found), tt.want)
+		}
+	}
+}
+
+func TestServiceFindUnknownStatus(t *testing.T) {
+	t.Parallel()
+
+	svc := stacks.NewService(newRepository(t), zap.NewNop())
+
+	_, err := svc.Find(t.Context(), stacks.Filter{Statuses: []stacks.Status{"paused"}})
+	if !errors.Is(err, stacks.ErrNotAllowed) {
+		t.Fatalf("Find() error = %v, want %v", err, stacks.ErrNotAllowed)
+	}
+}
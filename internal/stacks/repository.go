@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/apiarycd/apiarycd/pkg/badgerfx"
 	"github.com/dgraph-io/badger/v4"
@@ -36,6 +40,10 @@ func (r *Repository) Create(_ context.Context, stack StackDraft) (*Stack, error)
 			return fmt.Errorf("failed to check name uniqueness: %w", err)
 		}
 
+		if nsErr := r.checkNamespaces(txn, model, nil); nsErr != nil {
+			return nsErr
+		}
+
 		if writeErr := r.storage.Write(txn, model); writeErr != nil {
 			return writeErr //nolint:wrapcheck // wrapped outside of transaction
 		}
@@ -83,6 +91,7 @@ func (r *Repository) Update(_ context.Context, id uuid.UUID, updater func(*Stack
 			return fmt.Errorf("failed to update stack indexes: %w", indexErr)
 		}
 
+		namespaces := model.namespaces()
 		stack := model.toDomain()
 
 		if updErr := updater(stack); updErr != nil {
@@ -91,7 +100,7 @@ func (r *Repository) Update(_ context.Context, id uuid.UUID, updater func(*Stack
 
 		if model.Name != stack.Name {
 			return fmt.Errorf(
-				"%w: cannot change stack name (old=%s new=%s)",
+				"%w: cannot change stack name (old=%s new=%s), rename the stack instead",
 				ErrNotAllowed,
 				model.Name,
 				stack.Name,
@@ -100,11 +109,15 @@ func (r *Repository) Update(_ context.Context, id uuid.UUID, updater func(*Stack
 
 		model.update(stack.StackUpdate)
 
+		if nsErr := r.checkNamespaces(txn, model, namespaces); nsErr != nil {
+			return nsErr
+		}
+
 		if writeErr := r.storage.Write(txn, model); writeErr != nil {
 			return writeErr //nolint:wrapcheck // wrapped outside of transaction
 		}
 
-		return nil
+		return r.reindexNamespaces(txn, namespaces)
 	})
 
 	if err != nil {
@@ -114,10 +127,118 @@ func (r *Repository) Update(_ context.Context, id uuid.UUID, updater func(*Stack
 	return nil
 }
 
+// Rename changes the name of a stack and moves its name index. If migrate is
+// set and the namespace of the stack changes, the namespace it had before the
+// first rename since its last migration is kept to be removed by the next
+// deployment.
+func (r *Repository) Rename(_ context.Context, id uuid.UUID, name string, migrate bool) (*Stack, error) {
+	var model *stackModel
+
+	err := r.db.Update(func(txn *badger.Txn) error {
+		var err error
+		model, err = r.storage.Read(txn, id.String())
+		if err != nil {
+			return fmt.Errorf("failed to get stack before rename: %w", err)
+		}
+
+		if model.Name == name {
+			return nil
+		}
+
+		owner, err := r.storage.ReadByIndex(txn, prefixByName+name)
+		if err == nil {
+			return fmt.Errorf("%w: stack with name %q already exists (id=%s)", ErrConflict, name, owner.ID)
+		}
+		if !errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("failed to check name uniqueness: %w", err)
+		}
+
+		if indexErr := r.storage.DeleteIndexes(txn, model); indexErr != nil {
+			return fmt.Errorf("failed to update stack indexes: %w", indexErr)
+		}
+
+		namespaces := model.namespaces()
+		previous := Namespace(model.Name)
+		if migrate && model.PreviousNamespace == "" && previous != Namespace(name) {
+			// The resources of the previous namespace are removed by label,
+			// so they must not belong to another stack as well.
+			users, usersErr := r.namespaceUsers(txn, previous)
+			if usersErr != nil {
+				return usersErr
+			}
+			for _, user := range users {
+				if user.ID != model.ID {
+					return fmt.Errorf("%w: namespace %q is also used by stack %q, cannot migrate it",
+						ErrConflict, previous, user.Name)
+				}
+			}
+
+			model.PreviousNamespace = previous
+		}
+		if model.PreviousNamespace == Namespace(name) {
+			model.PreviousNamespace = ""
+		}
+
+		model.Name = name
+		model.UpdatedAt = time.Now()
+
+		if nsErr := r.checkNamespaces(txn, model, namespaces); nsErr != nil {
+			return nsErr
+		}
+
+		if writeErr := r.storage.Write(txn, model); writeErr != nil {
+			return writeErr //nolint:wrapcheck // wrapped outside of transaction
+		}
+
+		return r.reindexNamespaces(txn, namespaces)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename stack: %w", err)
+	}
+
+	return model.toDomain(), nil
+}
+
+// FindByNamespace returns the stacks whose namespace or previous namespace
+// awaiting removal is namespace.
+func (r *Repository) FindByNamespace(_ context.Context, namespace string) ([]Stack, error) {
+	var stacks []Stack
+
+	err := r.db.View(func(txn *badger.Txn) error {
+		users, err := r.namespaceUsers(txn, namespace)
+		if err != nil {
+			return err
+		}
+
+		stacks = make([]Stack, 0, len(users))
+		for _, user := range users {
+			stacks = append(stacks, *user.toDomain())
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stacks by namespace: %w", err)
+	}
+
+	return stacks, nil
+}
+
 // Delete deletes a stack.
 func (r *Repository) Delete(_ context.Context, id uuid.UUID) error {
 	err := r.db.Update(func(txn *badger.Txn) error {
-		return r.storage.Delete(txn, id.String())
+		model, err := r.storage.Read(txn, id.String())
+		if err != nil {
+			return err //nolint:wrapcheck // wrapped outside of transaction
+		}
+
+		if delErr := r.storage.Delete(txn, id.String()); delErr != nil {
+			return delErr //nolint:wrapcheck // wrapped outside of transaction
+		}
+
+		return r.reindexNamespaces(txn, model.namespaces())
 	})
 
 	if err != nil {
@@ -151,3 +272,200 @@ func (r *Repository) List(_ context.Context) ([]Stack, error) {
 
 	return stacks, nil
 }
+
+// Reindex indexes the namespaces of the stacks stored before namespaces were
+// indexed and drops stale index entries. It returns the number of entries
+// changed and the namespaces shared by several stacks, which were created
+// before namespaces were unique. The index of a shared namespace points at its
+// oldest stack; all of them keep working, but no other stack may use it.
+func (r *Repository) Reindex(_ context.Context) (int, []NamespaceCollision, error) {
+	var (
+		changed    int
+		collisions []NamespaceCollision
+	)
+
+	err := r.db.Update(func(txn *badger.Txn) error {
+		items, err := r.storage.List(txn, prefixByID, badger.DefaultIteratorOptions)
+		if err != nil {
+			return fmt.Errorf("failed to list stacks: %w", err)
+		}
+
+		users := make(map[string][]*stackModel, len(items))
+		for _, item := range items {
+			for _, namespace := range item.namespaces() {
+				users[namespace] = append(users[namespace], item)
+			}
+		}
+
+		for _, namespace := range r.indexedNamespaces(txn) {
+			if _, ok := users[namespace]; !ok {
+				users[namespace] = nil
+			}
+		}
+
+		for _, namespace := range slices.Sorted(maps.Keys(users)) {
+			updated, idxErr := r.indexNamespace(txn, namespace, users[namespace])
+			if idxErr != nil {
+				return idxErr
+			}
+			if updated {
+				changed++
+			}
+
+			if len(users[namespace]) > 1 {
+				collision, colErr := r.collision(txn, namespace, users[namespace])
+				if colErr != nil {
+					return colErr
+				}
+				collisions = append(collisions, collision)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to reindex stacks: %w", err)
+	}
+
+	return changed, collisions, nil
+}
+
+// checkNamespaces checks that no other stack owns the namespaces of model.
+// Namespaces are derived from names lossily, so distinct names may collide.
+// Namespaces in kept were used by model before, and stay usable even if they
+// are shared with stacks created before namespaces were unique.
+func (r *Repository) checkNamespaces(txn *badger.Txn, model *stackModel, kept []string) error {
+	for _, namespace := range model.namespaces() {
+		if slices.Contains(kept, namespace) {
+			continue
+		}
+
+		owner, err := r.storage.ReadByIndex(txn, prefixByNamespace+namespace)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check namespace uniqueness: %w", err)
+		}
+
+		if owner.ID != model.ID {
+			return fmt.Errorf("%w: namespace %q is used by stack %q", ErrConflict, namespace, owner.Name)
+		}
+	}
+
+	return nil
+}
+
+// reindexNamespaces keeps the index of each namespace pointing at a stack that
+// still uses it, as a stack leaving a namespace shared with a stack created
+// before namespaces were unique removes the index of the other stack too.
+func (r *Repository) reindexNamespaces(txn *badger.Txn, namespaces []string) error {
+	for _, namespace := range namespaces {
+		owner, err := r.storage.ReadByIndex(txn, prefixByNamespace+namespace)
+		if err == nil && slices.Contains(owner.namespaces(), namespace) {
+			continue
+		}
+
+		users, err := r.namespaceUsers(txn, namespace)
+		if err != nil {
+			return err
+		}
+
+		if _, idxErr := r.indexNamespace(txn, namespace, users); idxErr != nil {
+			return idxErr
+		}
+	}
+
+	return nil
+}
+
+// indexNamespace points the index of namespace at the stack it already points
+// at if that is one of users, or at the first of users otherwise. The index is
+// removed if there are no users. It reports whether the index has changed.
+func (r *Repository) indexNamespace(txn *badger.Txn, namespace string, users []*stackModel) (bool, error) {
+	key := []byte(prefixByNamespace + namespace)
+
+	owner, err := r.storage.ReadByIndex(txn, string(key))
+	if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+		return false, fmt.Errorf("failed to get namespace index: %w", err)
+	}
+	found := err == nil
+
+	if len(users) == 0 {
+		if _, getErr := txn.Get(key); errors.Is(getErr, badger.ErrKeyNotFound) {
+			return false, nil
+		}
+		if delErr := txn.Delete(key); delErr != nil {
+			return false, fmt.Errorf("failed to delete namespace index: %w", delErr)
+		}
+		return true, nil
+	}
+
+	if found && slices.ContainsFunc(users, func(user *stackModel) bool { return user.ID == owner.ID }) {
+		return false, nil
+	}
+
+	if setErr := txn.Set(key, []byte(users[0].StorageKey())); setErr != nil {
+		return false, fmt.Errorf("failed to set namespace index: %w", setErr)
+	}
+
+	return true, nil
+}
+
+// indexedNamespaces returns the namespaces in the index.
+func (r *Repository) indexedNamespaces(txn *badger.Txn) []string {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var namespaces []string
+	prefix := []byte(prefixByNamespace)
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		namespaces = append(namespaces, strings.TrimPrefix(string(it.Item().Key()), prefixByNamespace))
+	}
+
+	return namespaces
+}
+
+// collision describes a namespace shared by users once it has been indexed.
+func (r *Repository) collision(txn *badger.Txn, namespace string, users []*stackModel) (NamespaceCollision, error) {
+	owner, err := r.storage.ReadByIndex(txn, prefixByNamespace+namespace)
+	if err != nil {
+		return NamespaceCollision{}, fmt.Errorf("failed to get namespace index: %w", err)
+	}
+
+	collision := NamespaceCollision{
+		Namespace: namespace,
+		Owner:     owner.Name,
+		Others:    make([]string, 0, len(users)-1),
+	}
+	for _, user := range users {
+		if user.ID != owner.ID {
+			collision.Others = append(collision.Others, user.Name)
+		}
+	}
+
+	return collision, nil
+}
+
+// namespaceUsers returns the stacks using namespace, oldest first as IDs are
+// time ordered. Unlike the namespace index, it scans every stack, so stacks
+// sharing a namespace are all found.
+func (r *Repository) namespaceUsers(txn *badger.Txn, namespace string) ([]*stackModel, error) {
+	items, err := r.storage.List(txn, prefixByID, badger.DefaultIteratorOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stacks: %w", err)
+	}
+
+	users := make([]*stackModel, 0, 1)
+	for _, item := range items {
+		if slices.Contains(item.namespaces(), namespace) {
+			users = append(users, item)
+		}
+	}
+
+	return users, nil
+}
@@ -0,0 +1,254 @@
+package stacks_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	"github.com/dgraph-io/badger/v4"
+)
+
+func newRepository(t *testing.T) *stacks.Repository {
+	t.Helper()
+
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	return stacks.NewRepository(db)
+}
+
+func newDraft(name string, labels map[string]string) stacks.StackDraft {
+	return stacks.StackDraft{
+		Name:        name,
+		GitURL:      "https://example.com/" + name + ".git",
+		GitBranch:   "main",
+		ComposePath: "docker-compose.yml",
+		Labels:      labels,
+	}
+}
+
+func TestRepositoryCreateUniqueness(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		stack   string
+		wantErr error
+	}{
+		{name: "same name", stack: "my-app", wantErr: stacks.ErrConflict},
+		{name: "same namespace with spaces", stack: "My App", wantErr: stacks.ErrConflict},
+		{name: "same namespace with underscores", stack: "My_App", wantErr: stacks.ErrConflict},
+		{name: "other namespace", stack: "my-app-2", wantErr: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newRepository(t)
+			if _, err := repo.Create(t.Context(), newDraft("my-app", nil)); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			_, err := repo.Create(t.Context(), newDraft(tt.stack, nil))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create(%q) error = %v, want %v", tt.stack, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRepositoryUpdateNamespace(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		namespace string
+		wantErr   error
+	}{
+		{name: "own namespace", namespace: "", wantErr: nil},
+		{name: "free namespace", namespace: "legacy", wantErr: nil},
+		{name: "namespace of another stack", namespace: "other", wantErr: stacks.ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newRepository(t)
+			stack, err := repo.Create(t.Context(), newDraft("app", nil))
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if _, err = repo.Create(t.Context(), newDraft("Other", nil)); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			err = repo.Update(t.Context(), stack.ID, func(s *stacks.Stack) error {
+				s.Description = "updated"
+				s.PreviousNamespace = tt.namespace
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Update() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRepositoryRename(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		to       string
+		migrate  bool
+		wantErr  error
+		previous string
+	}{
+		{name: "same namespace", to: "App", migrate: false, wantErr: nil, previous: ""},
+		{name: "without migration", to: "web", migrate: false, wantErr: nil, previous: ""},
+		{name: "with migration", to: "web", migrate: true, wantErr: nil, previous: "app"},
+		{name: "taken name", to: "other", migrate: true, wantErr: stacks.ErrConflict},
+		{name: "taken namespace", to: "Other", migrate: true, wantErr: stacks.ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newRepository(t)
+			stack, err := repo.Create(t.Context(), newDraft("app", nil))
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if _, err = repo.Create(t.Context(), newDraft("other", nil)); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			renamed, err := repo.Rename(t.Context(), stack.ID, tt.to, tt.migrate)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Rename() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if renamed.Name != tt.to || renamed.PreviousNamespace != tt.previous {
+				t.Fatalf("Rename() = %q (previous %q), want %q (previous %q)",
+					renamed.Name, renamed.PreviousNamespace, tt.to, tt.previous)
+			}
+
+			// The namespaces of the stack are reserved until removed.
+			for _, name := range []string{tt.to, tt.previous} {
+				if name == "" {
+					continue
+				}
+				if _, createErr := repo.Create(t.Context(), newDraft(name, nil)); !errors.Is(createErr, stacks.ErrConflict) {
+					t.Errorf("Create(%q) error = %v, want %v", name, createErr, stacks.ErrConflict)
+				}
+			}
+		})
+	}
+}
+
+func TestRepositoryFindByNamespace(t *testing.T) {
+	t.Parallel()
+
+	repo := newRepository(t)
+	stack, err := repo.Create(t.Context(), newDraft("app", nil))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err = repo.Rename(t.Context(), stack.ID, "web", true); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+
+	tests := []struct {
+		namespace string
+		want      int
+	}{
+		{namespace: "web", want: 1},
+		{namespace: "app", want: 1},
+		{namespace: "other", want: 0},
+	}
+
+	for _, tt := range tests {
+		found, findErr := repo.FindByNamespace(t.Context(), tt.namespace)
+		if findErr != nil {
+			t.Fatalf("FindByNamespace(%q) error = %v", tt.namespace, findErr)
+		}
+		if len(found) != tt.want {
+			t.Errorf("FindByNamespace(%q) = %d stacks, want %d", tt.namespace, len(found), tt.want)
+		}
+	}
+}
+
+func TestRepositoryReindex(t *testing.T) {
+	t.Parallel()
+
+	repo := newRepository(t)
+	create := func(name string) *stacks.Stack {
+		t.Helper()
+
+		stack, err := repo.CreateUnindexed(newDraft(name, nil))
+		if err != nil {
+			t.Fatalf("CreateUnindexed(%q) error = %v", name, err)
+		}
+		return stack
+	}
+	owner := create("my-app")
+	other := create("My App")
+	create("web")
+	if _, err := repo.Create(t.Context(), newDraft("indexed", nil)); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	wantCollisions := []stacks.NamespaceCollision{{Namespace: "my-app", Owner: "my-app", Others: []string{"My App"}}}
+	for i, wantReindexed := range []int{2, 0} {
+		reindexed, collisions, err := repo.Reindex(t.Context())
+		if err != nil {
+			t.Fatalf("Reindex() #%d error = %v", i+1, err)
+		}
+		if reindexed != wantReindexed {
+			t.Errorf("Reindex() #%d reindexed = %d, want %d", i+1, reindexed, wantReindexed)
+		}
+		if !reflect.DeepEqual(collisions, wantCollisions) {
+			t.Errorf("Reindex() #%d collisions = %+v, want %+v", i+1, collisions, wantCollisions)
+		}
+	}
+
+	for _, name := range []string{"WEB", "my_app"} {
+		if _, err := repo.Create(t.Context(), newDraft(name, nil)); !errors.Is(err, stacks.ErrConflict) {
+			t.Errorf("Create(%q) error = %v, want %v", name, err, stacks.ErrConflict)
+		}
+	}
+
+	// Stacks sharing a namespace keep working.
+	for _, stack := range []*stacks.Stack{owner, other} {
+		if err := repo.Update(t.Context(), stack.ID, func(s *stacks.Stack) error {
+			s.Description = "updated"
+			return nil
+		}); err != nil {
+			t.Errorf("Update(%q) error = %v", stack.Name, err)
+		}
+	}
+
+	// The namespace stays reserved until no stack uses it.
+	if err := repo.Delete(t.Context(), owner.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.Create(t.Context(), newDraft("my_app", nil)); !errors.Is(err, stacks.ErrConflict) {
+		t.Errorf("Create() after deleting one stack error = %v, want %v", err, stacks.ErrConflict)
+	}
+	if _, err := repo.Rename(t.Context(), other.ID, "web-2", false); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if _, err := repo.Create(t.Context(), newDraft("my_app", nil)); err != nil {
+		t.Errorf("Create() after renaming the last stack error = %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package stacks
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Operator string
+
+const (
+	OperatorEquals       Operator = "="
+	OperatorNotEquals    Operator = "!="
+	OperatorIn           Operator = "in"
+	OperatorNotIn        Operator = "notin"
+	OperatorExists       Operator = "exists"
+	OperatorDoesNotExist Operator = "!"
+)
+
+// LabelSelector is a requirement on a single label of a stack.
+type LabelSelector struct {
+	Key      string
+	Operator Operator
+	Values   []string // One value for equality, any number for set operators
+}
+
+// Filter selects the stacks to list. Empty fields match every stack.
+type Filter struct {
+	Statuses []Status        // Any of the statuses
+	Labels   []LabelSelector // All of the selectors
+}
+
+// ParseLabelSelectors parses a comma separated list of label selectors:
+//
+//	key=value, key==value  label is set to value
+//	key!=value             label is not set to value or not set at all
+//	key in (a,b)           label is set to one of the values
+//	key notin (a,b)        label is not set to any of the values or not set at all
+//	key                    label is set
+//	!key                   label is not set
+func ParseLabelSelectors(s string) ([]LabelSelector, error) {
+	var selectors []LabelSelector
+
+	for _, part := range splitSelectors(s) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		selector, err := parseLabelSelector(part)
+		if err != nil {
+			return nil, err
+		}
+
+		selectors = append(selectors, selector)
+	}
+
+	return selectors, nil
+}
+
+// splitSelectors splits s on commas outside of parentheses.
+func splitSelectors(s string) []string {
+	var (
+		parts []string
+		depth int
+		start int
+	)
+
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+func parseLabelSelector(s string) (LabelSelector, error) {
+	if strings.HasSuffix(s, ")") {
+		if key, values, ok := strings.Cut(s, " notin "); ok {
+			return parseSetSelector(s, key, OperatorNotIn, values)
+		}
+
+		if key, values, ok := strings.Cut(s, " in "); ok {
+			return parseSetSelector(s, key, OperatorIn, values)
+		}
+	}
+
+	if key, ok := strings.CutPrefix(s, "!"); ok {
+		return newLabelSelector(s, key, OperatorDoesNotExist, nil)
+	}
+
+	if key, value, ok := strings.Cut(s, "!="); ok {
+		return newLabelSelector(s, key, OperatorNotEquals, []string{value})
+	}
+
+	if key, value, ok := strings.Cut(s, "="); ok {
+		return newLabelSelector(s, key, OperatorEquals, []string{strings.TrimPrefix(value, "=")})
+	}
+
+	return newLabelSelector(s, s, OperatorExists, nil)
+}
+
+func parseSetSelector(s, key string, op Operator, values string) (LabelSelector, error) {
+	values = strings.TrimSpace(values)
+	if !strings.HasPrefix(values, "(") || !strings.HasSuffix(values, ")") {
+		return LabelSelector{}, fmt.Errorf("%w: selector %q: values must be enclosed in parentheses", ErrNotAllowed, s)
+	}
+
+	var set []string
+	for value := range strings.SplitSeq(values[1:len(values)-1], ",") {
+		set = append(set, strings.TrimSpace(value))
+	}
+
+	return newLabelSelector(s, key, op, set)
+}
+
+func newLabelSelector(s, key string, op Operator, values []string) (LabelSelector, error) {
+	key = strings.TrimSpace(key)
+	if key == "" || strings.ContainsAny(key, "!=() ") {
+		return LabelSelector{}, fmt.Errorf("%w: selector %q: invalid label key", ErrNotAllowed, s)
+	}
+
+	for i, value := range values {
+		values[i] = strings.TrimSpace(value)
+	}
+
+	return LabelSelector{
+		Key:      key,
+		Operator: op,
+		Values:   values,
+	}, nil
+}
@@ -0,0 +1,87 @@
+package stacks_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/stacks"
+)
+
+func TestParseLabelSelectors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []stacks.LabelSelector
+		wantErr error
+	}{
+		{name: "empty", input: "", want: nil},
+		{
+			name:  "equals",
+			input: "env=prod",
+			want:  []stacks.LabelSelector{{Key: "env", Operator: stacks.OperatorEquals, Values: []string{"prod"}}},
+		},
+		{
+			name:  "double equals",
+			input: "env==prod",
+			want:  []stacks.LabelSelector{{Key: "env", Operator: stacks.OperatorEquals, Values: []string{"prod"}}},
+		},
+		{
+			name:  "not equals",
+			input: "env!=prod",
+			want:  []stacks.LabelSelector{{Key: "env", Operator: stacks.OperatorNotEquals, Values: []string{"prod"}}},
+		},
+		{
+			name:  "in",
+			input: "env in (prod, staging)",
+			want: []stacks.LabelSelector{
+				{Key: "env", Operator: stacks.OperatorIn, Values: []string{"prod", "staging"}},
+			},
+		},
+		{
+			name:  "not in",
+			input: "env notin (dev)",
+			want:  []stacks.LabelSelector{{Key: "env", Operator: stacks.OperatorNotIn, Values: []string{"dev"}}},
+		},
+		{
+			name:  "exists",
+			input: "team",
+			want:  []stacks.LabelSelector{{Key: "team", Operator: stacks.OperatorExists, Values: nil}},
+		},
+		{
+			name:  "does not exist",
+			input: "!team",
+			want:  []stacks.LabelSelector{{Key: "team", Operator: stacks.OperatorDoesNotExist, Values: nil}},
+		},
+		{
+			name:  "list with sets",
+			input: "env in (prod,staging), team , !legacy,,tier=web",
+			want: []stacks.LabelSelector{
+				{Key: "env", Operator: stacks.OperatorIn, Values: []string{"prod", "staging"}},
+				{Key: "team", Operator: stacks.OperatorExists, Values: nil},
+				{Key: "legacy", Operator: stacks.OperatorDoesNotExist, Values: nil},
+				{Key: "tier", Operator: stacks.OperatorEquals, Values: []string{"web"}},
+			},
+		},
+		{name: "missing key", input: "=prod", wantErr: stacks.ErrNotAllowed},
+		{name: "key with spaces", input: "my env=prod", wantErr: stacks.ErrNotAllowed},
+		{name: "set without parentheses", input: "env in prod)", wantErr: stacks.ErrNotAllowed},
+		{name: "unbalanced parentheses", input: "env in (prod", wantErr: stacks.ErrNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := stacks.ParseLabelSelectors(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseLabelSelectors(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLabelSelectors(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package stacks
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -23,6 +24,10 @@ func NewService(stacks *Repository, logger *zap.Logger) *Service {
 func (s *Service) Create(ctx context.Context, draft StackDraft) (*Stack, error) {
 	s.logger.Info("creating stack", zap.String("name", draft.Name))
 
+	if err := draft.Validate(); err != nil {
+		return nil, err
+	}
+
 	stack, err := s.stacks.Create(ctx, draft)
 	if err != nil {
 		s.logger.Error("failed to create stack", zap.Error(err))
@@ -58,6 +63,26 @@ func (s *Service) List(ctx context.Context) ([]Stack, error) {
 	return stacks, nil
 }
 
+// Find lists the stacks matching filter.
+func (s *Service) Find(ctx context.Context, filter Filter) ([]Stack, error) {
+	s.logger.Info("finding stacks", zap.Int("statuses", len(filter.Statuses)), zap.Int("selectors", len(filter.Labels)))
+
+	for _, status := range filter.Statuses {
+		if !status.isValid() {
+			return nil, fmt.Errorf("%w: unknown status %q", ErrNotAllowed, status)
+		}
+	}
+
+	stacks, err := s.stacks.Find(ctx, filter)
+	if err != nil {
+		s.logger.Error("failed to find stacks", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("stacks found", zap.Int("count", len(stacks)))
+	return stacks, nil
+}
+
 func (s *Service) Update(ctx context.Context, id uuid.UUID, updater func(*Stack) error) error {
 	s.logger.Info("updating stack", zap.String("id", id.String()))
 
@@ -71,6 +96,56 @@ func (s *Service) Update(ctx context.Context, id uuid.UUID, updater func(*Stack)
 	return nil
 }
 
+// Rename changes the name of a stack. See Repository.Rename for migrate.
+func (s *Service) Rename(ctx context.Context, id uuid.UUID, name string, migrate bool) (*Stack, error) {
+	s.logger.Info("renaming stack", zap.String("id", id.String()), zap.String("name", name), zap.Bool("migrate", migrate))
+
+	stack, err := s.stacks.Rename(ctx, id, name, migrate)
+	if err != nil {
+		s.logger.Error("failed to rename stack", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("stack renamed", zap.String("id", id.String()), zap.String("previous_namespace", stack.PreviousNamespace))
+	return stack, nil
+}
+
+// FindByNamespace lists the stacks using namespace, either as their namespace
+// or as the previous one awaiting removal.
+func (s *Service) FindByNamespace(ctx context.Context, namespace string) ([]Stack, error) {
+	stacks, err := s.stacks.FindByNamespace(ctx, namespace)
+	if err != nil {
+		s.logger.Error("failed to find stacks by namespace", zap.String("namespace", namespace), zap.Error(err))
+		return nil, err
+	}
+
+	return stacks, nil
+}
+
+// Reindex indexes the namespaces of stacks stored before namespaces were
+// indexed. Namespaces shared by such stacks are reported; the stacks keep
+// working until all but one of them are renamed or deleted.
+func (s *Service) Reindex(ctx context.Context) error {
+	reindexed, collisions, err := s.stacks.Reindex(ctx)
+	if err != nil {
+		s.logger.Error("failed to reindex stacks", zap.Error(err))
+		return err
+	}
+
+	if reindexed > 0 {
+		s.logger.Info("stacks reindexed", zap.Int("count", reindexed))
+	}
+	for _, c := range collisions {
+		s.logger.Warn("namespace shared by several stacks, rename all but one of them",
+			zap.String("namespace", c.Namespace),
+			zap.String("owner", c.Owner),
+			zap.Strings("others", c.Others),
+		)
+	}
+
+	return nil
+}
+
 func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
 	s.logger.Info("deleting stack", zap.String("id", id.String()))
 
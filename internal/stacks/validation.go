@@ -0,0 +1,69 @@
+package stacks
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// draftRules are the constraints on a stack definition. They match the
+// validation of the API requests, so that stacks defined through the API and
+// declaratively accept the same values.
+type draftRules struct {
+	Name        string `json:"name"         validate:"required,min=1,max=100"`
+	Description string `json:"description"  validate:"max=500"`
+	GitURL      string `json:"git_url"      validate:"required,url|startswith=git@"`
+	GitBranch   string `json:"git_branch"   validate:"required,min=1,max=100"`
+	ComposePath string `json:"compose_path" validate:"required,min=1,max=255"`
+
+	WebhookSecret string `json:"webhook_secret" validate:"max=255"`
+
+	DeployTimeout time.Duration `json:"deploy_timeout" validate:"min=0s,max=24h"`
+	DeployPolicy  string        `json:"deploy_policy"  validate:"omitempty,oneof=queue reject"`
+}
+
+//nolint:gochecknoglobals // the validator caches struct metadata
+var draftValidator = newDraftValidator()
+
+func newDraftValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return field.Tag.Get("json")
+	})
+
+	return v
+}
+
+// Validate checks the definition of the stack.
+func (d *StackDraft) Validate() error {
+	err := draftValidator.Struct(draftRules{
+		Name:          d.Name,
+		Description:   d.Description,
+		GitURL:        d.GitURL,
+		GitBranch:     d.GitBranch,
+		ComposePath:   d.ComposePath,
+		WebhookSecret: d.WebhookSecret,
+		DeployTimeout: d.DeployTimeout,
+		DeployPolicy:  string(d.DeployPolicy),
+	})
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err //nolint:wrapcheck // nil or an invalid validation
+	}
+
+	messages := make([]string, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		rule := fe.Tag()
+		if fe.Param() != "" {
+			rule += "=" + fe.Param()
+		}
+		messages = append(messages, fmt.Sprintf("%s does not satisfy %s", fe.Field(), rule))
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalid, strings.Join(messages, ", "))
+}
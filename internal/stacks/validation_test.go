@@ -0,0 +1,89 @@
+package stacks_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apiarycd/apiarycd/internal/stacks"
+)
+
+func TestStackDraftValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		update  func(*stacks.StackDraft)
+		wantErr error
+	}{
+		{name: "valid", update: func(*stacks.StackDraft) {}},
+		{name: "ssh url", update: func(d *stacks.StackDraft) { d.GitURL = "git@example.com:app.git" }},
+		{name: "longest name", update: func(d *stacks.StackDraft) { d.Name = strings.Repeat("a", 100) }},
+		{name: "day long timeout", update: func(d *stacks.StackDraft) { d.DeployTimeout = 24 * time.Hour }},
+		{name: "reject policy", update: func(d *stacks.StackDraft) { d.DeployPolicy = stacks.DeployPolicyReject }},
+		{
+			name:    "missing name",
+			update:  func(d *stacks.StackDraft) { d.Name = "" },
+			wantErr: stacks.ErrInvalid,
+		},
+		{
+			name:    "long name",
+			update:  func(d *stacks.StackDraft) { d.Name = strings.Repeat("a", 101) },
+			wantErr: stacks.ErrInvalid,
+		},
+		{
+			name:    "long description",
+			update:  func(d *stacks.StackDraft) { d.Description = strings.Repeat("a", 501) },
+			wantErr: stacks.ErrInvalid,
+		},
+		{
+			name:    "invalid url",
+			update:  func(d *stacks.StackDraft) { d.GitURL = "example.com/app" },
+			wantErr: stacks.ErrInvalid,
+		},
+		{
+			name:    "missing branch",
+			update:  func(d *stacks.StackDraft) { d.GitBranch = "" },
+			wantErr: stacks.ErrInvalid,
+		},
+		{
+			name:    "long compose path",
+			update:  func(d *stacks.StackDraft) { d.ComposePath = strings.Repeat("a", 256) },
+			wantErr: stacks.ErrInvalid,
+		},
+		{
+			name:    "long webhook secret",
+			update:  func(d *stacks.StackDraft) { d.WebhookSecret = strings.Repeat("a", 256) },
+			wantErr: stacks.ErrInvalid,
+		},
+		{
+			name:    "negative timeout",
+			update:  func(d *stacks.StackDraft) { d.DeployTimeout = -time.Second },
+			wantErr: stacks.ErrInvalid,
+		},
+		{
+			name:    "timeout over a day",
+			update:  func(d *stacks.StackDraft) { d.DeployTimeout = 25 * time.Hour },
+			wantErr: stacks.ErrInvalid,
+		},
+		{
+			name:    "unknown policy",
+			update:  func(d *stacks.StackDraft) { d.DeployPolicy = "drop" },
+			wantErr: stacks.ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			draft := newDraft("app", nil)
+			tt.update(&draft)
+
+			if err := draft.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/moby/moby/api/types/swarm"
+	"github.com/moby/moby/client"
+	"go.uber.org/zap"
+)
+
+// ListConfigs lists configs matching filters.
+func (s *Swarm) ListConfigs(ctx context.Context, filters client.Filters) ([]swarm.Config, error) {
+	s.logger.Debug("Listing configs")
+
+	result, err := s.client.ConfigList(ctx, client.ConfigListOptions{
+		Filters: filters,
+	})
+	if err != nil {
+		s.logger.Error("Failed to list configs", zap.Error(err))
+		return nil, fmt.Errorf("failed to list configs: %w", err)
+	}
+
+	return result.Items, nil
+}
+
+// CreateConfig creates a new config.
+func (s *Swarm) CreateConfig(ctx context.Context, config swarm.ConfigSpec) (string, error) {
+	s.logger.Info("Creating config", zap.String("name", config.Name))
+
+	result, err := s.client.ConfigCreate(ctx, client.ConfigCreateOptions{
+		Spec: config,
+	})
+	if err != nil {
+		s.logger.Error("Failed to create config", zap.Error(err), zap.String("name", config.Name))
+		return "", fmt.Errorf("failed to create config: %w", err)
+	}
+
+	s.logger.Info("Config created successfully", zap.String("id", result.ID), zap.String("name", config.Name))
+	return result.ID, nil
+}
+
+// RemoveConfig removes a config.
+func (s *Swarm) RemoveConfig(ctx context.Context, configID string) error {
+	s.logger.Info("Removing config", zap.String("id", configID))
+
+	if _, err := s.client.ConfigRemove(ctx, configID, client.ConfigRemoveOptions{}); err != nil {
+		s.logger.Error("Failed to remove config", zap.Error(err), zap.String("id", configID))
+		return fmt.Errorf("failed to remove config: %w", err)
+	}
+
+	s.logger.Info("Config removed successfully", zap.String("id", configID))
+	return nil
+}
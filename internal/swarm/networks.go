@@ -0,0 +1,52 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/moby/moby/api/types/network"
+	"github.com/moby/moby/client"
+	"go.uber.org/zap"
+)
+
+// ListNetworks lists networks matching filters.
+func (s *Swarm) ListNetworks(ctx context.Context, filters client.Filters) ([]network.Summary, error) {
+	s.logger.Debug("Listing networks")
+
+	result, err := s.client.NetworkList(ctx, client.NetworkListOptions{
+		Filters: filters,
+	})
+	if err != nil {
+		s.logger.Error("Failed to list networks", zap.Error(err))
+		return nil, fmt.Errorf("failed to list networks: %w", err)
+	}
+
+	return result.Items, nil
+}
+
+// CreateNetwork creates a new network.
+func (s *Swarm) CreateNetwork(ctx context.Context, name string, options client.NetworkCreateOptions) (string, error) {
+	s.logger.Info("Creating network", zap.String("name", name), zap.String("driver", options.Driver))
+
+	result, err := s.client.NetworkCreate(ctx, name, options)
+	if err != nil {
+		s.logger.Error("Failed to create network", zap.Error(err), zap.String("name", name))
+		return "", fmt.Errorf("failed to create network: %w", err)
+	}
+
+	s.logger.Info("Network created successfully", zap.String("id", result.ID), zap.String("name", name))
+	return result.ID, nil
+}
+
+// RemoveNetwork removes a network.
+func (s *Swarm) RemoveNetwork(ctx context.Context, networkID string) error {
+	s.logger.Info("Removing network", zap.String("id", networkID))
+
+	if _, err := s.client.NetworkRemove(ctx, networkID, client.NetworkRemoveOptions{}); err != nil {
+		s.logger.Error("Failed to remove network", zap.Error(err), zap.String("id", networkID))
+		return fmt.Errorf("failed to remove network: %w", err)
+	}
+
+	s.logger.Info("Network removed successfully", zap.String("id", networkID))
+	return nil
+}
@@ -0,0 +1,54 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/moby/moby/api/types/swarm"
+	"github.com/moby/moby/client"
+	"go.uber.org/zap"
+)
+
+// ListSecrets lists secrets matching filters.
+func (s *Swarm) ListSecrets(ctx context.Context, filters client.Filters) ([]swarm.Secret, error) {
+	s.logger.Debug("Listing secrets")
+
+	result, err := s.client.SecretList(ctx, client.SecretListOptions{
+		Filters: filters,
+	})
+	if err != nil {
+		s.logger.Error("Failed to list secrets", zap.Error(err))
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	return result.Items, nil
+}
+
+// CreateSecret creates a new secret.
+func (s *Swarm) CreateSecret(ctx context.Context, secret swarm.SecretSpec) (string, error) {
+	s.logger.Info("Creating secret", zap.String("name", secret.Name))
+
+	result, err := s.client.SecretCreate(ctx, client.SecretCreateOptions{
+		Spec: secret,
+	})
+	if err != nil {
+		s.logger.Error("Failed to create secret", zap.Error(err), zap.String("name", secret.Name))
+		return "", fmt.Errorf("failed to create secret: %w", err)
+	}
+
+	s.logger.Info("Secret created successfully", zap.String("id", result.ID), zap.String("name", secret.Name))
+	return result.ID, nil
+}
+
+// RemoveSecret removes a secret.
+func (s *Swarm) RemoveSecret(ctx context.Context, secretID string) error {
+	s.logger.Info("Removing secret", zap.String("id", secretID))
+
+	if _, err := s.client.SecretRemove(ctx, secretID, client.SecretRemoveOptions{}); err != nil {
+		s.logger.Error("Failed to remove secret", zap.Error(err), zap.String("id", secretID))
+		return fmt.Errorf("failed to remove secret: %w", err)
+	}
+
+	s.logger.Info("Secret removed successfully", zap.String("id", secretID))
+	return nil
+}
@@ -87,11 +87,14 @@ func (s *Swarm) LeaveSwarm(ctx context.Context, force bool) error {
 	return nil
 }
 
-// ListServices lists all services in the Swarm.
-func (s *Swarm) ListServices(ctx context.Context) ([]swarm.Service, error) {
+// ListServices lists services in the Swarm matching filters.
+func (s *Swarm) ListServices(ctx context.Context, filters client.Filters) ([]swarm.Service, error) {
 	s.logger.Debug("Listing Swarm services")
 
-	result, err := s.client.ServiceList(ctx, client.ServiceListOptions{})
+	result, err := s.client.ServiceList(ctx, client.ServiceListOptions{
+		Filters: filters,
+		Status:  true,
+	})
 	if err != nil {
 		s.logger.Error("Failed to list services", zap.Error(err))
 		return nil, fmt.Errorf("failed to list services: %w", err)
@@ -123,6 +126,73 @@ func (s *Swarm) CreateService(ctx context.Context, service swarm.ServiceSpec) (s
 	return result.ID, nil
 }
 
+// InspectService returns a service by ID or name.
+func (s *Swarm) InspectService(ctx context.Context, serviceID string) (swarm.Service, error) {
+	s.logger.Debug("Inspecting service", zap.String("id", serviceID))
+
+	result, err := s.client.ServiceInspect(ctx, serviceID, client.ServiceInspectOptions{})
+	if err != nil {
+		s.logger.Error("Failed to inspect service", zap.Error(err), zap.String("id", serviceID))
+		return swarm.Service{}, fmt.Errorf("failed to inspect service: %w", err)
+	}
+
+	return result.Service, nil
+}
+
+// UpdateService updates a service. version must be the version index of the
+// service as read before the update.
+func (s *Swarm) UpdateService(
+	ctx context.Context,
+	serviceID string,
+	version swarm.Version,
+	service swarm.ServiceSpec,
+) error {
+	s.logger.Info("Updating service",
+		zap.String("id", serviceID),
+		zap.String("name", service.Name),
+		zap.Uint64("version", version.Index),
+	)
+
+	result, err := s.client.ServiceUpdate(ctx, serviceID, client.ServiceUpdateOptions{
+		Version: version,
+		Spec:    service,
+	})
+	if err != nil {
+		s.logger.Error("Failed to update service", zap.Error(err), zap.String("id", serviceID))
+		return fmt.Errorf("failed to update service: %w", err)
+	}
+
+	for _, warning := range result.Warnings {
+		s.logger.Warn("Service update warning", zap.String("id", serviceID), zap.String("warning", warning))
+	}
+
+	s.logger.Info("Service updated successfully", zap.String("id", serviceID))
+	return nil
+}
+
+// RollbackService rolls a service back to its previous spec.
+func (s *Swarm) RollbackService(ctx context.Context, serviceID string) error {
+	s.logger.Info("Rolling back service", zap.String("id", serviceID))
+
+	current, err := s.InspectService(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.ServiceUpdate(ctx, serviceID, client.ServiceUpdateOptions{
+		Version:  current.Version,
+		Spec:     current.Spec,
+		Rollback: "previous",
+	})
+	if err != nil {
+		s.logger.Error("Failed to roll back service", zap.Error(err), zap.String("id", serviceID))
+		return fmt.Errorf("failed to roll back service: %w", err)
+	}
+
+	s.logger.Info("Service rolled back successfully", zap.String("id", serviceID))
+	return nil
+}
+
 // RemoveService removes a service from the Swarm.
 func (s *Swarm) RemoveService(ctx context.Context, serviceID string) error {
 	s.logger.Info("Removing service", zap.String("id", serviceID))
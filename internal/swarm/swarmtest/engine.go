@@ -0,0 +1,618 @@
+// Package swarmtest provides a fake Docker Engine API for testing code that
+// manages Swarm resources.
+package swarmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/swarm"
+	"github.com/moby/moby/api/types/network"
+	swarmtypes "github.com/moby/moby/api/types/swarm"
+	"github.com/moby/moby/client"
+	"go.uber.org/zap"
+)
+
+//nolint:gochecknoglobals // compiled once
+var apiVersionPrefix = regexp.MustCompile(`^/v[0-9.]+`)
+
+// Engine is a fake Docker Engine API keeping the services, networks, configs
+// and secrets of a Swarm in memory. It supports listing, creating and
+// removing them, updating and rolling back services, and the label and name
+// filters. Tasks are never scheduled.
+type Engine struct {
+	mu sync.Mutex
+
+	services map[string]swarmtypes.Service
+	networks map[string]network.Summary
+	configs  map[string]swarmtypes.Config
+	secrets  map[string]swarmtypes.Secret
+
+	failures map[string]bool // Names of resources whose creation fails
+	lastID   int
+	requests int
+
+	mux *http.ServeMux
+}
+
+func NewEngine() *Engine {
+	e := &Engine{
+		mu: sync.Mutex{},
+
+		services: make(map[string]swarmtypes.Service),
+		networks: make(map[string]network.Summary),
+		configs:  make(map[string]swarmtypes.Config),
+		secrets:  make(map[string]swarmtypes.Secret),
+
+		failures: make(map[string]bool),
+		lastID:   0,
+		requests: 0,
+
+		mux: http.NewServeMux(),
+	}
+
+	e.mux.HandleFunc("GET /services", e.listServices)
+	e.mux.HandleFunc("POST /services/create", e.createService)
+	e.mux.HandleFunc("GET /services/{id}", e.inspectService)
+	e.mux.HandleFunc("POST /services/{id}/update", e.updateService)
+	e.mux.HandleFunc("DELETE /services/{id}", e.removeService)
+	e.mux.HandleFunc("GET /networks", e.listNetworks)
+	e.mux.HandleFunc("POST /networks/create", e.createNetwork)
+	e.mux.HandleFunc("DELETE /networks/{id}", e.removeNetwork)
+	e.mux.HandleFunc("GET /configs", e.listConfigs)
+	e.mux.HandleFunc("POST /configs/create", e.createConfig)
+	e.mux.HandleFunc("DELETE /configs/{id}", e.removeConfig)
+	e.mux.HandleFunc("GET /secrets", e.listSecrets)
+	e.mux.HandleFunc("POST /secrets/create", e.createSecret)
+	e.mux.HandleFunc("DELETE /secrets/{id}", e.removeSecret)
+	e.mux.HandleFunc("GET /tasks", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, []swarmtypes.Task{})
+	})
+
+	return e
+}
+
+// Swarm returns a Swarm client of the engine.
+func (e *Engine) Swarm(tb testing.TB) *swarm.Swarm {
+	tb.Helper()
+
+	cli, err := client.New(
+		client.WithHost("tcp://docker.invalid:2375"),
+		client.WithAPIVersion(client.MaxAPIVersion),
+		client.WithHTTPClient(&http.Client{Transport: e}), //nolint:exhaustruct // defaults
+	)
+	if err != nil {
+		tb.Fatalf("failed to create Docker client: %v", err)
+	}
+	tb.Cleanup(func() { _ = cli.Close() })
+
+	return swarm.NewSwarm(cli, zap.NewNop())
+}
+
+// AddService adds a service and returns its ID.
+func (e *Engine) AddService(spec swarmtypes.ServiceSpec) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	id := e.nextID("service")
+	var service swarmtypes.Service
+	service.ID = id
+	service.Version.Index = 1
+	service.Spec = spec
+	e.services[id] = service
+
+	return id
+}
+
+// AddNetwork adds a network and returns its ID.
+func (e *Engine) AddNetwork(name string, labels map[string]string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	id := e.nextID("network")
+	var n network.Summary
+	n.ID = id
+	n.Name = name
+	n.Scope = "swarm"
+	n.Labels = labels
+	e.networks[id] = n
+
+	return id
+}
+
+// AddConfig adds a config and returns its ID.
+func (e *Engine) AddConfig(spec swarmtypes.ConfigSpec) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	id := e.nextID("config")
+	var c swarmtypes.Config
+	c.ID = id
+	c.Version.Index = 1
+	c.Spec = spec
+	e.configs[id] = c
+
+	return id
+}
+
+// AddSecret adds a secret and returns its ID.
+func (e *Engine) AddSecret(spec swarmtypes.SecretSpec) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	id := e.nextID("secret")
+	var s swarmtypes.Secret
+	s.ID = id
+	s.Version.Index = 1
+	s.Spec = spec
+	e.secrets[id] = s
+
+	return id
+}
+
+// RemoveService removes the service with the given name.
+func (e *Engine) RemoveService(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for id, s := range e.services {
+		if s.Spec.Name == name {
+			delete(e.services, id)
+		}
+	}
+}
+
+// FailCreate makes the creation of the service, network, config or secret
+// with the given name fail.
+func (e *Engine) FailCreate(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.failures[name] = true
+}
+
+// Services returns the services by name.
+func (e *Engine) Services() map[string]swarmtypes.Service {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	services := make(map[string]swarmtypes.Service, len(e.services))
+	for _, s := range e.services {
+		services[s.Spec.Name] = s
+	}
+
+	return services
+}
+
+// Networks returns the sorted names of the networks.
+func (e *Engine) Networks() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return sortedNames(e.networks, func(n network.Summary) string { return n.Name })
+}
+
+// Configs returns the sorted names of the configs.
+func (e *Engine) Configs() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return sortedNames(e.configs, func(c swarmtypes.Config) string { return c.Spec.Name })
+}
+
+// Secrets returns the sorted names of the secrets.
+func (e *Engine) Secrets() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return sortedNames(e.secrets, func(s swarmtypes.Secret) string { return s.Spec.Name })
+}
+
+// Requests returns the number of requests served.
+func (e *Engine) Requests() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.requests
+}
+
+// RoundTrip implements http.RoundTripper, serving requests in process.
+func (e *Engine) RoundTrip(r *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, r)
+
+	resp := rec.Result()
+	resp.Request = r
+	return resp, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (e *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.requests++
+
+	r.URL.Path = apiVersionPrefix.ReplaceAllString(r.URL.Path, "")
+	e.mux.ServeHTTP(w, r)
+}
+
+func (e *Engine) listServices(w http.ResponseWriter, r *http.Request) {
+	f, err := parseFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items := make([]swarmtypes.Service, 0, len(e.services))
+	for _, id := range slices.Sorted(maps.Keys(e.services)) {
+		if s := e.services[id]; f.match(s.Spec.Name, s.Spec.Labels) {
+			items = append(items, s)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (e *Engine) createService(w http.ResponseWriter, r *http.Request) {
+	var spec swarmtypes.ServiceSpec
+	if !decode(w, r, &spec) || !e.creatable(w, spec.Name, func(id string) bool {
+		return e.services[id].Spec.Name == spec.Name
+	}) {
+		return
+	}
+
+	id := e.nextID("service")
+	var service swarmtypes.Service
+	service.ID = id
+	service.Version.Index = 1
+	service.Spec = spec
+	e.services[id] = service
+
+	writeJSON(w, http.StatusCreated, swarmtypes.ServiceCreateResponse{ID: id, Warnings: nil})
+}
+
+func (e *Engine) inspectService(w http.ResponseWriter, r *http.Request) {
+	service, ok := e.services[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "service not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, service)
+}
+
+func (e *Engine) updateService(w http.ResponseWriter, r *http.Request) {
+	service, ok := e.services[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "service not found")
+		return
+	}
+
+	if version := r.URL.Query().Get("version"); version != strconv.FormatUint(service.Version.Index, 10) {
+		writeError(w, http.StatusConflict, "update out of sequence")
+		return
+	}
+
+	var spec swarmtypes.ServiceSpec
+	if !decode(w, r, &spec) {
+		return
+	}
+
+	previous := service.Spec
+	if r.URL.Query().Get("rollback") == "previous" {
+		if service.PreviousSpec == nil {
+			writeError(w, http.StatusBadRequest, "service has no previous spec")
+			return
+		}
+		spec = *service.PreviousSpec
+	}
+
+	service.PreviousSpec = &previous
+	service.Spec = spec
+	service.Version.Index++
+	e.services[service.ID] = service
+
+	writeJSON(w, http.StatusOK, swarmtypes.ServiceUpdateResponse{Warnings: nil})
+}
+
+func (e *Engine) removeService(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, ok := e.services[id]; !ok {
+		writeError(w, http.StatusNotFound, "service not found")
+		return
+	}
+
+	delete(e.services, id)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (e *Engine) listNetworks(w http.ResponseWriter, r *http.Request) {
+	f, err := parseFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items := make([]network.Summary, 0, len(e.networks))
+	for _, id := range slices.Sorted(maps.Keys(e.networks)) {
+		if n := e.networks[id]; f.match(n.Name, n.Labels) {
+			items = append(items, n)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (e *Engine) createNetwork(w http.ResponseWriter, r *http.Request) {
+	var req network.CreateRequest
+	if !decode(w, r, &req) || !e.creatable(w, req.Name, func(id string) bool {
+		return e.networks[id].Name == req.Name
+	}) {
+		return
+	}
+
+	id := e.nextID("network")
+	var n network.Summary
+	n.ID = id
+	n.Name = req.Name
+	n.Driver = req.Driver
+	n.Scope = req.Scope
+	n.Internal = req.Internal
+	n.Attachable = req.Attachable
+	n.Options = req.Options
+	n.Labels = req.Labels
+	e.networks[id] = n
+
+	writeJSON(w, http.StatusCreated, network.CreateResponse{ID: id, Warning: ""})
+}
+
+func (e *Engine) removeNetwork(w http.ResponseWriter, r *http.Request) {
+	n, ok := e.networks[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "network not found")
+		return
+	}
+
+	if e.inUse(func(s swarmtypes.ServiceSpec) bool {
+		return slices.ContainsFunc(s.TaskTemplate.Networks, func(a swarmtypes.NetworkAttachmentConfig) bool {
+			return a.Target == n.ID || a.Target == n.Name
+		})
+	}) {
+		writeError(w, http.StatusConflict, "network is in use")
+		return
+	}
+
+	delete(e.networks, n.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *Engine) listConfigs(w http.ResponseWriter, r *http.Request) {
+	f, err := parseFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items := make([]swarmtypes.Config, 0, len(e.configs))
+	for _, id := range slices.Sorted(maps.Keys(e.configs)) {
+		if c := e.configs[id]; f.match(c.Spec.Name, c.Spec.Labels) {
+			items = append(items, c)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (e *Engine) createConfig(w http.ResponseWriter, r *http.Request) {
+	var spec swarmtypes.ConfigSpec
+	if !decode(w, r, &spec) || !e.creatable(w, spec.Name, func(id string) bool {
+		return e.configs[id].Spec.Name == spec.Name
+	}) {
+		return
+	}
+
+	id := e.nextID("config")
+	var c swarmtypes.Config
+	c.ID = id
+	c.Version.Index = 1
+	c.Spec = spec
+	e.configs[id] = c
+
+	writeJSON(w, http.StatusCreated, swarmtypes.ConfigCreateResponse{ID: id})
+}
+
+func (e *Engine) removeConfig(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, ok := e.configs[id]; !ok {
+		writeError(w, http.StatusNotFound, "config not found")
+		return
+	}
+
+	if e.inUse(func(s swarmtypes.ServiceSpec) bool {
+		return slices.ContainsFunc(s.TaskTemplate.ContainerSpec.Configs, func(c *swarmtypes.ConfigReference) bool {
+			return c.ConfigID == id
+		})
+	}) {
+		writeError(w, http.StatusConflict, "config is in use")
+		return
+	}
+
+	delete(e.configs, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *Engine) listSecrets(w http.ResponseWriter, r *http.Request) {
+	f, err := parseFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items := make([]swarmtypes.Secret, 0, len(e.secrets))
+	for _, id := range slices.Sorted(maps.Keys(e.secrets)) {
+		if s := e.secrets[id]; f.match(s.Spec.Name, s.Spec.Labels) {
+			items = append(items, s)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (e *Engine) createSecret(w http.ResponseWriter, r *http.Request) {
+	var spec swarmtypes.SecretSpec
+	if !decode(w, r, &spec) || !e.creatable(w, spec.Name, func(id string) bool {
+		return e.secrets[id].Spec.Name == spec.Name
+	}) {
+		return
+	}
+
+	id := e.nextID("secret")
+	var s swarmtypes.Secret
+	s.ID = id
+	s.Version.Index = 1
+	s.Spec = spec
+	e.secrets[id] = s
+
+	writeJSON(w, http.StatusCreated, swarmtypes.ConfigCreateResponse{ID: id})
+}
+
+func (e *Engine) removeSecret(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, ok := e.secrets[id]; !ok {
+		writeError(w, http.StatusNotFound, "secret not found")
+		return
+	}
+
+	if e.inUse(func(s swarmtypes.ServiceSpec) bool {
+		return slices.ContainsFunc(s.TaskTemplate.ContainerSpec.Secrets, func(c *swarmtypes.SecretReference) bool {
+			return c.SecretID == id
+		})
+	}) {
+		writeError(w, http.StatusConflict, "secret is in use")
+		return
+	}
+
+	delete(e.secrets, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// creatable reports whether a resource named name may be created, writing an
+// error response otherwise. exists reports whether the resource with the
+// given ID has the same name.
+func (e *Engine) creatable(w http.ResponseWriter, name string, exists func(id string) bool) bool {
+	if e.failures[name] {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create %q", name))
+		return false
+	}
+
+	for _, ids := range [][]string{
+		slices.Collect(maps.Keys(e.services)),
+		slices.Collect(maps.Keys(e.networks)),
+		slices.Collect(maps.Keys(e.configs)),
+		slices.Collect(maps.Keys(e.secrets)),
+	} {
+		if slices.ContainsFunc(ids, exists) {
+			writeError(w, http.StatusConflict, fmt.Sprintf("name %q is already in use", name))
+			return false
+		}
+	}
+
+	return true
+}
+
+// inUse reports whether a service spec matches used.
+func (e *Engine) inUse(used func(swarmtypes.ServiceSpec) bool) bool {
+	for _, s := range e.services {
+		if s.Spec.TaskTemplate.ContainerSpec != nil && used(s.Spec) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e *Engine) nextID(kind string) string {
+	e.lastID++
+	return fmt.Sprintf("%s-%d", kind, e.lastID)
+}
+
+// filters are the list filters of the Engine API.
+type filters map[string]map[string]bool
+
+func parseFilters(r *http.Request) (filters, error) {
+	f := make(filters)
+
+	raw := r.URL.Query().Get("filters")
+	if raw == "" {
+		return f, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), &f); err != nil {
+		return nil, fmt.Errorf("invalid filters: %w", err)
+	}
+
+	return f, nil
+}
+
+// match reports whether a resource matches all label filters and, if any, at
+// least one name filter. Names match by prefix, like in the Engine.
+func (f filters) match(name string, labels map[string]string) bool {
+	for selector := range f["label"] {
+		key, value, hasValue := strings.Cut(selector, "=")
+		actual, ok := labels[key]
+		if !ok || (hasValue && actual != value) {
+			return false
+		}
+	}
+
+	if len(f["name"]) == 0 {
+		return true
+	}
+
+	for prefix := range f["name"] {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedNames[T any](items map[string]T, name func(T) string) []string {
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, name(item))
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	return true
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
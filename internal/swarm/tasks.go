@@ -0,0 +1,25 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/moby/moby/api/types/swarm"
+	"github.com/moby/moby/client"
+	"go.uber.org/zap"
+)
+
+// ListTasks lists tasks matching filters.
+func (s *Swarm) ListTasks(ctx context.Context, filters client.Filters) ([]swarm.Task, error) {
+	s.logger.Debug("Listing tasks")
+
+	result, err := s.client.TaskList(ctx, client.TaskListOptions{
+		Filters: filters,
+	})
+	if err != nil {
+		s.logger.Error("Failed to list tasks", zap.Error(err))
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	return result.Items, nil
+}
@@ -0,0 +1,38 @@
+package webhooks
+
+import (
+	"github.com/google/uuid"
+)
+
+type Provider string
+
+const (
+	ProviderGitHub  Provider = "github"
+	ProviderGitLab  Provider = "gitlab"
+	ProviderGitea   Provider = "gitea"
+	ProviderForgejo Provider = "forgejo"
+)
+
+type ResultStatus string
+
+const (
+	ResultTriggered ResultStatus = "triggered" // Deployment was triggered
+	ResultSkipped   ResultStatus = "skipped"   // Stack is inactive
+	ResultFailed    ResultStatus = "failed"    // Deployment could not be triggered
+)
+
+// Push describes a branch push.
+type Push struct {
+	Branch   string   // Pushed branch
+	Commit   string   // Head commit after the push
+	RepoURLs []string // Clone and web URLs of the repository
+}
+
+// Result is the outcome of a webhook for a single stack.
+type Result struct {
+	StackID      uuid.UUID
+	StackName    string
+	Status       ResultStatus
+	DeploymentID *uuid.UUID
+	Error        string
+}
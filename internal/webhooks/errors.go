@@ -0,0 +1,9 @@
+package webhooks
+
+import "errors"
+
+var (
+	ErrUnsupportedProvider = errors.New("unsupported webhook provider")
+	ErrInvalidPayload      = errors.New("invalid webhook payload")
+	ErrUnauthorized        = errors.New("webhook signature does not match")
+)
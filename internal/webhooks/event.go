@@ -0,0 +1,153 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+const (
+	branchRefPrefix = "refs/heads/"
+	zeroSHA         = "0000000000000000000000000000000000000000"
+)
+
+// Event is an inbound webhook request.
+type Event struct {
+	Provider Provider
+	Type     string // Provider specific event name
+	Push     *Push  // Set for branch pushes only
+
+	signature string
+	body      []byte
+}
+
+// ParseEvent detects the provider from the request headers and parses the
+// payload. header returns the value of the named request header.
+//
+// Events other than branch pushes are returned with a nil Push.
+func ParseEvent(header func(key string) string, body []byte) (*Event, error) {
+	event := &Event{
+		Provider:  "",
+		Type:      "",
+		Push:      nil,
+		signature: "",
+		body:      body,
+	}
+
+	// Gitea and Forgejo also send GitHub compatible headers, so they must be
+	// checked first.
+	switch {
+	case header("X-Forgejo-Event") != "":
+		event.Provider = ProviderForgejo
+		event.Type = header("X-Forgejo-Event")
+		event.signature = header("X-Forgejo-Signature")
+	case header("X-Gitea-Event") != "":
+		event.Provider = ProviderGitea
+		event.Type = header("X-Gitea-Event")
+		event.signature = header("X-Gitea-Signature")
+	case header("X-GitHub-Event") != "":
+		event.Provider = ProviderGitHub
+		event.Type = header("X-GitHub-Event")
+		event.signature = strings.TrimPrefix(header("X-Hub-Signature-256"), "sha256=")
+	case header("X-Gitlab-Event") != "":
+		event.Provider = ProviderGitLab
+		event.Type = header("X-Gitlab-Event")
+		event.signature = header("X-Gitlab-Token")
+	default:
+		return nil, ErrUnsupportedProvider
+	}
+
+	push, err := parsePush(event.Provider, event.Type, body)
+	if err != nil {
+		return nil, err
+	}
+	event.Push = push
+
+	return event, nil
+}
+
+// Verify reports whether the event was signed with secret. An empty secret
+// never verifies.
+func (e *Event) Verify(secret string) bool {
+	if secret == "" || e.signature == "" {
+		return false
+	}
+
+	if e.Provider == ProviderGitLab {
+		return subtle.ConstantTimeCompare([]byte(e.signature), []byte(secret)) == 1
+	}
+
+	expected, err := hex.DecodeString(e.signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(e.body)
+
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+type repositoryPayload struct {
+	CloneURL string `json:"clone_url"`
+	SSHURL   string `json:"ssh_url"`
+	HTMLURL  string `json:"html_url"`
+
+	// GitLab
+	GitHTTPURL string `json:"git_http_url"`
+	GitSSHURL  string `json:"git_ssh_url"`
+	WebURL     string `json:"web_url"`
+}
+
+type pushPayload struct {
+	Ref        string             `json:"ref"`
+	After      string             `json:"after"`
+	Deleted    bool               `json:"deleted"`
+	Repository repositoryPayload  `json:"repository"`
+	Project    *repositoryPayload `json:"project"` // GitLab
+}
+
+func parsePush(provider Provider, eventType string, body []byte) (*Push, error) {
+	isPush := eventType == "push"
+	if provider == ProviderGitLab {
+		isPush = eventType == "Push Hook"
+	}
+	if !isPush {
+		return nil, nil //nolint:nilnil // not a push event
+	}
+
+	payload := new(pushPayload)
+	if err := json.Unmarshal(body, payload); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+
+	// Tag pushes and branch deletions do not trigger deployments.
+	if !strings.HasPrefix(payload.Ref, branchRefPrefix) || payload.Deleted || payload.After == zeroSHA {
+		return nil, nil //nolint:nilnil // not a branch push
+	}
+
+	repo := payload.Repository
+	if payload.Project != nil {
+		repo = *payload.Project
+	}
+
+	urls := lo.Compact([]string{
+		repo.CloneURL, repo.SSHURL, repo.HTMLURL,
+		repo.GitHTTPURL, repo.GitSSHURL, repo.WebURL,
+	})
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("%w: repository url is missing", ErrInvalidPayload)
+	}
+
+	return &Push{
+		Branch:   strings.TrimPrefix(payload.Ref, branchRefPrefix),
+		Commit:   payload.After,
+		RepoURLs: urls,
+	}, nil
+}
@@ -0,0 +1,379 @@
+package webhooks_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // signs a payload with the wrong algorithm
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/apiarycd/apiarycd/internal/webhooks"
+)
+
+const (
+	secret = "s3cret"
+	commit = "9fceb02d0ae598e95dc970b74767f19372d61af8"
+
+	githubPush = `{
+		"ref": "refs/heads/main",
+		"after": "` + commit + `",
+		"deleted": false,
+		"repository": {
+			"clone_url": "https://github.com/org/repo.git",
+			"ssh_url": "git@github.com:org/repo.git",
+			"html_url": "https://github.com/org/repo"
+		}
+	}`
+)
+
+func sign(newHash func() hash.Hash, key, body string) string {
+	mac := hmac.New(newHash, []byte(key))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// pushBody returns a GitHub push payload of the repository
+// https://github.com/org/repo.git.
+func pushBody(ref string, deleted bool) string {
+	return fmt.Sprintf(
+		`{"ref":%q,"after":%q,"deleted":%t,"repository":{"clone_url":"https://github.com/org/repo.git"}}`,
+		ref, commit, deleted,
+	)
+}
+
+func headers(pairs ...string) func(string) string {
+	h := make(http.Header)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		h.Set(pairs[i], pairs[i+1])
+	}
+	return h.Get
+}
+
+func TestParseEventProvider(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		header   func(string) string
+		want     webhooks.Provider
+		wantType string
+		wantErr  error
+	}{
+		{
+			name:     "forgejo before gitea and github",
+			header:   headers("X-Forgejo-Event", "push", "X-Gitea-Event", "push", "X-GitHub-Event", "push"),
+			want:     webhooks.ProviderForgejo,
+			wantType: "push",
+		},
+		{
+			name:     "gitea before github",
+			header:   headers("X-Gitea-Event", "push", "X-GitHub-Event", "push"),
+			want:     webhooks.ProviderGitea,
+			wantType: "push",
+		},
+		{
+			name:     "github",
+			header:   headers("X-GitHub-Event", "ping"),
+			want:     webhooks.ProviderGitHub,
+			wantType: "ping",
+		},
+		{
+			name:     "gitlab",
+			header:   headers("X-Gitlab-Event", "Push Hook"),
+			want:     webhooks.ProviderGitLab,
+			wantType: "Push Hook",
+		},
+		{
+			name:    "unknown",
+			header:  headers("X-Bitbucket-Event", "repo:push"),
+			wantErr: webhooks.ErrUnsupportedProvider,
+		},
+		{
+			name:    "no headers",
+			header:  headers(),
+			wantErr: webhooks.ErrUnsupportedProvider,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			event, err := webhooks.ParseEvent(tt.header, []byte(githubPush))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseEvent() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if event.Provider != tt.want || event.Type != tt.wantType {
+				t.Errorf("ParseEvent() = %s %q, want %s %q", event.Provider, event.Type, tt.want, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestEventVerify(t *testing.T) {
+	t.Parallel()
+
+	valid := sign(sha256.New, secret, githubPush)
+
+	tests := []struct {
+		name   string
+		header func(string) string
+		secret string
+		want   bool
+	}{
+		{
+			name:   "github",
+			header: headers("X-GitHub-Event", "push", "X-Hub-Signature-256", "sha256="+valid),
+			secret: secret,
+			want:   true,
+		},
+		{
+			name:   "github with another secret",
+			header: headers("X-GitHub-Event", "push", "X-Hub-Signature-256", "sha256="+sign(sha256.New, "other", githubPush)),
+			secret: secret,
+			want:   false,
+		},
+		{
+			name:   "github with the signature of another payload",
+			header: headers("X-GitHub-Event", "push", "X-Hub-Signature-256", "sha256="+sign(sha256.New, secret, "{}")),
+			secret: secret,
+			want:   false,
+		},
+		{
+			name:   "github without signature",
+			header: headers("X-GitHub-Event", "push"),
+			secret: secret,
+			want:   false,
+		},
+		{
+			name: "github with sha1 only",
+			header: headers(
+				"X-GitHub-Event", "push",
+				"X-Hub-Signature", "sha1="+sign(sha1.New, secret, githubPush),
+			),
+			secret: secret,
+			want:   false,
+		},
+		{
+			name: "github with sha1 in the sha256 header",
+			header: headers(
+				"X-GitHub-Event", "push",
+				"X-Hub-Signature-256", "sha1="+sign(sha1.New, secret, githubPush),
+			),
+			secret: secret,
+			want:   false,
+		},
+		{
+			name: "github with a sha1 digest",
+			header: headers(
+				"X-GitHub-Event", "push",
+				"X-Hub-Signature-256", "sha256="+sign(sha1.New, secret, githubPush),
+			),
+			secret: secret,
+			want:   false,
+		},
+		{
+			name:   "github with a malformed signature",
+			header: headers("X-GitHub-Event", "push", "X-Hub-Signature-256", "sha256=not-hex"),
+			secret: secret,
+			want:   false,
+		},
+		{
+			name:   "stack without secret",
+			header: headers("X-GitHub-Event", "push", "X-Hub-Signature-256", "sha256="+sign(sha256.New, "", githubPush)),
+			secret: "",
+			want:   false,
+		},
+		{
+			name:   "gitea",
+			header: headers("X-Gitea-Event", "push", "X-Gitea-Signature", valid),
+			secret: secret,
+			want:   true,
+		},
+		{
+			name:   "gitea with github signature only",
+			header: headers("X-Gitea-Event", "push", "X-Hub-Signature-256", "sha256="+valid),
+			secret: secret,
+			want:   false,
+		},
+		{
+			name:   "forgejo",
+			header: headers("X-Forgejo-Event", "push", "X-Forgejo-Signature", valid),
+			secret: secret,
+			want:   true,
+		},
+		{
+			name:   "forgejo with another secret",
+			header: headers("X-Forgejo-Event", "push", "X-Forgejo-Signature", sign(sha256.New, "other", githubPush)),
+			secret: secret,
+			want:   false,
+		},
+		{
+			name:   "gitlab",
+			header: headers("X-Gitlab-Event", "Push Hook", "X-Gitlab-Token", secret),
+			secret: secret,
+			want:   true,
+		},
+		{
+			name:   "gitlab with another token",
+			header: headers("X-Gitlab-Event", "Push Hook", "X-Gitlab-Token", secret+"x"),
+			secret: secret,
+			want:   false,
+		},
+		{
+			name:   "gitlab with a signature instead of the token",
+			header: headers("X-Gitlab-Event", "Push Hook", "X-Gitlab-Token", valid),
+			secret: secret,
+			want:   false,
+		},
+		{
+			name:   "gitlab without token",
+			header: headers("X-Gitlab-Event", "Push Hook"),
+			secret: secret,
+			want:   false,
+		},
+		{
+			name:   "gitlab and stack without secret",
+			header: headers("X-Gitlab-Event", "Push Hook", "X-Gitlab-Token", ""),
+			secret: "",
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			event, err := webhooks.ParseEvent(tt.header, []byte(githubPush))
+			if err != nil {
+				t.Fatalf("ParseEvent() error = %v", err)
+			}
+
+			if got := event.Verify(tt.secret); got != tt.want {
+				t.Errorf("Verify() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEventPush(t *testing.T) {
+	t.Parallel()
+
+	github := headers("X-GitHub-Event", "push")
+	gitlab := headers("X-Gitlab-Event", "Push Hook")
+
+	tests := []struct {
+		name    string
+		header  func(string) string
+		body    string
+		want    *webhooks.Push
+		wantErr error
+	}{
+		{
+			name:   "branch push",
+			header: github,
+			body:   githubPush,
+			want: &webhooks.Push{
+				Branch: "main",
+				Commit: commit,
+				RepoURLs: []string{
+					"https://github.com/org/repo.git",
+					"git@github.com:org/repo.git",
+					"https://github.com/org/repo",
+				},
+			},
+		},
+		{
+			name:   "branch with a slash",
+			header: github,
+			body:   pushBody("refs/heads/release/1.0", false),
+			want: &webhooks.Push{
+				Branch:   "release/1.0",
+				Commit:   commit,
+				RepoURLs: []string{"https://github.com/org/repo.git"},
+			},
+		},
+		{
+			name:   "gitlab push",
+			header: gitlab,
+			body: `{"ref":"refs/heads/main","after":"` + commit + `","project":{` +
+				`"git_http_url":"https://gitlab.com/org/repo.git","git_ssh_url":"git@gitlab.com:org/repo.git"}}`,
+			want: &webhooks.Push{
+				Branch:   "main",
+				Commit:   commit,
+				RepoURLs: []string{"https://gitlab.com/org/repo.git", "git@gitlab.com:org/repo.git"},
+			},
+		},
+		{
+			name:   "tag push",
+			header: github,
+			body:   pushBody("refs/tags/v1.0.0", false),
+		},
+		{
+			name:   "gitlab tag push",
+			header: headers("X-Gitlab-Event", "Tag Push Hook"),
+			body: `{"ref":"refs/tags/v1.0.0","after":"` + commit + `",` +
+				`"project":{"git_http_url":"https://gitlab.com/org/repo.git"}}`,
+		},
+		{
+			name:   "branch deletion",
+			header: github,
+			body:   pushBody("refs/heads/main", true),
+		},
+		{
+			name:   "zero commit",
+			header: gitlab,
+			body: `{"ref":"refs/heads/main","after":"0000000000000000000000000000000000000000",` +
+				`"project":{"git_http_url":"https://gitlab.com/org/repo.git"}}`,
+		},
+		{
+			name:   "other event",
+			header: headers("X-GitHub-Event", "ping"),
+			body:   `{"zen":"Keep it logically awesome."}`,
+		},
+		{
+			name:    "malformed payload",
+			header:  github,
+			body:    `{"ref":`,
+			wantErr: webhooks.ErrInvalidPayload,
+		},
+		{
+			name:    "repository url missing",
+			header:  github,
+			body:    `{"ref":"refs/heads/main","after":"` + commit + `","repository":{}}`,
+			wantErr: webhooks.ErrInvalidPayload,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			event, err := webhooks.ParseEvent(tt.header, []byte(tt.body))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseEvent() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			got := event.Push
+			switch {
+			case got == nil && tt.want == nil:
+			case got == nil || tt.want == nil:
+				t.Fatalf("ParseEvent() push = %+v, want %+v", got, tt.want)
+			case got.Branch != tt.want.Branch || got.Commit != tt.want.Commit || !slices.Equal(got.RepoURLs, tt.want.RepoURLs):
+				t.Errorf("ParseEvent() push = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
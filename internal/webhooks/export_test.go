@@ -0,0 +1,6 @@
+package webhooks
+
+// NormalizeURL exposes normalizeURL to the tests.
+func NormalizeURL(raw string) string {
+	return normalizeURL(raw)
+}
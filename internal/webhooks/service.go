@@ -0,0 +1,114 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apiarycd/apiarycd/internal/deployments"
+	"github.com/apiarycd/apiarycd/internal/stacks"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	stacksSvc      *stacks.Service
+	deploymentsSvc *deployments.Service
+
+	logger *zap.Logger
+}
+
+func NewService(stacksSvc *stacks.Service, deploymentsSvc *deployments.Service, logger *zap.Logger) *Service {
+	return &Service{
+		stacksSvc:      stacksSvc,
+		deploymentsSvc: deploymentsSvc,
+
+		logger: logger,
+	}
+}
+
+// HandlePush triggers a deployment of the pushed commit for every stack that
+// tracks the pushed repository and branch and whose webhook secret matches the
+// event. Stacks whose secret does not match are left out of the results, so
+// callers cannot learn which stacks track a repository; ErrUnauthorized is
+// returned if no stack matches at all.
+func (s *Service) HandlePush(ctx context.Context, event *Event) ([]Result, error) {
+	push := event.Push
+	if push == nil {
+		return []Result{}, nil
+	}
+
+	logger := s.logger.With(
+		zap.String("provider", string(event.Provider)),
+		zap.String("branch", push.Branch),
+		zap.String("commit", push.Commit),
+	)
+	logger.Info("push received", zap.Strings("urls", push.RepoURLs))
+
+	items, err := s.stacksSvc.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stacks: %w", err)
+	}
+
+	urls := lo.SliceToMap(push.RepoURLs, func(u string) (string, struct{}) { return normalizeURL(u), struct{}{} })
+
+	results := make([]Result, 0)
+	unauthorized := 0
+	for _, stack := range items {
+		if stack.GitBranch != push.Branch {
+			continue
+		}
+		if _, ok := urls[normalizeURL(stack.GitURL)]; !ok {
+			continue
+		}
+
+		if !event.Verify(stack.WebhookSecret) {
+			logger.Warn("webhook signature mismatch", zap.String("stack_id", stack.ID.String()))
+			unauthorized++
+			continue
+		}
+
+		results = append(results, s.handleStack(ctx, push, &stack, logger))
+	}
+
+	if len(results) == 0 && unauthorized > 0 {
+		return nil, ErrUnauthorized
+	}
+
+	logger.Info("push handled", zap.Int("stacks", len(results)), zap.Int("unauthorized", unauthorized))
+
+	return results, nil
+}
+
+func (s *Service) handleStack(ctx context.Context, push *Push, stack *stacks.Stack, logger *zap.Logger) Result {
+	logger = logger.With(zap.String("stack_id", stack.ID.String()))
+
+	result := Result{
+		StackID:      stack.ID,
+		StackName:    stack.Name,
+		Status:       "",
+		DeploymentID: nil,
+		Error:        "",
+	}
+
+	if stack.Status == stacks.StatusInactive {
+		result.Status = ResultSkipped
+		return result
+	}
+
+	deployment, err := s.deploymentsSvc.Trigger(ctx, deployments.DeploymentRequest{
+		StackID:   stack.ID,
+		Ref:       push.Commit,
+		Variables: nil,
+	})
+	if err != nil {
+		logger.Error("failed to trigger deployment", zap.Error(err))
+		result.Status = ResultFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = ResultTriggered
+	result.DeploymentID = &deployment.ID
+
+	return result
+}
@@ -0,0 +1,27 @@
+package webhooks
+
+import (
+	"net/url"
+	"strings"
+)
+
+// normalizeURL reduces a repository URL to "host/path" so that the HTTPS, SSH
+// and scp-like forms of the same repository compare equal.
+func normalizeURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+
+	var host, path string
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if at := strings.Index(raw, "@"); at >= 0 {
+		// scp-like syntax: user@host:path
+		host, path, _ = strings.Cut(raw[at+1:], ":")
+	} else {
+		host, path, _ = strings.Cut(raw, ":")
+	}
+
+	path = strings.Trim(path, "/")
+	path = strings.TrimSuffix(path, ".git")
+
+	return strings.ToLower(host + "/" + path)
+}